@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"strings"
+	"sync/atomic"
 
 	"github.com/ysugimoto/falco/token"
 )
@@ -76,9 +77,9 @@ func (m *Meta) InfixComment(cm combinationMode) string {
 var idCounter uint64
 
 func New(t token.Token, nest int, comments ...Comments) *Meta {
-	idCounter++
 	m := &Meta{
-		ID:       idCounter,
+		// Parsers may run concurrently (e.g parallel testing) so the counter must be incremented atomically
+		ID:       atomic.AddUint64(&idCounter, 1),
 		Token:    t,
 		Nest:     nest,
 		Leading:  Comments{},
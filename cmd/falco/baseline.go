@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/linter"
+)
+
+const (
+	baselineVersion     = 1
+	defaultBaselineFile = ".falco-baseline.json"
+)
+
+// Baseline records existing lint findings in order to report only new findings.
+// Findings are identified by fingerprint which is calculated from rule, file, message and source line content,
+// so that the finding survives line shifts
+type Baseline struct {
+	Version  int                `json:"version"`
+	Findings []*BaselineFinding `json:"findings"`
+
+	// Base directory of file paths in the baseline
+	dir string
+	// Remaining count for each fingerprint, decremented when matched
+	remains map[string]int
+}
+
+type BaselineFinding struct {
+	Rule        string `json:"rule"`
+	File        string `json:"file"`
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint"`
+	Count       int    `json:"count"`
+}
+
+func newBaseline(file string) *Baseline {
+	return &Baseline{
+		Version:  baselineVersion,
+		Findings: []*BaselineFinding{},
+		dir:      baselineDir(file),
+		remains:  make(map[string]int),
+	}
+}
+
+func loadBaseline(file string) (*Baseline, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read baseline file")
+	}
+	b := newBaseline(file)
+	if err := json.Unmarshal(buf, b); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse baseline file")
+	}
+	if b.Version != baselineVersion {
+		return nil, errors.Errorf("Unsupported baseline version: %d", b.Version)
+	}
+	for _, f := range b.Findings {
+		b.remains[f.Fingerprint] += f.Count
+	}
+	return b, nil
+}
+
+func baselineDir(file string) string {
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return filepath.Dir(file)
+	}
+	return dir
+}
+
+// Add lint error to the baseline, same findings are counted up
+func (b *Baseline) Add(le *linter.LintError, file, line string) {
+	rel := b.relative(file)
+	fp := fingerprint(le, rel, line)
+	if _, ok := b.remains[fp]; ok {
+		for _, f := range b.Findings {
+			if f.Fingerprint == fp {
+				f.Count++
+				break
+			}
+		}
+	} else {
+		b.Findings = append(b.Findings, &BaselineFinding{
+			Rule:        string(le.Rule),
+			File:        rel,
+			Message:     le.Message,
+			Fingerprint: fp,
+			Count:       1,
+		})
+	}
+	b.remains[fp]++
+}
+
+// Returns true if the lint error is recorded in the baseline.
+// Each finding matches up to recorded count so that newly added same findings are reported
+func (b *Baseline) Match(le *linter.LintError, file, line string) bool {
+	fp := fingerprint(le, b.relative(file), line)
+	if b.remains[fp] <= 0 {
+		return false
+	}
+	b.remains[fp]--
+	return true
+}
+
+func (b *Baseline) Write(file string) error {
+	sort.Slice(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		if x.Rule != y.Rule {
+			return x.Rule < y.Rule
+		}
+		return x.Fingerprint < y.Fingerprint
+	})
+	buf, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(file, append(buf, '\n'), 0o644))
+}
+
+// File path in the baseline is relative from the baseline file directory
+// in order to share the baseline between environments
+func (b *Baseline) relative(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		if rel, err := filepath.Rel(b.dir, abs); err == nil {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+func fingerprint(le *linter.LintError, file, line string) string {
+	h := sha256.New()
+	for _, v := range []string{string(le.Rule), file, le.Message, strings.TrimSpace(line)} {
+		h.Write([]byte(v)) // nolint:errcheck
+		h.Write([]byte{0}) // nolint:errcheck
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// Returns baseline file path. If writing baseline without path, default file is used
+func (r *Runner) baselineFile() string {
+	if file := r.config.Linter.Baseline; file != "" {
+		return file
+	}
+	if r.config.Linter.WriteBaseline {
+		return defaultBaselineFile
+	}
+	return ""
+}
+
+// Write recorded findings to the baseline file, returns count of recorded findings
+func (r *Runner) WriteBaseline() (int, error) {
+	if r.baseline == nil {
+		return 0, nil
+	}
+	var count int
+	for _, f := range r.baseline.Findings {
+		count += f.Count
+	}
+	return count, r.baseline.Write(r.baselineFile())
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/token"
+)
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, ".falco-baseline.json")
+	main := filepath.Join(dir, "vcl", "main.vcl")
+
+	unused := func(line int) *linter.LintError {
+		return (&linter.LintError{
+			Severity: linter.WARNING,
+			Token:    token.Token{Line: line, Position: 1, File: main},
+			Message:  `Unused table "foo"`,
+		}).Match(linter.UNUSED_DECLARATION)
+	}
+
+	b := newBaseline(file)
+	b.Add(unused(1), main, "table foo {")
+	b.Add(unused(10), main, "  table foo {")
+	if err := b.Write(file); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	loaded, err := loadBaseline(file)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if len(loaded.Findings) != 1 {
+		t.Errorf("Same findings should be recorded as one, got %d", len(loaded.Findings))
+		return
+	}
+	finding := loaded.Findings[0]
+	if finding.File != "vcl/main.vcl" || finding.Rule != "unused/declaration" || finding.Count != 2 {
+		t.Errorf("Unexpected finding: %+v", finding)
+	}
+
+	// Findings match even if lines are shifted, but only up to recorded count
+	if !loaded.Match(unused(5), main, "table foo {") {
+		t.Errorf("Expected shifted finding is matched")
+	}
+	if !loaded.Match(unused(20), main, "table foo {") {
+		t.Errorf("Expected shifted finding is matched")
+	}
+	if loaded.Match(unused(30), main, "table foo {") {
+		t.Errorf("Expected finding over recorded count is not matched")
+	}
+	// Findings on changed source line are treated as new ones
+	if loaded.Match(unused(1), main, "table bar {") {
+		t.Errorf("Expected finding on changed line is not matched")
+	}
+}
+
+func TestLoadBaselineError(t *testing.T) {
+	if _, err := loadBaseline(filepath.Join(t.TempDir(), "not_found.json")); err == nil {
+		t.Errorf("Expected error for missing baseline file")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/interpreter/coverage"
+)
+
+const (
+	lcovFilename      = "lcov.info"
+	coberturaFilename = "cobertura.xml"
+)
+
+// Display path relative from current working directory if possible
+func relativePath(file string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+	if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return file
+}
+
+func coverageColor(c coverage.Counter) *color.Color {
+	switch p := c.Percentage(); {
+	case p >= 80:
+		return green
+	case p >= 50:
+		return yellow
+	default:
+		return red
+	}
+}
+
+func printCoverageSummary(summary *coverage.Summary) {
+	format := func(c coverage.Counter) string {
+		return fmt.Sprintf("%6.2f%% (%d/%d)", c.Percentage(), c.Covered, c.Total)
+	}
+	printRow := func(name string, subs, stmts, branches coverage.Counter) {
+		write(white, "| %-40s | ", name)
+		write(coverageColor(subs), "%-20s", format(subs))
+		write(white, " | ")
+		write(coverageColor(stmts), "%-20s", format(stmts))
+		write(white, " | ")
+		write(coverageColor(branches), "%-20s", format(branches))
+		writeln(white, " |")
+	}
+	separator := strings.Repeat("-", 114)
+
+	writeln(white, separator)
+	writeln(white, "| %-40s | %-20s | %-20s | %-20s |", "File / Subroutine", "Subroutines", "Statements", "Branches")
+	writeln(white, separator)
+	for _, f := range summary.Files {
+		printRow(relativePath(f.Name), f.Subroutines, f.Statements, f.Branches)
+		for _, d := range f.Details {
+			called := coverage.Counter{Total: 1}
+			if d.Hits > 0 {
+				called.Covered = 1
+			}
+			printRow("  "+d.Name, called, d.Statements, d.Branches)
+		}
+		writeln(white, separator)
+	}
+	printRow("All files", summary.Subroutines, summary.Statements, summary.Branches)
+	writeln(white, separator)
+}
+
+// Write coverage reports in lcov and Cobertura format into the directory
+func writeCoverageReports(c *coverage.Collector, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	lcov, err := os.Create(filepath.Join(dir, lcovFilename))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer lcov.Close()
+	if err := c.WriteLcov(lcov); err != nil {
+		return errors.WithStack(err)
+	}
+
+	cobertura, err := os.Create(filepath.Join(dir, coberturaFilename))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer cobertura.Close()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.WriteCobertura(cobertura, cwd); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -13,8 +13,12 @@ func printHelp(cmd string) {
 		printSimulateHelp()
 	case subcommandDAP:
 		printDAPHelp()
+	case subcommandLSP:
+		printLSPHelp()
 	case subcommandStats:
 		printStatsHelp()
+	case subcommandGraph:
+		printGraphHelp()
 	case subcommandTest:
 		printTestHelp()
 	case subcommandLint:
@@ -49,8 +53,10 @@ Usage:
 Subcommands:
     lint      : Run lint (default)
     stats     : Analyze VCL statistics
+    graph     : Export subroutine call graph and state transitions
     simulate  : Run simulator server with provided VCLs
     dap       : Launch DAP server to debug VCLs
+    lsp       : Launch LSP server for editor integration
     test      : Run local testing for provided VCLs
     console   : Run terminal console
     fmt       : Run formatter for provided VCLs
@@ -80,6 +86,7 @@ Usage:
 Actions:
     lint     : Run lint (default)
     stats    : Analyze VCL statistics
+    graph    : Export subroutine call graph and state transitions
     simulate : Run simulator server with planned JSON
     test     : Run local testing for planned JSON
 
@@ -109,6 +116,22 @@ Execute this command by using your editor's DAP support.
 	`))
 }
 
+func printLSPHelp() {
+	writeln(white, strings.TrimSpace(`
+Usage:
+    falco lsp [flags] [main vcl file]
+
+Flags:
+    -I, --include_path : Add include path
+    -h, --help         : Show this help
+
+This command launches Language Server Protocol server.
+Execute this command by using your editor's LSP support.
+If main vcl file is specified, opened files are analyzed as a part of the main VCL
+so that included files are linted with the whole service context.
+	`))
+}
+
 func printSimulateHelp() {
 	writeln(white, strings.TrimSpace(`
 Usage:
@@ -119,9 +142,13 @@ Flags:
     -h, --help         : Show this help
     -r, --remote       : Connect with Fastly API
     --proxy            : Enable actual proxy behavior
+    --shield           : Enable multi-node simulation with the shield node
     -request           : Simulate request config
     -debug             : Enable debug mode
     --max_backends     : Override max backends limitation
+    --geoip_db         : Add GeoIP database file (.mmdb or .csv)
+    --record           : Record backend requests and responses to HAR file
+    --replay           : Serve backend responses from HAR file without network
     --max_acls         : Override max acls limitation
     --key              : Specify TLS server key file
     --cert             : Specify TLS cert file
@@ -144,9 +171,35 @@ Flags:
     -h, --help         : Show this help
     -r, --remote       : Connect with Fastly API
     -json              : Output results as JSON
+    --max_complexity   : Fail when cyclomatic complexity of a subroutine exceeds this value
+    --max_statements   : Fail when statement count of a subroutine exceeds this value
+    --max_depth        : Fail when max nesting depth of a subroutine exceeds this value
+    --max_fan_out      : Fail when a subroutine calls more subroutines than this value
 
 Get statistics example:
     falco stats -I . /path/to/vcl/main.vcl
+
+Fail on complex subroutines example:
+    falco stats -I . --max_complexity 20 /path/to/vcl/main.vcl
+	`))
+}
+
+func printGraphHelp() {
+	writeln(white, strings.TrimSpace(`
+Usage:
+    falco graph [flags] file
+
+Flags:
+    -I, --include_path : Add include path
+    -h, --help         : Show this help
+    -r, --remote       : Connect with Fastly API
+    --format           : Output format, "dot" (default) or "mermaid"
+
+Export Graphviz DOT example:
+    falco graph -I . /path/to/vcl/main.vcl | dot -Tsvg -o graph.svg
+
+Export Mermaid flowchart example:
+    falco graph -I . --format mermaid /path/to/vcl/main.vcl
 	`))
 }
 
@@ -164,8 +217,17 @@ Flags:
     -json              : Output results as JSON
     -request           : Override request config
     --max_backends     : Override max backends limitation
+    --geoip_db         : Add GeoIP database file (.mmdb or .csv)
+    --record           : Record backend requests and responses to HAR file
+    --replay           : Serve backend responses from HAR file without network
     --max_acls         : Override max acls limitation
     --watch            : Watch VCL file changes and run test
+    --coverage         : Report coverage and write lcov and Cobertura reports
+    --coverage_dir     : Output directory of coverage reports (default: coverage)
+    --parallel         : Number of test files running in parallel (default: 1)
+    --reporter         : Output format of test results, text, json, junit or tap (default: text)
+    --report_file      : Write json, junit or tap test report to the file instead of stdout
+    --update-snapshots : Record or overwrite snapshots by actual responses
 
 Local testing example:
     falco test -I . -I ./tests /path/to/vcl/main.vcl
@@ -185,6 +247,11 @@ Flags:
     -vv                : Output all lint results (very verbose)
     -json              : Output results as JSON (very verbose)
     --generated        : Lint for Fastly generated VCL
+    --security         : Enable security/* lint rules
+    --fix              : Fix problems automatically and overwrite VCL files
+    --format           : Output format of lint results, "text", "json" or "sarif"
+    --baseline         : Report only findings which are not recorded in the baseline file
+    --write-baseline   : Record all findings to the baseline file
 
 Simple linting with very verbose example:
     falco lint -I . -vv /path/to/vcl/main.vcl
@@ -24,6 +24,7 @@ import (
 	"github.com/ysugimoto/falco/dap"
 	ife "github.com/ysugimoto/falco/interpreter/function/errors"
 	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/lsp"
 	"github.com/ysugimoto/falco/remote"
 	"github.com/ysugimoto/falco/resolver"
 	"github.com/ysugimoto/falco/snippets"
@@ -57,7 +58,9 @@ const (
 	subcommandTerraform = "terraform"
 	subcommandSimulate  = "simulate"
 	subcommandDAP       = "dap"
+	subcommandLSP       = "lsp"
 	subcommandStats     = "stats"
+	subcommandGraph     = "graph"
 	subcommandTest      = "test"
 	subcommandConsole   = "console"
 	subcommandFormat    = "fmt"
@@ -97,8 +100,8 @@ func main() {
 			fetcher = terraform.NewTerraformFetcher(fastlyServices)
 		}
 		action = c.Commands.At(1)
-	case subcommandSimulate, subcommandLint, subcommandStats, subcommandTest:
-		// "lint", "simulate", "stats", and "test" command provides single file of service,
+	case subcommandSimulate, subcommandLint, subcommandStats, subcommandGraph, subcommandTest:
+		// "lint", "simulate", "stats", "graph", and "test" command provides single file of service,
 		// then resolvers size is always 1
 		resolvers, err = resolver.NewFileResolvers(c.Commands.At(1), c.IncludePaths)
 		action = c.Commands.At(0)
@@ -112,6 +115,16 @@ func main() {
 			os.Exit(1)
 		}
 		os.Exit(0)
+	case subcommandLSP:
+		server, err := lsp.New(c, c.Commands.At(1))
+		if err != nil {
+			writeln(red, err.Error())
+			os.Exit(1)
+		}
+		if err := server.Run(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
 	case subcommandFormat:
 		// "fmt" command accepts multiple target files
 		resolvers, err = resolver.NewGlobResolver(c.Commands[1:]...)
@@ -168,7 +181,11 @@ func main() {
 				}
 			}
 		}
-		runner := NewRunner(c, fetcher)
+		runner, err := NewRunner(c, fetcher)
+		if err != nil {
+			writeln(red, err.Error())
+			os.Exit(1)
+		}
 
 		var exitErr error
 		switch action {
@@ -183,6 +200,8 @@ func main() {
 			exitErr = runSimulate(runner, v)
 		case subcommandStats:
 			exitErr = runStats(runner, v)
+		case subcommandGraph:
+			exitErr = runGraph(runner, v)
 		case subcommandFormat:
 			exitErr = runFormat(runner, v)
 		default:
@@ -200,6 +219,20 @@ func main() {
 }
 
 func runLint(runner *Runner, rslv resolver.Resolver) error {
+	format := runner.config.Linter.Format
+	if runner.config.Json {
+		format = lintFormatJSON
+	}
+	switch format {
+	case lintFormatText:
+	case lintFormatJSON, lintFormatSARIF:
+		// Machine readable formats must output only its result like -json option
+		runner.config.Json = true
+	default:
+		writeln(red, "Unknown lint format: %s", format)
+		return ErrExit
+	}
+
 	result, err := runner.Run(rslv)
 	if err != nil {
 		if err != ErrParser {
@@ -208,18 +241,53 @@ func runLint(runner *Runner, rslv resolver.Resolver) error {
 		return ErrExit
 	}
 
-	if runner.config.Json {
+	switch format {
+	case lintFormatJSON:
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		if err := enc.Encode(result); err != nil {
 			writeln(red, err.Error())
 			return ErrExit
 		}
+	case lintFormatSARIF:
+		if err := writeSARIF(os.Stdout, result, runner.severities); err != nil {
+			writeln(red, err.Error())
+			return ErrExit
+		}
+	}
+
+	// When writing baseline, all findings are recorded as existing ones so exit successfully
+	if runner.config.Linter.WriteBaseline {
+		recorded, err := runner.WriteBaseline()
+		if err != nil {
+			writeln(red, "Failed to write baseline: %s", err.Error())
+			return ErrExit
+		}
+		if !runner.config.Json {
+			writeln(green, "%d findings are recorded to baseline file %s", recorded, runner.baselineFile())
+		}
+		return nil
+	}
+
+	if runner.config.Linter.Fix {
+		fixed, err := runner.Fix(result)
+		if err != nil {
+			writeln(red, "Failed to fix VCL: %s", err.Error())
+			return ErrExit
+		}
+		if !runner.config.Json {
+			writeln(green, ":wrench:%d problems are fixed.", fixed)
+		}
+	} else if fixable := runner.Fixable(); fixable > 0 && !runner.config.Json {
+		writeln(white, "%d problems are fixable with the --fix option.", fixable)
 	}
 
 	write(red, ":fire:%d errors, ", result.Errors)
 	write(yellow, ":exclamation:%d warnings, ", result.Warnings)
 	writeln(cyan, ":speaker:%d recommendations.", result.Infos)
+	if result.Baselined > 0 {
+		writeln(white, "%d findings are suppressed by baseline.", result.Baselined)
+	}
 
 	if result.Errors > 0 {
 		return ErrExit
@@ -252,6 +320,24 @@ func runSimulate(runner *Runner, rslv resolver.Resolver) error {
 	return nil
 }
 
+func runGraph(runner *Runner, rslv resolver.Resolver) error {
+	g, err := runner.Graph(rslv)
+	if err != nil {
+		if err != ErrParser {
+			writeln(red, err.Error())
+		}
+		return ErrExit
+	}
+
+	out, err := g.Render(runner.config.Graph.Format)
+	if err != nil {
+		writeln(red, err.Error())
+		return ErrExit
+	}
+	fmt.Fprint(os.Stdout, out)
+	return nil
+}
+
 func runStats(runner *Runner, rslv resolver.Resolver) error {
 	stats, err := runner.Stats(rslv)
 	if err != nil {
@@ -268,6 +354,9 @@ func runStats(runner *Runner, rslv resolver.Resolver) error {
 			writeln(red, err.Error())
 			return ErrExit
 		}
+		if len(stats.Violations) > 0 {
+			return ErrExit
+		}
 		return nil
 	}
 	printStats := func(format string, args ...interface{}) {
@@ -293,6 +382,33 @@ func runStats(runner *Runner, rslv resolver.Resolver) error {
 	printStats(strings.Repeat("-", 80))
 	printStats("| %-22s | %51d |", "Directors", stats.Directors)
 	printStats(strings.Repeat("-", 80))
+
+	if len(stats.SubroutineMetrics) > 0 {
+		printStats("")
+		printStats(strings.Repeat("=", 80))
+		printStats("| %-24s | %5s | %6s | %4s | %5s | %3s | %3s | %7s |", "Subroutine", "Stmts", "Branch", "CC", "Depth", "In", "Out", "Hdr R/W")
+		printStats(strings.Repeat("=", 80))
+		for _, m := range stats.SubroutineMetrics {
+			name := m.Name
+			if len(name) > 24 {
+				name = name[:23] + "~"
+			}
+			printStats(
+				"| %-24s | %5d | %6d | %4d | %5d | %3d | %3d | %7s |",
+				name, m.Statements, m.Branches, m.Complexity, m.MaxDepth, m.FanIn, m.FanOut,
+				fmt.Sprintf("%d/%d", m.HeaderReads, m.HeaderWrites),
+			)
+			printStats(strings.Repeat("-", 80))
+		}
+	}
+
+	if len(stats.Violations) > 0 {
+		writeln(white, "")
+		for _, v := range stats.Violations {
+			writeln(red, "Subroutine %s exceeds %s threshold: %d > %d", v.Subroutine, v.Metric, v.Value, v.Threshold)
+		}
+		return ErrExit
+	}
 	return nil
 }
 
@@ -370,30 +486,51 @@ func watchRunTest(runner *Runner, rslv resolver.Resolver) error {
 }
 
 func runTest(runner *Runner, rslv resolver.Resolver) error {
+	reporter := runner.config.Testing.Reporter
+	if runner.config.Json {
+		// -json option is kept for backward compatibility
+		reporter = reporterJSON
+	}
+	// Text report is colored for terminal so it could not be written to the file
+	if (reporter == reporterText || reporter == "") && runner.config.Testing.ReportFile != "" {
+		writeln(red, "--report_file could not be used with text reporter, use json, junit or tap reporter")
+		return ErrExit
+	}
+
 	factory, err := runner.Test(rslv)
 	if err != nil {
 		return ErrExit
 	}
 
-	if runner.config.Json {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(struct {
-			Tests   []*tester.TestResult `json:"tests"`
-			Summary *tester.TestCounter  `json:"summary"`
-		}{
-			Tests:   factory.Results,
-			Summary: factory.Statistics,
-		}); err != nil {
-			writeln(red, err.Error())
+	if factory.Coverage != nil {
+		if err := writeCoverageReports(factory.Coverage, runner.config.Testing.CoverageDir); err != nil {
+			writeln(red, "Failed to write coverage reports: %s", err)
 			return ErrExit
 		}
-		if factory.Statistics.Fails > 0 {
+	}
+
+	switch reporter {
+	case reporterText, "":
+		printTestResults(runner, factory)
+	case reporterJSON, reporterJUnit, reporterTAP:
+		if err := writeTestReport(runner, factory, reporter); err != nil {
+			writeln(red, "Failed to write test report: %s", err)
 			return ErrExit
 		}
-		return nil
+	default:
+		writeln(red, "Unknown reporter %s, must be one of text, json, junit or tap", reporter)
+		return ErrExit
 	}
 
+	if factory.Statistics.Fails > 0 {
+		return ErrExit
+	}
+	return nil
+}
+
+// Print test results with colored text
+// nolint: funlen
+func printTestResults(runner *Runner, factory *tester.TestFactory) {
 	// shorthand indent making
 	indent := func(level int) string {
 		return strings.Repeat(" ", level*2)
@@ -440,8 +577,11 @@ func runTest(runner *Runner, rslv resolver.Resolver) error {
 				writeln(red, "%s%s", indent(2), c.Error.Error())
 				switch e := c.Error.(type) {
 				case *ife.AssertionError:
-					write(white, "%sActual Value: ", indent(2))
-					writeln(red, "%s\n", e.Actual.String())
+					if e.Actual != nil {
+						write(white, "%sActual Value: ", indent(2))
+						writeln(red, "%s", e.Actual.String())
+					}
+					writeln(white, "")
 					printCodeLine(r.Lexer, e.Token)
 				case *ife.TestingError:
 					writeln(white, "")
@@ -469,10 +609,11 @@ func runTest(runner *Runner, rslv resolver.Resolver) error {
 	write(white, "%d total, ", totalCount)
 	writeln(white, "%d assertions", factory.Statistics.Asserts)
 
-	if factory.Statistics.Fails > 0 {
-		return ErrExit
+	if factory.Coverage != nil {
+		writeln(white, "")
+		printCoverageSummary(factory.Coverage.Summarize())
+		writeln(white, "Coverage reports are written in %s", runner.config.Testing.CoverageDir)
 	}
-	return nil
 }
 
 func runFormat(runner *Runner, rslv resolver.Resolver) error {
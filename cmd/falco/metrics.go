@@ -0,0 +1,245 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+)
+
+// Per-subroutine metrics for falco stats
+type SubroutineMetrics struct {
+	Name         string `json:"name"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Statements   int    `json:"statements"`
+	Branches     int    `json:"branches"`
+	Complexity   int    `json:"complexity"`
+	MaxDepth     int    `json:"max_depth"`
+	FanIn        int    `json:"fan_in"`
+	FanOut       int    `json:"fan_out"`
+	HeaderReads  int    `json:"header_reads"`
+	HeaderWrites int    `json:"header_writes"`
+}
+
+// Metric value which exceeds the configured threshold
+type StatsViolation struct {
+	Subroutine string `json:"subroutine"`
+	Metric     string `json:"metric"`
+	Value      int    `json:"value"`
+	Threshold  int    `json:"threshold"`
+}
+
+// metricsWalker walks subroutine statements and accumulates metrics
+type metricsWalker struct {
+	metrics     *SubroutineMetrics
+	subroutines map[string]struct{}
+	calls       map[string]struct{}
+}
+
+func collectSubroutineMetrics(decls []*ast.SubroutineDeclaration, main string) []*SubroutineMetrics {
+	names := make(map[string]struct{})
+	for _, decl := range decls {
+		names[decl.Name.Value] = struct{}{}
+	}
+
+	metrics := []*SubroutineMetrics{}
+	callers := make(map[string]map[string]struct{})
+	for _, decl := range decls {
+		token := decl.GetMeta().Token
+		file := token.File
+		if file == "" {
+			file = main
+		}
+		w := &metricsWalker{
+			metrics: &SubroutineMetrics{
+				Name: decl.Name.Value,
+				File: file,
+				Line: token.Line,
+				// Cyclomatic complexity starts from 1 as single path
+				Complexity: 1,
+			},
+			subroutines: names,
+			calls:       make(map[string]struct{}),
+		}
+		w.statements(decl.Block.Statements, 0)
+		w.metrics.FanOut = len(w.calls)
+		for callee := range w.calls {
+			if _, ok := callers[callee]; !ok {
+				callers[callee] = make(map[string]struct{})
+			}
+			callers[callee][decl.Name.Value] = struct{}{}
+		}
+		metrics = append(metrics, w.metrics)
+	}
+
+	for _, m := range metrics {
+		m.FanIn = len(callers[m.Name])
+	}
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics
+}
+
+// Check metrics with configured thresholds, zero threshold is not checked
+func checkMetricsThresholds(metrics []*SubroutineMetrics, c *config.StatsConfig) []*StatsViolation {
+	violations := []*StatsViolation{}
+	if c == nil {
+		return violations
+	}
+
+	for _, m := range metrics {
+		for _, v := range []struct {
+			metric    string
+			value     int
+			threshold int
+		}{
+			{"complexity", m.Complexity, c.MaxComplexity},
+			{"statements", m.Statements, c.MaxStatements},
+			{"max_depth", m.MaxDepth, c.MaxDepth},
+			{"fan_out", m.FanOut, c.MaxFanOut},
+		} {
+			if v.threshold > 0 && v.value > v.threshold {
+				violations = append(violations, &StatsViolation{
+					Subroutine: m.Name,
+					Metric:     v.metric,
+					Value:      v.value,
+					Threshold:  v.threshold,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+func isHeaderVariable(name string) bool {
+	return strings.Contains(name, ".http.")
+}
+
+func (w *metricsWalker) call(name string) {
+	if _, ok := w.subroutines[name]; ok {
+		w.calls[name] = struct{}{}
+	}
+}
+
+func (w *metricsWalker) statements(stmts []ast.Statement, depth int) {
+	if depth > w.metrics.MaxDepth {
+		w.metrics.MaxDepth = depth
+	}
+	for _, stmt := range stmts {
+		w.metrics.Statements++
+		w.statement(stmt, depth)
+	}
+}
+
+func (w *metricsWalker) statement(stmt ast.Statement, depth int) {
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		w.statements(t.Statements, depth+1)
+	case *ast.IfStatement:
+		w.metrics.Branches++
+		w.metrics.Complexity++
+		w.expression(t.Condition)
+		w.statements(t.Consequence.Statements, depth+1)
+		for _, another := range t.Another {
+			w.metrics.Branches++
+			w.metrics.Complexity++
+			w.expression(another.Condition)
+			w.statements(another.Consequence.Statements, depth+1)
+		}
+		if t.Alternative != nil {
+			w.metrics.Branches++
+			w.statements(t.Alternative.Consequence.Statements, depth+1)
+		}
+	case *ast.SwitchStatement:
+		w.expression(t.Control.Expression)
+		for _, c := range t.Cases {
+			w.metrics.Branches++
+			// default case does not add decision point
+			if c.Test != nil {
+				w.metrics.Complexity++
+			}
+			w.statements(c.Statements, depth+1)
+		}
+	case *ast.SetStatement:
+		w.expression(t.Value)
+		if isHeaderVariable(t.Ident.Value) {
+			// Compound assignment like "+=" reads the current value
+			if t.Operator.Operator != "=" {
+				w.metrics.HeaderReads++
+			}
+			w.metrics.HeaderWrites++
+		}
+	case *ast.AddStatement:
+		w.expression(t.Value)
+		if isHeaderVariable(t.Ident.Value) {
+			w.metrics.HeaderWrites++
+		}
+	case *ast.UnsetStatement:
+		if isHeaderVariable(t.Ident.Value) {
+			w.metrics.HeaderWrites++
+		}
+	case *ast.RemoveStatement:
+		if isHeaderVariable(t.Ident.Value) {
+			w.metrics.HeaderWrites++
+		}
+	case *ast.CallStatement:
+		w.call(t.Subroutine.Value)
+	case *ast.FunctionCallStatement:
+		w.call(t.Function.Value)
+		for _, arg := range t.Arguments {
+			w.expression(arg)
+		}
+	case *ast.LogStatement:
+		w.expression(t.Value)
+	case *ast.SyntheticStatement:
+		w.expression(t.Value)
+	case *ast.SyntheticBase64Statement:
+		w.expression(t.Value)
+	case *ast.ErrorStatement:
+		if t.Code != nil {
+			w.expression(t.Code)
+		}
+		if t.Argument != nil {
+			w.expression(t.Argument)
+		}
+	case *ast.ReturnStatement:
+		if t.ReturnExpression != nil {
+			w.expression(t.ReturnExpression)
+		}
+	}
+}
+
+func (w *metricsWalker) expression(expr ast.Expression) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if isHeaderVariable(t.Value) {
+			w.metrics.HeaderReads++
+		}
+	case *ast.GroupedExpression:
+		w.expression(t.Right)
+	case *ast.PrefixExpression:
+		w.expression(t.Right)
+	case *ast.PostfixExpression:
+		w.expression(t.Left)
+	case *ast.InfixExpression:
+		// Short-circuit operators add decision points
+		if t.Operator == "&&" || t.Operator == "||" {
+			w.metrics.Complexity++
+		}
+		w.expression(t.Left)
+		w.expression(t.Right)
+	case *ast.IfExpression:
+		w.metrics.Complexity++
+		w.expression(t.Condition)
+		w.expression(t.Consequence)
+		w.expression(t.Alternative)
+	case *ast.FunctionCallExpression:
+		w.call(t.Function.Value)
+		for _, arg := range t.Arguments {
+			w.expression(arg)
+		}
+	}
+}
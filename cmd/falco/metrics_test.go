@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+func TestCollectSubroutineMetrics(t *testing.T) {
+	input := `
+sub normalize_recv {
+	set req.http.Host = std.tolower(req.http.Host);
+}
+
+sub vcl_recv {
+	#FASTLY recv
+	call normalize_recv;
+	if (req.http.Foo && req.http.Bar) {
+		if (req.url ~ "^/api") {
+			set req.http.X-API = "1";
+		}
+	} else if (req.http.Baz) {
+		unset req.http.Baz;
+	} else {
+		esi;
+	}
+	switch (req.http.Host) {
+	case "example.com":
+		break;
+	default:
+		break;
+	}
+	return(lookup);
+}
+`
+	vcl, err := parser.New(lexer.NewFromString(input, lexer.WithFile("main.vcl"))).ParseVCL()
+	if err != nil {
+		t.Errorf("Unexpected parser error: %s", err)
+		return
+	}
+	var decls []*ast.SubroutineDeclaration
+	for _, stmt := range vcl.Statements {
+		if decl, ok := stmt.(*ast.SubroutineDeclaration); ok {
+			decls = append(decls, decl)
+		}
+	}
+
+	metrics := collectSubroutineMetrics(decls, "main.vcl")
+	expect := []*SubroutineMetrics{
+		{
+			Name:         "normalize_recv",
+			File:         "main.vcl",
+			Line:         2,
+			Statements:   1,
+			Complexity:   1,
+			FanIn:        1,
+			HeaderReads:  1,
+			HeaderWrites: 1,
+		},
+		{
+			Name:         "vcl_recv",
+			File:         "main.vcl",
+			Line:         6,
+			Statements:   10,
+			Branches:     6,
+			Complexity:   6,
+			MaxDepth:     2,
+			FanOut:       1,
+			HeaderReads:  4,
+			HeaderWrites: 2,
+		},
+	}
+	if diff := cmp.Diff(expect, metrics); diff != "" {
+		t.Errorf("Metrics mismatch, diff=%s", diff)
+	}
+
+	violations := checkMetricsThresholds(metrics, &config.StatsConfig{MaxComplexity: 5, MaxDepth: 2})
+	expectViolations := []*StatsViolation{
+		{Subroutine: "vcl_recv", Metric: "complexity", Value: 6, Threshold: 5},
+	}
+	if diff := cmp.Diff(expectViolations, violations); diff != "" {
+		t.Errorf("Violations mismatch, diff=%s", diff)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/interpreter/coverage"
+	"github.com/ysugimoto/falco/tester"
+)
+
+const (
+	reporterText  = "text"
+	reporterJSON  = "json"
+	reporterJUnit = "junit"
+	reporterTAP   = "tap"
+)
+
+// Write test report in the reporter format.
+// If report file is specified, the report is written to the file and text results are also printed to stdout.
+func writeTestReport(runner *Runner, factory *tester.TestFactory, reporter string) error {
+	var w io.Writer = os.Stdout
+	if file := runner.config.Testing.ReportFile; file != "" {
+		fp, err := os.Create(file)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer fp.Close()
+		w = fp
+		printTestResults(runner, factory)
+	}
+
+	switch reporter {
+	case reporterJUnit:
+		return errors.WithStack(factory.WriteJUnit(w))
+	case reporterTAP:
+		return errors.WithStack(factory.WriteTAP(w))
+	default:
+		return errors.WithStack(writeJSONReport(w, factory))
+	}
+}
+
+func writeJSONReport(w io.Writer, factory *tester.TestFactory) error {
+	var coverageSummary *coverage.Summary
+	if factory.Coverage != nil {
+		coverageSummary = factory.Coverage.Summarize()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Tests    []*tester.TestResult `json:"tests"`
+		Summary  *tester.TestCounter  `json:"summary"`
+		Coverage *coverage.Summary    `json:"coverage,omitempty"`
+	}{
+		Tests:    factory.Results,
+		Summary:  factory.Statistics,
+		Coverage: coverageSummary,
+	})
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	gocontext "context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
@@ -15,8 +19,11 @@ import (
 	"github.com/ysugimoto/falco/context"
 	"github.com/ysugimoto/falco/debugger"
 	"github.com/ysugimoto/falco/formatter"
+	"github.com/ysugimoto/falco/graph"
 	"github.com/ysugimoto/falco/interpreter"
 	icontext "github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/geoip"
+	"github.com/ysugimoto/falco/interpreter/har"
 	"github.com/ysugimoto/falco/lexer"
 	"github.com/ysugimoto/falco/linter"
 	"github.com/ysugimoto/falco/parser"
@@ -44,9 +51,10 @@ type VCL struct {
 }
 
 type RunnerResult struct {
-	Infos    int
-	Warnings int
-	Errors   int
+	Infos     int
+	Warnings  int
+	Errors    int
+	Baselined int
 
 	LintErrors  map[string][]*linter.LintError
 	ParseErrors map[string]*parser.ParseError
@@ -63,6 +71,9 @@ type StatsResult struct {
 	Directors   int    `json:"directors"`
 	Files       int    `json:"files"`
 	Lines       int    `json:"lines"`
+
+	SubroutineMetrics []*SubroutineMetrics `json:"subroutine_metrics"`
+	Violations        []*StatsViolation    `json:"violations"`
 }
 
 type Fetcher interface {
@@ -80,19 +91,23 @@ const (
 )
 
 type Runner struct {
-	overrides map[string]linter.Severity
-	lexers    map[string]*lexer.Lexer
-	snippets  *snippets.Snippets
-	config    *config.Config
+	severities *linter.SeverityOverrides
+	lexers     map[string]*lexer.Lexer
+	snippets   *snippets.Snippets
+	config     *config.Config
 
 	level       Level
 	lintErrors  map[string][]*linter.LintError
 	parseErrors map[string]*parser.ParseError
+	fixes       map[string][]severityFix
+	baseline    *Baseline
+	subroutines []*ast.SubroutineDeclaration
 
 	// runner result fields
-	infos    int
-	warnings int
-	errors   int
+	infos     int
+	warnings  int
+	errors    int
+	baselined int
 }
 
 // Wrap writeln function in order to prevent to write when json mode turns on
@@ -106,14 +121,14 @@ func (r *Runner) message(c *color.Color, format string, args ...interface{}) {
 	write(c, format, args...)
 }
 
-func NewRunner(c *config.Config, fetcher snippets.Fetcher) *Runner {
+func NewRunner(c *config.Config, fetcher snippets.Fetcher) (*Runner, error) {
 	r := &Runner{
 		level:       LevelError,
-		overrides:   make(map[string]linter.Severity),
 		lexers:      make(map[string]*lexer.Lexer),
 		config:      c,
 		lintErrors:  make(map[string][]*linter.LintError),
 		parseErrors: make(map[string]*parser.ParseError),
+		fixes:       make(map[string][]severityFix),
 	}
 
 	// If fetch interface is provided, communicate with it
@@ -136,22 +151,13 @@ func NewRunner(c *config.Config, fetcher snippets.Fetcher) *Runner {
 	}
 
 	// Override linter rules
-	for key, value := range c.Linter.Rules {
-		switch strings.ToUpper(value) {
-		case "ERROR":
-			r.overrides[key] = linter.ERROR
-		case "WARNING":
-			r.overrides[key] = linter.WARNING
-		case "INFO":
-			r.overrides[key] = linter.INFO
-		case "IGNORE":
-			r.overrides[key] = linter.IGNORE
-		default:
-			r.message(yellow, "Level for rule %s has invalid value %s, skipping.\n", key, value)
-		}
+	severities, err := linter.NewSeverityOverrides(c.Linter)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid linter rules configuration")
 	}
+	r.severities = severities
 
-	return r
+	return r, nil
 }
 
 func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
@@ -166,6 +172,15 @@ func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
 		return nil, err
 	}
 
+	// Prepare baseline to record or filter existing findings
+	if file := r.baselineFile(); r.config.Linter.WriteBaseline {
+		r.baseline = newBaseline(file)
+	} else if file != "" {
+		if r.baseline, err = loadBaseline(file); err != nil {
+			return nil, err
+		}
+	}
+
 	// Note: this context is not Go context, our parsing context :)
 	ctx := context.New(options...)
 	vcl, err := r.run(ctx, main, RunModeLint)
@@ -177,6 +192,7 @@ func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
 		Infos:       r.infos,
 		Warnings:    r.warnings,
 		Errors:      r.errors,
+		Baselined:   r.baselined,
 		LintErrors:  r.lintErrors,
 		ParseErrors: r.parseErrors,
 		Vcl:         vcl,
@@ -206,6 +222,7 @@ func (r *Runner) run(ctx *context.Context, main *resolver.VCL, mode RunMode) (*V
 	for k, v := range lt.Lexers() {
 		r.lexers[k] = v
 	}
+	r.subroutines = lt.Subroutines()
 
 	// If runner is running as stat mode, prevent to output lint result
 	if mode&RunModeStat > 0 {
@@ -231,16 +248,35 @@ func (r *Runner) run(ctx *context.Context, main *resolver.VCL, mode RunMode) (*V
 
 	if len(lt.Errors) > 0 {
 		for _, le := range lt.Errors {
+			file := le.Token.File
+			if file == "" {
+				file = main.Name
+			}
 			// check severity with overrides
-			severity := le.Severity
-			if v, ok := r.overrides[string(le.Rule)]; ok {
-				severity = v
+			severity := r.severities.Severity(le, file)
+
+			// Record or filter findings by baseline
+			if r.baseline != nil && severity != linter.IGNORE {
+				var line string
+				if lx, ok := r.lexers[file]; ok {
+					line, _ = lx.GetLine(le.Token.Line)
+				}
+				if r.config.Linter.WriteBaseline {
+					r.baseline.Add(le, file, line)
+				} else if r.baseline.Match(le, file, line) {
+					r.baselined++
+					continue
+				}
 			}
 
 			// Store all but ignored linter errors
 			if r.config.Json && severity != linter.IGNORE {
 				r.lintErrors[le.Token.File] = append(r.lintErrors[le.Token.File], le)
 			}
+			// Store fixable errors to apply them later
+			if le.Fix != nil && severity != linter.IGNORE {
+				r.fixes[file] = append(r.fixes[file], severityFix{Fix: le.Fix, severity: severity})
+			}
 			r.printLinterError(r.lexers[main.Name], severity, le)
 		}
 	}
@@ -251,6 +287,78 @@ func (r *Runner) run(ctx *context.Context, main *resolver.VCL, mode RunMode) (*V
 	}, nil
 }
 
+// severityFix is the fix of the lint error with its overridden severity
+type severityFix struct {
+	linter.Fix
+	severity linter.Severity
+}
+
+// Returns count of fixable lint errors
+func (r *Runner) Fixable() int {
+	var count int
+	for _, fixes := range r.fixes {
+		count += len(fixes)
+	}
+	return count
+}
+
+// Apply fixes to each VCL file and overwrite them.
+// The fixed VCL is re-emitted through the formatter so the output stays clean.
+// Fixed problems are subtracted from the counts of the result
+func (r *Runner) Fix(result *RunnerResult) (int, error) {
+	files := make([]string, 0, len(r.fixes))
+	for file := range r.fixes {
+		// Fastly managed snippet could not be fixed locally
+		if strings.HasPrefix(file, "snippet::") {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var fixed int
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return fixed, errors.WithStack(err)
+		}
+		vcl, err := parser.New(lexer.NewFromString(string(buf), lexer.WithFile(file))).ParseVCL()
+		if err != nil {
+			return fixed, errors.WithStack(err)
+		}
+		var applied []severityFix
+		for _, f := range r.fixes[file] {
+			if f.Apply(vcl) {
+				applied = append(applied, f)
+			}
+		}
+		if len(applied) == 0 {
+			continue
+		}
+
+		formatted, err := io.ReadAll(formatter.New(r.config.Format).Format(vcl))
+		if err != nil {
+			return fixed, errors.WithStack(err)
+		}
+		if err := os.WriteFile(file, formatted, 0o644); err != nil {
+			return fixed, errors.WithStack(err)
+		}
+		for _, f := range applied {
+			r.message(white, "%s in %s\n", f.String(), file)
+			switch f.severity {
+			case linter.ERROR:
+				result.Errors--
+			case linter.WARNING:
+				result.Warnings--
+			case linter.INFO:
+				result.Infos--
+			}
+		}
+		fixed += len(applied)
+	}
+	return fixed, nil
+}
+
 func (r *Runner) parseVCL(name, code string) (*ast.VCL, error) {
 	lx := lexer.NewFromString(code, lexer.WithFile(name))
 	p := parser.New(lx)
@@ -395,9 +503,34 @@ func (r *Runner) Stats(rslv resolver.Resolver) (*StatsResult, error) {
 		stats.Lines += lx.LineCount()
 	}
 
+	stats.SubroutineMetrics = collectSubroutineMetrics(r.subroutines, main.Name)
+	stats.Violations = checkMetricsThresholds(stats.SubroutineMetrics, r.config.Stats)
+
 	return stats, nil
 }
 
+func (r *Runner) Graph(rslv resolver.Resolver) (*graph.Graph, error) {
+	options := []context.Option{context.WithResolver(rslv)}
+	// If remote snippets exists, prepare parse and prepend to main VCL
+	if r.snippets != nil {
+		options = append(options, context.WithSnippets(r.snippets))
+	}
+
+	main, err := rslv.MainVCL()
+	if err != nil {
+		return nil, err
+	}
+
+	// Note: this context is not Go context, our parsing context :)
+	ctx := context.New(options...)
+
+	if _, err := r.run(ctx, main, RunModeStat); err != nil {
+		return nil, err
+	}
+
+	return graph.New(r.subroutines), nil
+}
+
 func (r *Runner) Simulate(rslv resolver.Resolver) error {
 	sc := r.config.Simulator
 	options := []icontext.Option{
@@ -419,12 +552,51 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 	if sc.OverrideEdgeDictionaries != nil {
 		options = append(options, icontext.WithInjectEdgeDictionaries(sc.OverrideEdgeDictionaries))
 	}
+	if len(r.config.GeoIPDatabases) > 0 {
+		db, err := geoip.Open(r.config.GeoIPDatabases...)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		options = append(options, icontext.WithGeoIP(db))
+	}
+	opt, recorder, err := r.harOption()
+	if err != nil {
+		return errors.WithStack(err)
+	} else if opt != nil {
+		options = append(options, opt)
+	}
 
 	i := interpreter.New(options...)
+	if sc.IsShield {
+		i.EnableShieldNode()
+	}
 
+	// Debugger console must be set up before probes start to show health transitions on the console
+	var console *debugger.Console
 	if sc.IsDebug {
+		console = debugger.New(i)
+	}
+
+	// Run backend health check probes while the simulator is running
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+	if err := i.StartProbes(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if recorder != nil {
+		go recorder.Run(ctx, func(err error) {
+			// Do not write over the debugger screen, the error is reported by the final flush
+			if !sc.IsDebug {
+				writeln(red, "Failed to write HAR file: %s", err)
+			}
+		})
+		defer r.flushHAR(recorder)
+	}
+
+	if console != nil {
 		// If debugger flag is on, run debugger mode
-		return debugger.New(i).Run(sc)
+		return console.Run(sc)
 	}
 
 	// Otherwise, simply start simulator server
@@ -435,7 +607,17 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 		Addr:    fmt.Sprintf(":%d", sc.Port),
 	}
 
-	var err error
+	if recorder != nil {
+		// Stop the server gracefully to write recorded entries on exit
+		go func() {
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+
+			<-sig
+			s.Shutdown(ctx) // nolint:errcheck
+		}()
+	}
+
 	if sc.KeyFile != "" && sc.CertFile != "" {
 		writeln(green, "Simulator server starts on 0.0.0.0:%d with TLS", sc.Port)
 		err = s.ListenAndServeTLS(sc.CertFile, sc.KeyFile)
@@ -443,12 +625,40 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 		writeln(green, "Simulator server starts on 0.0.0.0:%d", sc.Port)
 		err = s.ListenAndServe()
 	}
-	if err != nil {
+	if err != nil && err != http.ErrServerClosed {
 		return errors.WithStack(err)
 	}
 	return nil
 }
 
+// harOption returns interpreter option to record backend traffic to HAR file, or to replay backend responses from HAR file.
+// On recording, the recorder is also returned and the caller must flush it on exit
+func (r *Runner) harOption() (icontext.Option, *har.Recorder, error) {
+	switch {
+	case r.config.RecordHAR != "" && r.config.ReplayHAR != "":
+		return nil, nil, fmt.Errorf("--record and --replay options could not be specified at the same time")
+	case r.config.RecordHAR != "":
+		writeln(white, "Backend traffic is recorded to %s", r.config.RecordHAR)
+		recorder := har.NewRecorder(r.config.RecordHAR, version)
+		return icontext.WithHARRecorder(recorder), recorder, nil
+	case r.config.ReplayHAR != "":
+		rp, err := har.NewReplayer(r.config.ReplayHAR)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		writeln(white, "Backend responses are replayed from %s", r.config.ReplayHAR)
+		return icontext.WithHARReplayer(rp), nil, nil
+	default:
+		return nil, nil, nil
+	}
+}
+
+func (r *Runner) flushHAR(recorder *har.Recorder) {
+	if err := recorder.Flush(); err != nil {
+		writeln(red, "Failed to write HAR file: %s", err)
+	}
+}
+
 func (r *Runner) Test(rslv resolver.Resolver) (*tester.TestFactory, error) {
 	tc := r.config.Testing
 	options := []icontext.Option{
@@ -465,6 +675,22 @@ func (r *Runner) Test(rslv resolver.Resolver) (*tester.TestFactory, error) {
 	if tc.OverrideHost != "" {
 		options = append(options, icontext.WithOverrideHost(tc.OverrideHost))
 	}
+	if len(r.config.GeoIPDatabases) > 0 {
+		db, err := geoip.Open(r.config.GeoIPDatabases...)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		options = append(options, icontext.WithGeoIP(db))
+	}
+	opt, recorder, err := r.harOption()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if opt != nil {
+		options = append(options, opt)
+	}
+	if recorder != nil {
+		defer r.flushHAR(recorder)
+	}
 
 	// Factory override variables.
 	// The order is imporotant, should do yaml -> cli order because cli could override yaml configuration
@@ -485,14 +711,20 @@ func (r *Runner) Test(rslv resolver.Resolver) (*tester.TestFactory, error) {
 	}
 	options = append(options, icontext.WithOverrideVariales(overrides))
 
-	r.message(white, "Running tests...")
+	// Progress messages must not be mixed into the report which is written to stdout
+	quiet := tc.Reporter != reporterText && tc.ReportFile == ""
+	if !quiet {
+		r.message(white, "Running tests...")
+	}
 	factory, err := tester.New(tc, options).Run(r.config.Commands.At(1))
 	if err != nil {
 		writeln(red, " Failed.")
 		writeln(red, "Failed to run test: %s", err.Error())
 		return nil, err
 	}
-	r.message(white, " Done.\n")
+	if !quiet {
+		r.message(white, " Done.\n")
+	}
 	return factory, nil
 }
 
@@ -98,7 +98,11 @@ func TestResolveExternalWithExternalProperties(t *testing.T) {
 				VerboseWarning: true,
 			},
 		}
-		ret, err := NewRunner(c, f).Run(rslv[0])
+		runner, err := NewRunner(c, f)
+		if err != nil {
+			t.Fatalf("Unexpected NewRunner() error: %s", err)
+		}
+		ret, err := runner.Run(rslv[0])
 		if err != nil {
 			t.Fatalf("Unexpected Run() error: %s", err)
 		}
@@ -122,7 +126,11 @@ func TestResolveExternalWithNoExternalProperties(t *testing.T) {
 			VerboseWarning: true,
 		},
 	}
-	ret, err := NewRunner(c, f).Run(rslv[0])
+	runner, err := NewRunner(c, f)
+	if err != nil {
+		t.Fatalf("Unexpected NewRunner() error: %s", err)
+	}
+	ret, err := runner.Run(rslv[0])
 	if err != nil {
 		t.Fatalf("Unexpected Run() error: %s", err)
 	}
@@ -145,7 +153,11 @@ func TestResolveWithDuplicateDeclarations(t *testing.T) {
 			VerboseWarning: true,
 		},
 	}
-	ret, err := NewRunner(c, f).Run(rslv[0])
+	runner, err := NewRunner(c, f)
+	if err != nil {
+		t.Fatalf("Unexpected NewRunner() error: %s", err)
+	}
+	ret, err := runner.Run(rslv[0])
 	if err != nil {
 		t.Fatalf("Unexpected Run() error: %s", err)
 	}
@@ -164,7 +176,11 @@ func TestResolveModulesWithVCLExtension(t *testing.T) {
 		},
 	}
 
-	ret, err := NewRunner(c, f).Run(rslv[0])
+	runner, err := NewRunner(c, f)
+	if err != nil {
+		t.Fatalf("Unexpected NewRunner() error: %s", err)
+	}
+	ret, err := runner.Run(rslv[0])
 	if err != nil {
 		t.Fatalf("Unexpected Run() error: %s", err)
 	}
@@ -183,7 +199,11 @@ func TestResolveModulesWithoutVCLExtension(t *testing.T) {
 		},
 	}
 
-	ret, err := NewRunner(c, f).Run(rslv[0])
+	runner, err := NewRunner(c, f)
+	if err != nil {
+		t.Fatalf("Unexpected NewRunner() error: %s", err)
+	}
+	ret, err := runner.Run(rslv[0])
 	if err != nil {
 		t.Fatalf("Unexpected Run() error: %s", err)
 	}
@@ -206,7 +226,11 @@ func TestTesterWithTerraform(t *testing.T) {
 		},
 	}
 
-	res, err := NewRunner(c, f).Test(rslv[0])
+	runner, err := NewRunner(c, f)
+	if err != nil {
+		t.Fatalf("Unexpected NewRunner() error: %s", err)
+	}
+	res, err := runner.Test(rslv[0])
 	if err != nil {
 		t.Fatalf("Unexpected Run() error: %s", err)
 	}
@@ -235,7 +259,11 @@ func TestRepositoryExamples(t *testing.T) {
 				return
 			}
 
-			ret, err := NewRunner(c, nil).Run(resolvers[0])
+			runner, err := NewRunner(c, nil)
+			if err != nil {
+				t.Fatalf("Unexpected NewRunner() error: %s", err)
+			}
+			ret, err := runner.Run(resolvers[0])
 			if err != nil {
 				if !tt.runError {
 					t.Errorf("Unexpected runner error: %s", err)
@@ -274,7 +302,11 @@ func TestRepositoryExamplesJSONMode(t *testing.T) {
 				t.Errorf("Unexpected runner creation error: %s", err)
 				return
 			}
-			ret, err := NewRunner(c, nil).Run(resolvers[0])
+			runner, err := NewRunner(c, nil)
+			if err != nil {
+				t.Fatalf("Unexpected NewRunner() error: %s", err)
+			}
+			ret, err := runner.Run(resolvers[0])
 			if tt.errors != 0 {
 				if err != nil {
 					t.Errorf("Unexpected error running Run(): %s", err)
@@ -403,7 +435,11 @@ func TestTester(t *testing.T) {
 				t.Errorf("Unexpected runner creation error: %s", err)
 				return
 			}
-			ret, err := NewRunner(c, nil).Test(resolvers[0])
+			runner, err := NewRunner(c, nil)
+			if err != nil {
+				t.Fatalf("Unexpected NewRunner() error: %s", err)
+			}
+			ret, err := runner.Test(resolvers[0])
 			if err != nil {
 				t.Errorf("Unexpected runner creation error: %s", err)
 				return
@@ -440,7 +476,11 @@ func TestFastlyGeneratedVCLLinting(t *testing.T) {
 		t.Errorf("Unexpected runner creation error: %s", err)
 		return
 	}
-	ret, err := NewRunner(c, nil).Run(resolvers[0])
+	runner, err := NewRunner(c, nil)
+	if err != nil {
+		t.Fatalf("Unexpected NewRunner() error: %s", err)
+	}
+	ret, err := runner.Run(resolvers[0])
 	if err != nil {
 		t.Errorf("Unexpected linting error: %s", err)
 		return
@@ -455,3 +495,49 @@ func TestFastlyGeneratedVCLLinting(t *testing.T) {
 		t.Errorf("Errors expects 0, got %d", ret.Errors)
 	}
 }
+
+func TestFixSubtractsFixedProblems(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.vcl")
+	vcl := `table unused_table {
+  "foo": "bar",
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  return(lookup);
+}
+`
+	if err := os.WriteFile(file, []byte(vcl), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	c, err := config.New([]string{})
+	if err != nil {
+		t.Fatalf("Unexpected config error: %s", err)
+	}
+	resolvers, err := resolver.NewFileResolvers(file, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected resolver creation error: %s", err)
+	}
+	runner, err := NewRunner(c, nil)
+	if err != nil {
+		t.Fatalf("Unexpected NewRunner() error: %s", err)
+	}
+	ret, err := runner.Run(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected runner error: %s", err)
+	}
+	if ret.Warnings != 1 {
+		t.Fatalf("Warnings expects 1 before fix, got %d", ret.Warnings)
+	}
+
+	fixed, err := runner.Fix(ret)
+	if err != nil {
+		t.Fatalf("Unexpected fix error: %s", err)
+	}
+	if fixed != 1 {
+		t.Errorf("Fixed expects 1, got %d", fixed)
+	}
+	if ret.Warnings != 0 {
+		t.Errorf("Warnings expects 0 after fix, got %d", ret.Warnings)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/token"
+)
+
+// SARIF 2.1.0 log structures, only fields which falco uses are defined
+// see: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+// Lint output formats
+const (
+	lintFormatText  = "text"
+	lintFormatJSON  = "json"
+	lintFormatSARIF = "sarif"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	// Rule IDs for the problems which are not related to linter rules
+	sarifRuleParseError   = "parse-error"
+	sarifRuleUnclassified = "unclassified"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// sarifBuilder collects rules and results, rules are indexed in order of appearance
+type sarifBuilder struct {
+	main    string
+	rules   []sarifRule
+	indexes map[string]int
+	results []sarifResult
+}
+
+func (b *sarifBuilder) rule(r sarifRule) int {
+	if index, ok := b.indexes[r.ID]; ok {
+		return index
+	}
+	b.rules = append(b.rules, r)
+	b.indexes[r.ID] = len(b.rules) - 1
+	return len(b.rules) - 1
+}
+
+func (b *sarifBuilder) add(r sarifRule, level, message string, t token.Token) {
+	file := t.File
+	if file == "" {
+		file = b.main
+	}
+	b.results = append(b.results, sarifResult{
+		RuleID:    r.ID,
+		RuleIndex: b.rule(r),
+		Level:     level,
+		Message:   sarifMessage{Text: message},
+		Locations: []sarifLocation{
+			{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(file)},
+					Region: sarifRegion{
+						StartLine:   max(t.Line, 1),
+						StartColumn: max(t.Position, 1),
+						EndColumn:   max(t.Position, 1) + len([]rune(t.Literal)),
+					},
+				},
+			},
+		},
+	})
+}
+
+// sarifLintRule returns rule metadata of the lint error.
+// Description and help URI are taken from the builtin rule,
+// custom rules and unclassified errors fall back to the reference of the error
+func sarifLintRule(le *linter.LintError) sarifRule {
+	if le.Rule == "" {
+		return sarifRule{
+			ID:               sarifRuleUnclassified,
+			ShortDescription: sarifMessage{Text: "Problem which is not classified to any linter rule"},
+			HelpURI:          le.Reference,
+		}
+	}
+	r := sarifRule{
+		ID:               string(le.Rule),
+		ShortDescription: sarifMessage{Text: le.Rule.Description()},
+		HelpURI:          le.Rule.Reference(),
+	}
+	if r.ShortDescription.Text == "" {
+		r.ShortDescription.Text = string(le.Rule)
+	}
+	if r.HelpURI == "" {
+		r.HelpURI = le.Reference
+	}
+	if r.HelpURI == "" {
+		r.HelpURI = le.Rule.DocumentURL()
+	}
+	return r
+}
+
+// Write lint result as SARIF log.
+// Severity overrides are applied to each result level, and ignored errors are not reported
+func writeSARIF(w io.Writer, result *RunnerResult, severities *linter.SeverityOverrides) error {
+	b := &sarifBuilder{
+		indexes: make(map[string]int),
+		results: []sarifResult{},
+		rules:   []sarifRule{},
+	}
+	if result.Vcl != nil {
+		b.main = result.Vcl.File
+	}
+
+	for _, file := range sortedKeys(result.ParseErrors) {
+		pe := result.ParseErrors[file]
+		b.add(sarifRule{
+			ID:               sarifRuleParseError,
+			ShortDescription: sarifMessage{Text: "VCL could not be parsed"},
+		}, "error", pe.Message, pe.Token)
+	}
+	for _, file := range sortedKeys(result.LintErrors) {
+		for _, le := range result.LintErrors[file] {
+			name := file
+			if name == "" {
+				name = b.main
+			}
+			severity := severities.Severity(le, name)
+			if severity == linter.IGNORE {
+				continue
+			}
+			b.add(sarifLintRule(le), sarifLevel(severity), le.Message, le.Token)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.WithStack(enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "falco",
+						Version:        version,
+						InformationURI: "https://github.com/ysugimoto/falco",
+						Rules:          b.rules,
+					},
+				},
+				Results: b.results,
+			},
+		},
+	}))
+}
+
+func sarifLevel(s linter.Severity) string {
+	switch s {
+	case linter.ERROR:
+		return "error"
+	case linter.WARNING:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// SARIF consumers resolve relative URI from the repository root,
+// so the file path is converted to relative from working directory if possible
+func sarifURI(file string) string {
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
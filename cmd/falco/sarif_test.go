@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/parser"
+	"github.com/ysugimoto/falco/token"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	result := &RunnerResult{
+		LintErrors: map[string][]*linter.LintError{
+			"main.vcl": {
+				(&linter.LintError{
+					Severity: linter.WARNING,
+					Token:    token.Token{Literal: "foo", Line: 3, Position: 5, File: "main.vcl"},
+					Message:  "Unused table foo",
+				}).Match(linter.UNUSED_DECLARATION),
+				(&linter.LintError{
+					Severity: linter.INFO,
+					Token:    token.Token{Literal: "re", Line: 10, Position: 1, File: "main.vcl"},
+					Message:  "Regex captured variable may override older one",
+				}).Match(linter.REGEX_MATCHED_VALUE_MAY_OVERRIDE),
+				(&linter.LintError{
+					Severity: linter.WARNING,
+					Token:    token.Token{Literal: "vcl_recv", Line: 12, Position: 1, File: "main.vcl"},
+					Message:  "Subroutine is missing Fastly boilerplate comment",
+				}).Match(linter.SUBROUTINE_BOILERPLATE_MACRO),
+			},
+			"mod.vcl": {
+				{
+					Severity: linter.ERROR,
+					Token:    token.Token{Literal: "var.x", Line: 2, Position: 7, File: "mod.vcl"},
+					Message:  `Variable "var.x" is not defined`,
+				},
+			},
+		},
+		ParseErrors: map[string]*parser.ParseError{
+			"broken.vcl": {
+				Token:   token.Token{Literal: "}", Line: 1, Position: 1, File: "broken.vcl"},
+				Message: "Unexpected token",
+			},
+		},
+	}
+	severities, err := linter.NewSeverityOverrides(&config.LinterConfig{
+		Rules: map[string]string{
+			string(linter.REGEX_MATCHED_VALUE_MAY_OVERRIDE): "ERROR",
+			string(linter.SUBROUTINE_BOILERPLATE_MACRO):     "IGNORE",
+		},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, result, severities); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Errorf("Failed to decode SARIF: %s", err)
+		return
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Errorf("Unexpected SARIF log: %+v", log)
+		return
+	}
+	run := log.Runs[0]
+	expectRules := []sarifRule{
+		{ID: "parse-error", ShortDescription: sarifMessage{Text: "VCL could not be parsed"}},
+		{
+			ID:               "unused/declaration",
+			ShortDescription: sarifMessage{Text: "Declaration is never used"},
+			HelpURI:          "https://github.com/ysugimoto/falco/blob/main/docs/rules.md#unuseddeclaration",
+		},
+		{
+			ID:               "regex/matched-value-override",
+			ShortDescription: sarifMessage{Text: "Regex matched value re.group.N may be overridden"},
+			HelpURI:          "https://github.com/ysugimoto/falco/blob/main/docs/rules.md#regexmatched-value-override",
+		},
+		{
+			ID:               "unclassified",
+			ShortDescription: sarifMessage{Text: "Problem which is not classified to any linter rule"},
+		},
+	}
+	if diff := cmp.Diff(expectRules, run.Tool.Driver.Rules); diff != "" {
+		t.Errorf("Rules mismatch, diff=%s", diff)
+	}
+
+	type summary struct {
+		RuleID    string
+		RuleIndex int
+		Level     string
+		URI       string
+		Line      int
+		Column    int
+		EndColumn int
+	}
+	var actual []summary
+	for _, r := range run.Results {
+		loc := r.Locations[0].PhysicalLocation
+		actual = append(actual, summary{
+			RuleID:    r.RuleID,
+			RuleIndex: r.RuleIndex,
+			Level:     r.Level,
+			URI:       loc.ArtifactLocation.URI,
+			Line:      loc.Region.StartLine,
+			Column:    loc.Region.StartColumn,
+			EndColumn: loc.Region.EndColumn,
+		})
+	}
+	expectResults := []summary{
+		{RuleID: "parse-error", RuleIndex: 0, Level: "error", URI: "broken.vcl", Line: 1, Column: 1, EndColumn: 2},
+		{RuleID: "unused/declaration", RuleIndex: 1, Level: "warning", URI: "main.vcl", Line: 3, Column: 5, EndColumn: 8},
+		{RuleID: "regex/matched-value-override", RuleIndex: 2, Level: "error", URI: "main.vcl", Line: 10, Column: 1, EndColumn: 3},
+		{RuleID: "unclassified", RuleIndex: 3, Level: "error", URI: "mod.vcl", Line: 2, Column: 7, EndColumn: 12},
+	}
+	if diff := cmp.Diff(expectResults, actual); diff != "" {
+		t.Errorf("Results mismatch, diff=%s", diff)
+	}
+}
@@ -14,13 +14,26 @@ func (c Commands) At(n int) string {
 }
 
 var needValueOptions = map[string]struct{}{
-	"-I":             {},
-	"--include_path": {},
-	"-t":             {},
-	"--transformer":  {},
-	"-f":             {},
-	"--filter":       {},
-	"--generated":    {},
+	"-I":               {},
+	"--include_path":   {},
+	"-t":               {},
+	"--transformer":    {},
+	"-f":               {},
+	"--filter":         {},
+	"--generated":      {},
+	"--coverage_dir":   {},
+	"--geoip_db":       {},
+	"--record":         {},
+	"--replay":         {},
+	"--parallel":       {},
+	"--reporter":       {},
+	"--report_file":    {},
+	"--format":         {},
+	"--baseline":       {},
+	"--max_complexity": {},
+	"--max_statements": {},
+	"--max_depth":      {},
+	"--max_fan_out":    {},
 }
 
 func parseCommands(args []string) Commands {
@@ -29,15 +29,27 @@ type OverrideBackend struct {
 
 type EdgeDictionary map[string]string
 
+// Linter rule severities which apply only to the files matched with glob patterns
+type RuleOverride struct {
+	Files []string          `yaml:"files"`
+	Rules map[string]string `yaml:"rules"`
+}
+
 // Linter configuration
 type LinterConfig struct {
 	VerboseLevel            string              `yaml:"verbose"`
 	VerboseWarning          bool                `cli:"v"`
 	VerboseInfo             bool                `cli:"vv"`
 	Rules                   map[string]string   `yaml:"rules"`
+	Overrides               []*RuleOverride     `yaml:"overrides"`
 	EnforceSubroutineScopes map[string][]string `yaml:"enforce_subroutine_scopes"`
 	IgnoreSubroutines       []string            `yaml:"ignore_subroutines"`
 	IsGenerated             bool                `cli:"generated"`
+	EnableSecurityRules     bool                `cli:"security" yaml:"security"`
+	Fix                     bool                `cli:"fix"` // Enable only in CLI option
+	Format                  string              `cli:"format" yaml:"format" default:"text"`
+	Baseline                string              `cli:"baseline" yaml:"baseline"`
+	WriteBaseline           bool                `cli:"write-baseline"` // Enable only in CLI option
 }
 
 // Simulator configuration
@@ -45,6 +57,7 @@ type SimulatorConfig struct {
 	Port            int      `cli:"p,port" yaml:"port" default:"3124"`
 	IsDebug         bool     `cli:"debug"` // Enable only in CLI option
 	IsProxyResponse bool     `cli:"proxy"` // Enable only in CLI option
+	IsShield        bool     `cli:"shield" yaml:"shield"`
 	IncludePaths    []string // Copy from root field
 
 	// HTTPS related configuration. If both fields are spcified, simulator will serve with HTTPS
@@ -63,11 +76,17 @@ type SimulatorConfig struct {
 
 // Testing configuration
 type TestConfig struct {
-	Timeout      int      `cli:"t,timeout" yaml:"timeout"`
-	Filter       string   `cli:"f,filter" default:"*.test.vcl"`
-	IncludePaths []string // Copy from root field
-	OverrideHost string   `yaml:"host"`
-	Watch        bool     `cli:"watch"` // Enable only in CLI option
+	Timeout         int      `cli:"t,timeout" yaml:"timeout"`
+	Filter          string   `cli:"f,filter" default:"*.test.vcl"`
+	IncludePaths    []string // Copy from root field
+	OverrideHost    string   `yaml:"host"`
+	Watch           bool     `cli:"watch"` // Enable only in CLI option
+	Coverage        bool     `cli:"coverage" yaml:"coverage"`
+	CoverageDir     string   `cli:"coverage_dir" yaml:"coverage_dir" default:"coverage"`
+	Parallel        int      `cli:"parallel" yaml:"parallel" default:"1"`
+	Reporter        string   `cli:"reporter" yaml:"reporter" default:"text"`
+	ReportFile      string   `cli:"report_file" yaml:"report_file"`
+	UpdateSnapshots bool     `cli:"update-snapshots"` // Enable only in CLI option
 
 	// Override Request configuration
 	OverrideRequest *RequestConfig
@@ -86,6 +105,21 @@ type ConsoleConfig struct {
 	OverrideRequest *RequestConfig
 }
 
+// Stats configuration
+type StatsConfig struct {
+	// Thresholds of subroutine metrics, zero means unlimited
+	MaxComplexity int `cli:"max_complexity" yaml:"max_complexity"`
+	MaxStatements int `cli:"max_statements" yaml:"max_statements"`
+	MaxDepth      int `cli:"max_depth" yaml:"max_depth"`
+	MaxFanOut     int `cli:"max_fan_out" yaml:"max_fan_out"`
+}
+
+// Graph configuration
+type GraphConfig struct {
+	// Output format, "dot" or "mermaid"
+	Format string `cli:"format" yaml:"format" default:"dot"`
+}
+
 // Format configuration
 type FormatConfig struct {
 	// CLI options
@@ -132,6 +166,13 @@ type Config struct {
 	OverrideMaxBackends int `cli:"max_backends" yaml:"max_backends"`
 	OverrideMaxAcls     int `cli:"mac_acls" yaml:"max_acls"`
 
+	// GeoIP database files for client.geo.* and client.as.* variables, MaxMind DB (.mmdb) or CSV (.csv)
+	GeoIPDatabases []string `cli:"geoip_db" yaml:"geoip_databases"`
+
+	// HAR file to record backend traffic to, or to replay backend responses from
+	RecordHAR string `cli:"record"` // Enable only in CLI option
+	ReplayHAR string `cli:"replay"` // Enable only in CLI option
+
 	// Linter configuration
 	Linter *LinterConfig `yaml:"linter"`
 	// Simulator configuration
@@ -142,6 +183,10 @@ type Config struct {
 	Console *ConsoleConfig `yaml:"console"`
 	// Format configuration
 	Format *FormatConfig `yaml:"format"`
+	// Stats configuration
+	Stats *StatsConfig `yaml:"stats"`
+	// Graph configuration
+	Graph *GraphConfig `yaml:"graph"`
 }
 
 func New(args []string) (*Config, error) {
@@ -53,6 +53,7 @@ func TestConfigFromCLI(t *testing.T) {
 			VerboseWarning:    true,
 			VerboseInfo:       true,
 			IgnoreSubroutines: []string{"vcl_pipe"},
+			Format:            "text",
 		},
 		Simulator: &SimulatorConfig{
 			Port:            3124,
@@ -62,6 +63,9 @@ func TestConfigFromCLI(t *testing.T) {
 		Testing: &TestConfig{
 			Filter:          "*.test.vcl",
 			IncludePaths:    []string{"."},
+			CoverageDir:     "coverage",
+			Parallel:        1,
+			Reporter:        "text",
 			OverrideRequest: &RequestConfig{},
 		},
 		Console: &ConsoleConfig{
@@ -83,6 +87,10 @@ func TestConfigFromCLI(t *testing.T) {
 			CommentStyle:               "none",
 			ShouldUseUnset:             false,
 		},
+		Stats: &StatsConfig{},
+		Graph: &GraphConfig{
+			Format: "dot",
+		},
 		OverrideBackends: make(map[string]*OverrideBackend),
 	}
 
@@ -1179,6 +1179,7 @@ var promptSuggestions = map[string][]prompt.Suggest{
 		{Text: "obj.hits", Description: "Predefined Variable"},
 		{Text: "obj.http", Description: "Predefined Variable"},
 		{Text: "obj.is_pci", Description: "Predefined Variable"},
+		{Text: "obj.is_stale", Description: "Predefined Variable"},
 		{Text: "obj.lastuse", Description: "Predefined Variable"},
 		{Text: "obj.proto", Description: "Predefined Variable"},
 		{Text: "obj.response", Description: "Predefined Variable"},
@@ -2946,6 +2947,7 @@ var promptSuggestions = map[string][]prompt.Suggest{
 		{Text: "obj.grace", Description: "Predefined Variable"},
 		{Text: "obj.http", Description: "Predefined Variable"},
 		{Text: "obj.is_pci", Description: "Predefined Variable"},
+		{Text: "obj.is_stale", Description: "Predefined Variable"},
 		{Text: "obj.lastuse", Description: "Predefined Variable"},
 		{Text: "obj.proto", Description: "Predefined Variable"},
 		{Text: "obj.response", Description: "Predefined Variable"},
@@ -3402,6 +3404,7 @@ var promptSuggestions = map[string][]prompt.Suggest{
 		{Text: "obj.entered", Description: "Predefined Variable"},
 		{Text: "obj.hits", Description: "Predefined Variable"},
 		{Text: "obj.is_pci", Description: "Predefined Variable"},
+		{Text: "obj.is_stale", Description: "Predefined Variable"},
 		{Text: "obj.lastuse", Description: "Predefined Variable"},
 		{Text: "parse_time_delta", Description: "Built-in Function"},
 		{Text: "querystring.add", Description: "Built-in Function"},
@@ -3926,6 +3929,7 @@ var promptSuggestions = map[string][]prompt.Suggest{
 		{Text: "obj.grace", Description: "Predefined Variable"},
 		{Text: "obj.hits", Description: "Predefined Variable"},
 		{Text: "obj.is_pci", Description: "Predefined Variable"},
+		{Text: "obj.is_stale", Description: "Predefined Variable"},
 		{Text: "obj.lastuse", Description: "Predefined Variable"},
 		{Text: "obj.stale_if_error", Description: "Predefined Variable"},
 		{Text: "obj.stale_while_revalidate", Description: "Predefined Variable"},
@@ -187,6 +187,12 @@ func (c *Context) Snippets() *snippets.Snippets {
 	return c.fastlySnippets
 }
 
+// Returns all defined functions including user defined functional subroutines.
+// Note that returned map is shared with the context so caller must not modify it
+func (c *Context) Functions() Functions {
+	return c.functions
+}
+
 func (c *Context) CurrentFunction() string {
 	return c.curName
 }
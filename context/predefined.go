@@ -2634,6 +2634,16 @@ func predefinedVariables() Variables {
 						Reference: "https://developer.fastly.com/reference/vcl/variables/cache-object/obj-is-pci/",
 					},
 				},
+				"is_stale": {
+					Items: map[string]*Object{},
+					Value: &Accessor{
+						Get:       types.BoolType,
+						Set:       types.NeverType,
+						Unset:     false,
+						Scopes:    HIT | DELIVER | ERROR | LOG,
+						Reference: "https://developer.fastly.com/learning/concepts/stale/",
+					},
+				},
 				"lastuse": {
 					Items: map[string]*Object{},
 					Value: &Accessor{
@@ -3909,7 +3919,7 @@ func predefinedVariables() Variables {
 				"exists": {
 					Items: map[string]*Object{},
 					Value: &Accessor{
-						Get:       types.StringType,
+						Get:       types.BoolType,
 						Set:       types.NeverType,
 						Unset:     false,
 						Scopes:    RECV | HASH | HIT | MISS | PASS | FETCH | ERROR | DELIVER | LOG,
@@ -2,6 +2,7 @@ package messageview
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -24,6 +25,8 @@ type MessageEntry struct {
 
 type MessageView struct {
 	*tview.TextView
+	// Messages may be appended from backend health check probes which run in background
+	mu    sync.Mutex
 	lines []MessageEntry
 }
 
@@ -39,10 +42,14 @@ func New() *MessageView {
 }
 
 func (m *MessageView) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.lines = []MessageEntry{}
 }
 
 func (m *MessageView) Append(mt EntryType, format string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.lines = append(m.lines, MessageEntry{
 		Type: mt,
 		Text: fmt.Sprintf(format, args...),
@@ -57,6 +64,8 @@ func (m *MessageView) drawMessages() {
 	defer w.Close()
 	w.Clear()
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for i := range m.lines {
 		line := m.lines[i]
 		var prefix string
@@ -0,0 +1,48 @@
+// Package backend_prefix_rule is an example of Go-native linter rule.
+// Link this package into your custom falco binary by blank import:
+//
+//	import _ "github.com/ysugimoto/falco/examples/plugin/backend_prefix_rule"
+package backend_prefix_rule
+
+import (
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/linter"
+)
+
+func init() {
+	linter.RegisterRule(&BackendPrefixRule{})
+}
+
+// BackendPrefixRule reports backends which name does not have "F_" prefix
+type BackendPrefixRule struct{}
+
+func (r *BackendPrefixRule) Name() linter.Rule {
+	return "example/backend-prefix"
+}
+
+func (r *BackendPrefixRule) Reference() string {
+	return ""
+}
+
+func (r *BackendPrefixRule) Lint(rc *linter.RuleContext) {
+	// Unlike exec plugin, all backends including ones in the included modules can be inspected
+	// without any annotation comments
+	rc.Walk(func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.BackendDeclaration:
+			if !strings.HasPrefix(t.Name.Value, "F_") {
+				rc.Report(&linter.LintError{
+					Severity: linter.WARNING,
+					Token:    t.Name.GetMeta().Token,
+					Message:  `Backend name must start with "F_"`,
+				})
+			}
+		case *ast.SubroutineDeclaration:
+			// Backend is never declared in subroutine, skip walking its statements
+			return false
+		}
+		return true
+	})
+}
@@ -0,0 +1,282 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/ysugimoto/falco/ast"
+)
+
+type EdgeKind string
+
+const (
+	// Subroutine call by call statement or functional subroutine call
+	EdgeCall EdgeKind = "call"
+	// Jump to the goto destination in the same subroutine
+	EdgeGoto EdgeKind = "goto"
+	// Restart request from vcl_recv
+	EdgeRestart EdgeKind = "restart"
+	// State transition by return statement
+	EdgeState EdgeKind = "state"
+	// State transition to vcl_error by error statement
+	EdgeError EdgeKind = "error"
+	// Default state transition of Fastly subroutine which does not need any statements
+	EdgeDefault EdgeKind = "default"
+)
+
+// Fastly state-machine subroutines in the execution order
+var fastlySubroutines = []string{
+	"vcl_recv",
+	"vcl_hash",
+	"vcl_hit",
+	"vcl_miss",
+	"vcl_pass",
+	"vcl_fetch",
+	"vcl_error",
+	"vcl_deliver",
+	"vcl_log",
+}
+
+// Default state transitions of Fastly subroutines
+var defaultTransitions = map[string][]string{
+	"vcl_recv":    {"vcl_hash"},
+	"vcl_hash":    {"vcl_hit", "vcl_miss"},
+	"vcl_hit":     {"vcl_deliver"},
+	"vcl_miss":    {"vcl_fetch"},
+	"vcl_pass":    {"vcl_fetch"},
+	"vcl_fetch":   {"vcl_deliver"},
+	"vcl_error":   {"vcl_deliver"},
+	"vcl_deliver": {"vcl_log"},
+}
+
+// Next subroutines of return(state) in each Fastly subroutine
+var stateTransitions = map[string]map[string][]string{
+	"vcl_recv": {
+		"lookup": {"vcl_hash"},
+		"pass":   {"vcl_hash"},
+		"error":  {"vcl_error"},
+	},
+	"vcl_hash": {
+		"hash": {"vcl_hit", "vcl_miss"},
+	},
+	"vcl_hit": {
+		"deliver": {"vcl_deliver"},
+		"pass":    {"vcl_pass"},
+		"error":   {"vcl_error"},
+	},
+	"vcl_miss": {
+		"fetch":         {"vcl_fetch"},
+		"deliver_stale": {"vcl_deliver"},
+		"pass":          {"vcl_pass"},
+		"error":         {"vcl_error"},
+	},
+	"vcl_pass": {
+		"pass":  {"vcl_fetch"},
+		"error": {"vcl_error"},
+	},
+	"vcl_fetch": {
+		"deliver":       {"vcl_deliver"},
+		"deliver_stale": {"vcl_deliver"},
+		"pass":          {"vcl_deliver"},
+		"error":         {"vcl_error"},
+	},
+	"vcl_error": {
+		"deliver":       {"vcl_deliver"},
+		"deliver_stale": {"vcl_deliver"},
+	},
+	"vcl_deliver": {
+		"deliver": {"vcl_log"},
+	},
+}
+
+type Node struct {
+	Name     string
+	File     string
+	IsFastly bool
+	// Node is referenced as state transition target but not declared in VCL
+	IsImplicit bool
+}
+
+type Edge struct {
+	From  string
+	To    string
+	Kind  EdgeKind
+	Label string
+}
+
+// Graph represents subroutine call graph and Fastly state transitions
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	nodes map[string]*Node
+	edges map[Edge]struct{}
+}
+
+func isFastlySubroutine(name string) bool {
+	for _, v := range fastlySubroutines {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// New builds graph from subroutine declarations
+func New(decls []*ast.SubroutineDeclaration) *Graph {
+	g := &Graph{
+		nodes: make(map[string]*Node),
+		edges: make(map[Edge]struct{}),
+	}
+
+	subroutines := make(map[string]*ast.SubroutineDeclaration)
+	var names []string
+	for _, decl := range decls {
+		name := decl.Name.Value
+		g.addNode(name, decl.GetMeta().Token.File, false)
+		exists, ok := subroutines[name]
+		if !ok {
+			subroutines[name] = decl
+			names = append(names, name)
+			continue
+		}
+		// Duplicated Fastly subroutines are concatenated as Fastly does
+		if isFastlySubroutine(name) {
+			subroutines[name] = concatSubroutine(exists, decl)
+		}
+	}
+
+	// Collect called subroutines in order to know which state the user defined subroutine runs in
+	calls := make(map[string][]string)
+	for _, name := range names {
+		w := &walker{subroutines: subroutines}
+		w.statements(subroutines[name].Block.Statements)
+		calls[name] = w.calls
+	}
+	scopes := make(map[string]map[string]struct{})
+	for _, name := range fastlySubroutines {
+		if _, ok := subroutines[name]; ok {
+			markScope(name, name, calls, scopes)
+		}
+	}
+
+	var recvPass bool
+	for _, name := range names {
+		w := &walker{subroutines: subroutines}
+		w.statements(subroutines[name].Block.Statements)
+
+		for _, callee := range w.calls {
+			g.addEdge(name, callee, EdgeCall, "call")
+		}
+		for _, label := range w.gotos {
+			g.addEdge(name, name, EdgeGoto, "goto "+label)
+		}
+		if w.restart {
+			g.addEdge(name, "vcl_recv", EdgeRestart, "restart")
+		}
+		if w.error {
+			g.addEdge(name, "vcl_error", EdgeError, "error")
+		}
+		for _, state := range w.states {
+			for _, scope := range sortedKeys(scopes[name]) {
+				if scope == "vcl_recv" && state == "pass" {
+					recvPass = true
+				}
+				for _, next := range stateTransitions[scope][state] {
+					g.addEdge(name, next, EdgeState, "return("+state+")")
+				}
+			}
+		}
+	}
+	// return(pass) in vcl_recv goes to vcl_pass after vcl_hash
+	if recvPass {
+		g.addEdge("vcl_hash", "vcl_pass", EdgeState, "pass")
+	}
+
+	for _, name := range fastlySubroutines {
+		for _, next := range defaultTransitions[name] {
+			g.addEdge(name, next, EdgeDefault, "")
+		}
+	}
+
+	g.sort()
+	return g
+}
+
+// concatSubroutine returns new subroutine which has the statements of both subroutines.
+// Declarations are not modified because they are shared with other commands
+func concatSubroutine(a, b *ast.SubroutineDeclaration) *ast.SubroutineDeclaration {
+	sub := *a
+	sub.Block = &ast.BlockStatement{
+		Meta:       a.Block.Meta,
+		Statements: append(append([]ast.Statement{}, a.Block.Statements...), b.Block.Statements...),
+	}
+	return &sub
+}
+
+// markScope marks the subroutine and its callees run in the scope
+func markScope(name, scope string, calls map[string][]string, scopes map[string]map[string]struct{}) {
+	if _, ok := scopes[name]; !ok {
+		scopes[name] = make(map[string]struct{})
+	}
+	if _, ok := scopes[name][scope]; ok {
+		return
+	}
+	scopes[name][scope] = struct{}{}
+	for _, callee := range calls[name] {
+		markScope(callee, scope, calls, scopes)
+	}
+}
+
+func (g *Graph) addNode(name, file string, implicit bool) {
+	if _, ok := g.nodes[name]; ok {
+		return
+	}
+	node := &Node{
+		Name:       name,
+		File:       file,
+		IsFastly:   isFastlySubroutine(name),
+		IsImplicit: implicit,
+	}
+	g.nodes[name] = node
+	g.Nodes = append(g.Nodes, node)
+}
+
+func (g *Graph) addEdge(from, to string, kind EdgeKind, label string) {
+	edge := Edge{From: from, To: to, Kind: kind, Label: label}
+	if _, ok := g.edges[edge]; ok {
+		return
+	}
+	// Fastly subroutines always exist even if they are not declared in VCL
+	g.addNode(from, "", true)
+	g.addNode(to, "", true)
+	g.edges[edge] = struct{}{}
+	g.Edges = append(g.Edges, &edge)
+}
+
+// sort nodes that Fastly subroutines come first in execution order, and then user defined subroutines
+func (g *Graph) sort() {
+	order := func(n *Node) int {
+		for i, v := range fastlySubroutines {
+			if v == n.Name {
+				return i
+			}
+		}
+		return len(fastlySubroutines)
+	}
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		a, b := order(g.Nodes[i]), order(g.Nodes[j])
+		if a != b {
+			return a < b
+		}
+		return g.Nodes[i].Name < g.Nodes[j].Name
+	})
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
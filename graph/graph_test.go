@@ -0,0 +1,180 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+func parseSubroutines(t *testing.T, input string) []*ast.SubroutineDeclaration {
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		t.FailNow()
+	}
+	var decls []*ast.SubroutineDeclaration
+	for _, stmt := range vcl.Statements {
+		if decl, ok := stmt.(*ast.SubroutineDeclaration); ok {
+			decls = append(decls, decl)
+		}
+	}
+	return decls
+}
+
+func TestGraph(t *testing.T) {
+	input := `
+sub route_request {
+	if (req.url ~ "^/static") {
+		return(pass);
+	}
+}
+
+sub is_bot BOOL {
+	return req.http.User-Agent ~ "bot";
+}
+
+sub vcl_recv {
+	#FASTLY recv
+	call route_request;
+	if (is_bot()) {
+		error 403;
+	}
+	return(lookup);
+}
+
+sub vcl_fetch {
+	#FASTLY fetch
+	if (beresp.status >= 500 && req.restarts < 1) {
+		restart;
+	}
+	goto done;
+	done:
+	return(deliver);
+}
+`
+	g := New(parseSubroutines(t, input))
+
+	var nodes []string
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n.Name)
+	}
+	expectNodes := []string{
+		"vcl_recv",
+		"vcl_hash",
+		"vcl_hit",
+		"vcl_miss",
+		"vcl_pass",
+		"vcl_fetch",
+		"vcl_error",
+		"vcl_deliver",
+		"vcl_log",
+		"is_bot",
+		"route_request",
+	}
+	if diff := cmp.Diff(expectNodes, nodes); diff != "" {
+		t.Errorf("Unexpected nodes, diff=%s", diff)
+	}
+
+	edges := make(map[Edge]struct{})
+	for _, e := range g.Edges {
+		edges[*e] = struct{}{}
+	}
+	for _, expect := range []Edge{
+		{From: "vcl_recv", To: "route_request", Kind: EdgeCall, Label: "call"},
+		{From: "vcl_recv", To: "is_bot", Kind: EdgeCall, Label: "call"},
+		{From: "vcl_recv", To: "vcl_error", Kind: EdgeError, Label: "error"},
+		{From: "vcl_recv", To: "vcl_hash", Kind: EdgeState, Label: "return(lookup)"},
+		// return(pass) in user defined subroutine is resolved by the caller's scope
+		{From: "route_request", To: "vcl_hash", Kind: EdgeState, Label: "return(pass)"},
+		{From: "vcl_hash", To: "vcl_pass", Kind: EdgeState, Label: "pass"},
+		{From: "vcl_fetch", To: "vcl_recv", Kind: EdgeRestart, Label: "restart"},
+		{From: "vcl_fetch", To: "vcl_fetch", Kind: EdgeGoto, Label: "goto done"},
+		{From: "vcl_fetch", To: "vcl_deliver", Kind: EdgeState, Label: "return(deliver)"},
+		{From: "vcl_miss", To: "vcl_fetch", Kind: EdgeDefault},
+	} {
+		if _, ok := edges[expect]; !ok {
+			t.Errorf("Expected edge %+v is not found", expect)
+		}
+	}
+}
+
+func TestGraphConcatenatedSubroutines(t *testing.T) {
+	input := `
+sub route_request {
+	return(pass);
+}
+
+sub vcl_recv {
+	call route_request;
+}
+
+sub vcl_recv {
+	error 403;
+}
+`
+	g := New(parseSubroutines(t, input))
+
+	edges := make(map[Edge]struct{})
+	for _, e := range g.Edges {
+		edges[*e] = struct{}{}
+	}
+	for _, expect := range []Edge{
+		{From: "vcl_recv", To: "route_request", Kind: EdgeCall, Label: "call"},
+		{From: "vcl_recv", To: "vcl_error", Kind: EdgeError, Label: "error"},
+		{From: "route_request", To: "vcl_hash", Kind: EdgeState, Label: "return(pass)"},
+		{From: "vcl_hash", To: "vcl_pass", Kind: EdgeState, Label: "pass"},
+	} {
+		if _, ok := edges[expect]; !ok {
+			t.Errorf("Expected edge %+v is not found", expect)
+		}
+	}
+}
+
+func TestGraphRender(t *testing.T) {
+	input := `
+sub vcl_recv {
+	#FASTLY recv
+	return(pass);
+}
+`
+	g := New(parseSubroutines(t, input))
+
+	dot, err := g.Render(FormatDOT)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	for _, expect := range []string{
+		"digraph falco {",
+		`"vcl_recv" -> "vcl_hash" [label="return(pass)", style=bold, color=blue];`,
+		`"vcl_miss" -> "vcl_fetch" [style=dashed, color=gray];`,
+	} {
+		if !strings.Contains(dot, expect) {
+			t.Errorf("DOT output does not contain %q:\n%s", expect, dot)
+		}
+	}
+
+	mermaid, err := g.Render(FormatMermaid)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	for _, expect := range []string{
+		"flowchart LR",
+		`n0(["vcl_recv"])`,
+		`n0 ==>|"return(pass)"| n1`,
+		"n3 -.-> n5",
+	} {
+		if !strings.Contains(mermaid, expect) {
+			t.Errorf("Mermaid output does not contain %q:\n%s", expect, mermaid)
+		}
+	}
+
+	if _, err := g.Render("svg"); err == nil {
+		t.Errorf("Expected error for unsupported format but got nil")
+	}
+}
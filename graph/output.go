@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+// Render returns graph string in the specified format
+func (g *Graph) Render(format string) (string, error) {
+	switch format {
+	case FormatDOT:
+		return g.DOT(), nil
+	case FormatMermaid:
+		return g.Mermaid(), nil
+	default:
+		return "", fmt.Errorf("Unsupported graph format: %s", format)
+	}
+}
+
+// DOT returns Graphviz DOT representation
+func (g *Graph) DOT() string {
+	var buf bytes.Buffer
+
+	buf.WriteString("digraph falco {\n")
+	buf.WriteString("  rankdir=LR;\n")
+	buf.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	buf.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+	buf.WriteString("\n")
+	for _, n := range g.Nodes {
+		var attrs []string
+		if n.IsFastly {
+			attrs = append(attrs, "shape=ellipse", "style=filled", "fillcolor=\"#fdebd0\"")
+		}
+		if n.IsImplicit {
+			attrs = append(attrs, "color=gray", "fontcolor=gray")
+		}
+		if len(attrs) > 0 {
+			buf.WriteString(fmt.Sprintf("  %q [%s];\n", n.Name, strings.Join(attrs, ", ")))
+		} else {
+			buf.WriteString(fmt.Sprintf("  %q;\n", n.Name))
+		}
+	}
+	buf.WriteString("\n")
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=%q", e.Label))
+		}
+		switch e.Kind {
+		case EdgeState:
+			attrs = append(attrs, "style=bold", "color=blue")
+		case EdgeError:
+			attrs = append(attrs, "color=red")
+		case EdgeRestart:
+			attrs = append(attrs, "color=orange")
+		case EdgeGoto:
+			attrs = append(attrs, "style=dotted")
+		case EdgeDefault:
+			attrs = append(attrs, "style=dashed", "color=gray")
+		}
+		buf.WriteString(fmt.Sprintf("  %q -> %q", e.From, e.To))
+		if len(attrs) > 0 {
+			buf.WriteString(fmt.Sprintf(" [%s]", strings.Join(attrs, ", ")))
+		}
+		buf.WriteString(";\n")
+	}
+	buf.WriteString("}\n")
+
+	return buf.String()
+}
+
+// Mermaid returns Mermaid flowchart representation
+func (g *Graph) Mermaid() string {
+	var buf bytes.Buffer
+
+	// Mermaid node id could not contain some characters like "." so we use index based id
+	ids := make(map[string]string)
+	buf.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n.Name] = id
+		if n.IsFastly {
+			buf.WriteString(fmt.Sprintf("  %s([\"%s\"])\n", id, n.Name))
+		} else {
+			buf.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", id, n.Name))
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		switch e.Kind {
+		case EdgeState:
+			arrow = "==>"
+		case EdgeDefault, EdgeGoto:
+			arrow = "-.->"
+		}
+		if e.Label != "" {
+			buf.WriteString(fmt.Sprintf("  %s %s|\"%s\"| %s\n", ids[e.From], arrow, e.Label, ids[e.To]))
+		} else {
+			buf.WriteString(fmt.Sprintf("  %s %s %s\n", ids[e.From], arrow, ids[e.To]))
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"github.com/ysugimoto/falco/ast"
+)
+
+// walker collects graph edge sources from subroutine statements
+type walker struct {
+	subroutines map[string]*ast.SubroutineDeclaration
+
+	calls   []string
+	gotos   []string
+	states  []string
+	restart bool
+	error   bool
+}
+
+func (w *walker) call(name string) {
+	if _, ok := w.subroutines[name]; !ok {
+		return
+	}
+	for _, v := range w.calls {
+		if v == name {
+			return
+		}
+	}
+	w.calls = append(w.calls, name)
+}
+
+func (w *walker) statements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		w.statement(stmt)
+	}
+}
+
+func (w *walker) statement(stmt ast.Statement) {
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		w.statements(t.Statements)
+	case *ast.IfStatement:
+		w.expression(t.Condition)
+		w.statements(t.Consequence.Statements)
+		for _, another := range t.Another {
+			w.expression(another.Condition)
+			w.statements(another.Consequence.Statements)
+		}
+		if t.Alternative != nil {
+			w.statements(t.Alternative.Consequence.Statements)
+		}
+	case *ast.SwitchStatement:
+		w.expression(t.Control.Expression)
+		for _, c := range t.Cases {
+			w.statements(c.Statements)
+		}
+	case *ast.SetStatement:
+		w.expression(t.Value)
+	case *ast.AddStatement:
+		w.expression(t.Value)
+	case *ast.CallStatement:
+		w.call(t.Subroutine.Value)
+	case *ast.FunctionCallStatement:
+		w.call(t.Function.Value)
+		for _, arg := range t.Arguments {
+			w.expression(arg)
+		}
+	case *ast.LogStatement:
+		w.expression(t.Value)
+	case *ast.SyntheticStatement:
+		w.expression(t.Value)
+	case *ast.SyntheticBase64Statement:
+		w.expression(t.Value)
+	case *ast.GotoStatement:
+		w.gotos = append(w.gotos, t.Destination.Value)
+	case *ast.RestartStatement:
+		w.restart = true
+	case *ast.ErrorStatement:
+		w.error = true
+	case *ast.ReturnStatement:
+		if state := returnState(t.ReturnExpression); state != "" {
+			w.states = append(w.states, state)
+		} else if t.ReturnExpression != nil {
+			w.expression(t.ReturnExpression)
+		}
+	}
+}
+
+func (w *walker) expression(expr ast.Expression) {
+	switch t := expr.(type) {
+	case *ast.GroupedExpression:
+		w.expression(t.Right)
+	case *ast.PrefixExpression:
+		w.expression(t.Right)
+	case *ast.PostfixExpression:
+		w.expression(t.Left)
+	case *ast.InfixExpression:
+		w.expression(t.Left)
+		w.expression(t.Right)
+	case *ast.IfExpression:
+		w.expression(t.Condition)
+		w.expression(t.Consequence)
+		w.expression(t.Alternative)
+	case *ast.FunctionCallExpression:
+		w.call(t.Function.Value)
+		for _, arg := range t.Arguments {
+			w.expression(arg)
+		}
+	}
+}
+
+// returnState returns state name of return(state) statement, empty string if not a state
+func returnState(expr ast.Expression) string {
+	switch t := expr.(type) {
+	case *ast.GroupedExpression:
+		return returnState(t.Right)
+	case *ast.Ident:
+		switch t.Value {
+		case "lookup", "hash", "pass", "fetch", "deliver", "deliver_stale", "error":
+			return t.Value
+		}
+	}
+	return ""
+}
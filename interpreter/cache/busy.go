@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"sync"
+)
+
+// BusyObject represents the in-flight backend fetch for the cache hash.
+// Fastly collapses concurrent cache misses for the same hash into one backend fetch,
+// other requests are put on the waiting list until the fetch is completed
+// see: https://developer.fastly.com/learning/concepts/request-collapsing/
+type BusyObject struct {
+	done chan struct{}
+	once sync.Once
+	pass bool
+}
+
+// Wait blocks until the in-flight fetch is completed.
+// Returns true when the fetched response is not cacheable,
+// then waiting requests must not wait anymore and go to pass (pass-on-miss)
+func (b *BusyObject) Wait() bool {
+	<-b.done
+	return b.pass
+}
+
+// Acquire finds the in-flight fetch for the hash.
+// When no request is fetching, the busy object is registered and returned with true
+// so the caller must fetch from backend and call Release after the response is stored.
+// Otherwise, returns the busy object of other request with false so the caller could wait for it
+func (c *Cache) Acquire(hash string) (*BusyObject, bool) {
+	c.busyMu.Lock()
+	defer c.busyMu.Unlock()
+
+	if b, ok := c.busy[hash]; ok {
+		return b, false
+	}
+	b := &BusyObject{done: make(chan struct{})}
+	c.busy[hash] = b
+	return b, true
+}
+
+// Release completes the in-flight fetch and wakes up waiting requests.
+// pass should be true when the response is not cached by beresp.cacheable = false or return(pass) in vcl_fetch.
+// It is safe to call multiple times, only the first call takes effect
+func (c *Cache) Release(hash string, b *BusyObject, pass bool) {
+	b.once.Do(func() {
+		c.busyMu.Lock()
+		if c.busy[hash] == b {
+			delete(c.busy, hash)
+		}
+		c.busyMu.Unlock()
+
+		b.pass = pass
+		close(b.done)
+	})
+}
@@ -2,6 +2,7 @@
 package cache
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -19,27 +20,212 @@ type CacheItem struct {
 	Hits      int
 	LastUsed  time.Duration
 
+	// Stale windows which are counted from Expires.
+	// The item is kept in the cache until both windows have passed
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+
+	// Surrogate keys which are parsed from Surrogate-Key response header
+	SurrogateKeys []string
+
+	// ESI processing is needed on delivering
+	DoESI bool
+
+	// Hit-for-pass object which is created when the response is not cacheable,
+	// requests for the hash go to pass without waiting for the in-flight fetch
+	HitForPass bool
+
 	// private
 	requestedTime time.Time
+	// Item is shared between concurrent requests, guards mutable fields and response body reading
+	mu sync.RWMutex
 }
 
 func (i *CacheItem) Update(d time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	i.Expires = i.EntryTime.Add(d)
 }
 
+func (i *CacheItem) expires() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.Expires
+}
+
+// IsStale returns true when the item has passed its TTL
+func (i *CacheItem) IsStale(now time.Time) bool {
+	return !now.Before(i.expires())
+}
+
+// CanRevalidate returns true when the stale item could be served while revalidating.
+// limit is the request's max_stale_while_revalidate value
+func (i *CacheItem) CanRevalidate(now time.Time, limit time.Duration) bool {
+	return now.Before(i.expires().Add(min(i.StaleWhileRevalidate, limit)))
+}
+
+// CanServeOnError returns true when the stale item could be served instead of an error.
+// limit is the request's max_stale_if_error value
+func (i *CacheItem) CanServeOnError(now time.Time, limit time.Duration) bool {
+	return now.Before(i.expires().Add(min(i.StaleIfError, limit)))
+}
+
+func (i *CacheItem) staleUntil() time.Time {
+	return i.expires().Add(max(i.StaleWhileRevalidate, i.StaleIfError))
+}
+
+// expire marks the item as stale if it is still fresh
+func (i *CacheItem) expire(now time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if now.Before(i.Expires) {
+		i.Expires = now
+	}
+}
+
+// Hit updates cache state - increment Hit count, update last used time
+func (i *CacheItem) Hit() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.Hits++
+	i.LastUsed = time.Since(i.requestedTime)
+	i.requestedTime = time.Now()
+}
+
+// HitStats returns hit count and duration since the item was used last time
+func (i *CacheItem) HitStats() (int, time.Duration) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.Hits, i.LastUsed
+}
+
+// ReadResponse calls fn with the cached response exclusively
+// because reading the response body mutates the reader
+func (i *CacheItem) ReadResponse(fn func(resp *http.Response)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	fn(i.Response)
+}
+
 type Cache struct {
 	storage sync.Map
+
+	// Surrogate key index, key is surrogate key and value is set of cache hash
+	mu   sync.Mutex
+	keys map[string]map[string]struct{}
+
+	// In-flight backend fetches, key is cache hash
+	busyMu sync.Mutex
+	busy   map[string]*BusyObject
 }
 
 func New() *Cache {
-	return &Cache{}
+	return &Cache{
+		keys: make(map[string]map[string]struct{}),
+		busy: make(map[string]*BusyObject),
+	}
 }
 
 func (c *Cache) Set(hash string, item *CacheItem) {
 	item.requestedTime = item.EntryTime
-	c.storage.Store(hash, item)
+	if item.Response != nil {
+		item.SurrogateKeys = strings.Fields(item.Response.Header.Get("Surrogate-Key"))
+	}
+
+	// Swap the item and replace the index exclusively so that concurrent sets on the same hash
+	// do not leave the surrogate keys of the replaced item in the index
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, loaded := c.storage.Swap(hash, item); loaded {
+		if prev, ok := v.(*CacheItem); ok {
+			c.unindex(hash, prev)
+		}
+	}
+	for _, key := range item.SurrogateKeys {
+		if _, ok := c.keys[key]; !ok {
+			c.keys[key] = make(map[string]struct{})
+		}
+		c.keys[key][hash] = struct{}{}
+	}
+}
+
+// unindex removes surrogate key index of the item, caller must hold c.mu
+func (c *Cache) unindex(hash string, item *CacheItem) {
+	for _, key := range item.SurrogateKeys {
+		delete(c.keys[key], hash)
+		if len(c.keys[key]) == 0 {
+			delete(c.keys, key)
+		}
+	}
+}
+
+// delete removes the item only if it is still stored for the hash,
+// the item may have been replaced by the concurrent request
+func (c *Cache) delete(hash string, item *CacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.storage.CompareAndDelete(hash, item) {
+		c.unindex(hash, item)
+	}
 }
 
+// Purge purges the cache item for the hash and returns true if the item exists.
+// On soft purge, the item is marked as stale instead of being removed
+// so that it could be served in its stale windows
+func (c *Cache) Purge(hash string, soft bool) bool {
+	v, ok := c.storage.Load(hash)
+	if !ok {
+		return false
+	}
+	item, ok := v.(*CacheItem)
+	if !ok {
+		return false
+	}
+	if !soft {
+		c.delete(hash, item)
+		return true
+	}
+	item.expire(time.Now())
+	return true
+}
+
+// PurgeKey purges all cache items which are tagged with the surrogate key
+// and returns the number of purged items
+func (c *Cache) PurgeKey(key string, soft bool) int {
+	c.mu.Lock()
+	hashes := make([]string, 0, len(c.keys[key]))
+	for hash := range c.keys[key] {
+		hashes = append(hashes, hash)
+	}
+	c.mu.Unlock()
+
+	var purged int
+	for _, hash := range hashes {
+		if c.Purge(hash, soft) {
+			purged++
+		}
+	}
+	return purged
+}
+
+// PurgeAll removes all cache items and returns the number of purged items.
+// Note that purge all is always hard purge
+func (c *Cache) PurgeAll() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var purged int
+	c.storage.Range(func(k, v any) bool {
+		c.storage.Delete(k)
+		purged++
+		return true
+	})
+	c.keys = make(map[string]map[string]struct{})
+	return purged
+}
+
+// Get returns the cache item which is fresh or still in its stale windows.
+// Caller should check the item staleness by IsStale()
 func (c *Cache) Get(hash string) *CacheItem {
 	// Load and cast to *CacheItem
 	v, ok := c.storage.Load(hash)
@@ -50,19 +236,29 @@ func (c *Cache) Get(hash string) *CacheItem {
 	if !ok {
 		return nil
 	}
-	// Check expiration
-	if time.Now().After(item.Expires) {
-		c.storage.Delete(hash)
+	// Check expiration including stale windows
+	if !time.Now().Before(item.staleUntil()) {
+		c.delete(hash, item)
 		return nil
 	}
-
-	// Update cache state - increment Hit count, update last used time
-	item.Hits++
-	item.LastUsed = time.Since(item.requestedTime)
-	item.requestedTime = time.Now()
 	return item
 }
 
+// ParseCacheControl parses Cache-Control or Surrogate-Control header value to directive map.
+// Directive name is lower-cased and valueless directive has empty string value
+func ParseCacheControl(v string) map[string]string {
+	directives := make(map[string]string)
+	for _, d := range strings.Split(v, ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		name, val, _ := strings.Cut(d, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+	return directives
+}
+
 // Fastly follows its own cache freshness rules
 // see: https://developer.fastly.com/learning/concepts/cache-freshness/
 var unCacheableStatusCodes = []int{200, 203, 300, 301, 302, 404, 410}
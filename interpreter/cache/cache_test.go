@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	actual := ParseCacheControl(`public, Max-Age=60, stale-while-revalidate="30",no-transform`)
+	expect := map[string]string{
+		"public":                 "",
+		"max-age":                "60",
+		"stale-while-revalidate": "30",
+		"no-transform":           "",
+	}
+	if diff := cmp.Diff(expect, actual); diff != "" {
+		t.Errorf("ParseCacheControl result mismatch, diff=%s", diff)
+	}
+}
+
+func TestCacheStaleWindows(t *testing.T) {
+	now := time.Now()
+	item := &CacheItem{
+		Expires:              now.Add(-20 * time.Second),
+		EntryTime:            now.Add(-80 * time.Second),
+		StaleWhileRevalidate: 10 * time.Second,
+		StaleIfError:         60 * time.Second,
+	}
+	if !item.IsStale(now) {
+		t.Errorf("Item should be stale")
+	}
+	if item.CanRevalidate(now, time.Hour) {
+		t.Errorf("Item should not be in stale-while-revalidate window")
+	}
+	if !item.CanServeOnError(now, time.Hour) {
+		t.Errorf("Item should be in stale-if-error window")
+	}
+	if item.CanServeOnError(now, 10*time.Second) {
+		t.Errorf("Item should not be served when max_stale_if_error is exceeded")
+	}
+
+	c := New()
+	c.Set("stale", item)
+	if v := c.Get("stale"); v != item {
+		t.Errorf("Stale item should be kept in stale windows")
+	}
+	c.Set("expired", &CacheItem{
+		Expires:      now.Add(-20 * time.Second),
+		StaleIfError: 10 * time.Second,
+	})
+	if v := c.Get("expired"); v != nil {
+		t.Errorf("Item should be removed after stale windows")
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	now := time.Now()
+	newItem := func(keys string) *CacheItem {
+		return &CacheItem{
+			Response: &http.Response{
+				Header: http.Header{"Surrogate-Key": {keys}},
+			},
+			Expires:              now.Add(time.Minute),
+			EntryTime:            now,
+			StaleWhileRevalidate: time.Minute,
+		}
+	}
+
+	c := New()
+	c.Set("a", newItem("foo bar"))
+	c.Set("b", newItem("bar"))
+	c.Set("c", newItem("baz"))
+
+	if n := c.PurgeKey("bar", true); n != 2 {
+		t.Errorf("Soft purge expects 2 objects, got=%d", n)
+	}
+	if v := c.Get("a"); v == nil || !v.IsStale(time.Now()) {
+		t.Errorf("Soft purged item should be kept as stale")
+	}
+	if n := c.PurgeKey("foo", false); n != 1 {
+		t.Errorf("Hard purge expects 1 object, got=%d", n)
+	}
+	if v := c.Get("a"); v != nil {
+		t.Errorf("Hard purged item should be removed")
+	}
+	if n := c.PurgeKey("foo", false); n != 0 {
+		t.Errorf("Surrogate key index should be removed, got=%d", n)
+	}
+	if !c.Purge("b", false) || c.Purge("b", false) {
+		t.Errorf("URL purge should remove the item only once")
+	}
+	if n := c.PurgeAll(); n != 1 {
+		t.Errorf("Purge all expects 1 object, got=%d", n)
+	}
+}
+
+func TestCacheConcurrentSet(t *testing.T) {
+	now := time.Now()
+	c := New()
+
+	var wg sync.WaitGroup
+	for n := 0; n < 50; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			c.Set("hash", &CacheItem{
+				Response: &http.Response{
+					Header: http.Header{"Surrogate-Key": {fmt.Sprintf("key-%d", n)}},
+				},
+				Expires:   now.Add(time.Minute),
+				EntryTime: now,
+			})
+		}(n)
+	}
+	wg.Wait()
+
+	// Only surrogate key of the stored item must be indexed
+	stored := c.Get("hash")
+	if stored == nil {
+		t.Errorf("Item should be stored")
+		return
+	}
+	if len(c.keys) != 1 {
+		t.Errorf("Only one surrogate key should be indexed, got=%d", len(c.keys))
+	}
+	if _, ok := c.keys[stored.SurrogateKeys[0]]; !ok {
+		t.Errorf("Surrogate key %s of the stored item should be indexed", stored.SurrogateKeys[0])
+	}
+
+	// Expired item must not delete the item which is stored concurrently
+	expired := &CacheItem{Expires: now.Add(-time.Minute)}
+	c.Set("hash", expired)
+	fresh := &CacheItem{Expires: now.Add(time.Minute)}
+	c.Set("hash", fresh)
+	c.delete("hash", expired)
+	if v := c.Get("hash"); v != fresh {
+		t.Errorf("Fresh item should not be deleted")
+	}
+}
+
+func TestCacheBusyObject(t *testing.T) {
+	c := New()
+
+	owner, ok := c.Acquire("hash")
+	if !ok {
+		t.Errorf("First request should be the owner of the in-flight fetch")
+		return
+	}
+	waiter, ok := c.Acquire("hash")
+	if ok || waiter != owner {
+		t.Errorf("Second request should wait for the in-flight fetch")
+		return
+	}
+
+	result := make(chan bool)
+	go func() {
+		result <- waiter.Wait()
+	}()
+	c.Release("hash", owner, true)
+	// Release could be called multiple times
+	c.Release("hash", owner, false)
+
+	if pass := <-result; !pass {
+		t.Errorf("Waiting request should go to pass when the response is not cacheable")
+	}
+	if _, ok := c.Acquire("hash"); !ok {
+		t.Errorf("Busy object should be removed after released")
+	}
+}
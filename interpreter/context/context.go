@@ -9,6 +9,9 @@ import (
 	"github.com/ysugimoto/falco/ast"
 	"github.com/ysugimoto/falco/config"
 	"github.com/ysugimoto/falco/interpreter/cache"
+	"github.com/ysugimoto/falco/interpreter/geoip"
+	"github.com/ysugimoto/falco/interpreter/har"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"github.com/ysugimoto/falco/resolver"
 	"github.com/ysugimoto/falco/snippets"
@@ -50,12 +53,17 @@ type Context struct {
 	Backends            map[string]*value.Backend
 	Tables              map[string]*ast.TableDeclaration
 	Subroutines         map[string]*ast.SubroutineDeclaration
-	Penaltyboxes        map[string]*ast.PenaltyboxDeclaration
-	Ratecounters        map[string]*ast.RatecounterDeclaration
+	Penaltyboxes        map[string]*ratelimit.Penaltybox
+	Ratecounters        map[string]*ratelimit.Ratecounter
 	Gotos               map[string]*ast.GotoStatement
 	SubroutineFunctions map[string]*ast.SubroutineDeclaration
 	OriginalHost        string
 	IsActualResponse    bool
+	// True when the request is processed on the shield node in multi-node simulation
+	IsShieldNode bool
+	// Record backend traffic to HAR file, or replay backend responses from HAR file
+	HARRecorder *har.Recorder
+	HARReplayer *har.Replayer
 
 	OverrideMaxBackends    int
 	OverrideMaxAcls        int
@@ -76,6 +84,12 @@ type Context struct {
 	RequestEndTime   time.Time
 	RequestStartTime time.Time
 	CacheHitItem     *cache.CacheItem
+	GeoIP            *geoip.Database
+	StaleItem        *cache.CacheItem
+	// In-flight fetch which this request is responsible for, other requests for the same hash wait for it
+	CacheBusyObject *cache.BusyObject
+	// Request is processed in pass mode, the backend response must not be stored in the cache
+	IsPass bool
 
 	// Interpreter states, following variables could be set in each subroutine directives
 	Restarts                            int
@@ -88,7 +102,7 @@ type Context struct {
 	Stale                               *value.Boolean
 	StaleIsError                        *value.Boolean
 	StaleIsRevalidating                 *value.Boolean
-	StaleContents                       *value.String
+	StaleExists                         *value.Boolean
 	FastlyError                         *value.String
 	ClientIdentity                      *value.String
 	ClientGeoIpOverride                 *value.String
@@ -104,6 +118,7 @@ type Context struct {
 	HashIgnoreBusy                      *value.Boolean
 	SegmentedCacheingBlockSize          *value.Integer
 	ESILevel                            *value.Integer
+	ESITopURL                           string
 	WafAnomalyScore                     *value.Integer
 	WafBlocked                          *value.Boolean
 	WafCounter                          *value.Integer
@@ -140,6 +155,8 @@ type Context struct {
 	BackendResponseStatus               *value.Integer
 	BackendResponseTTL                  *value.RTime
 	ObjectGrace                         *value.RTime
+	ObjectIsStale                       *value.Boolean
+	ObjectStaleWhileRevalidate          *value.RTime
 	ObjectTTL                           *value.RTime
 	ObjectStatus                        *value.Integer
 	ObjectResponse                      *value.String
@@ -156,6 +173,9 @@ type Context struct {
 	// reset this when process is outgoing for each subroutines
 	RegexMatchedValues map[string]*value.String
 
+	// Most recently incremented entry for each ratecounter, used for "ratecounter.{NAME}.*" variables
+	RatecounterEntries map[string]string
+
 	// Modify states from builtin functions
 	DisableCompressionHeaders []string // modified via "h2.disable_header_compression"
 	PushResources             []string // modified via "h2.push"
@@ -175,8 +195,8 @@ func New(options ...Option) *Context {
 		Backends:               make(map[string]*value.Backend),
 		Tables:                 make(map[string]*ast.TableDeclaration),
 		Subroutines:            make(map[string]*ast.SubroutineDeclaration),
-		Penaltyboxes:           make(map[string]*ast.PenaltyboxDeclaration),
-		Ratecounters:           make(map[string]*ast.RatecounterDeclaration),
+		Penaltyboxes:           make(map[string]*ratelimit.Penaltybox),
+		Ratecounters:           make(map[string]*ratelimit.Ratecounter),
 		Gotos:                  make(map[string]*ast.GotoStatement),
 		SubroutineFunctions:    make(map[string]*ast.SubroutineDeclaration),
 		OverrideBackends:       make(map[string]*config.OverrideBackend),
@@ -195,7 +215,7 @@ func New(options ...Option) *Context {
 		Stale:                           &value.Boolean{},
 		StaleIsError:                    &value.Boolean{},
 		StaleIsRevalidating:             &value.Boolean{},
-		StaleContents:                   &value.String{},
+		StaleExists:                     &value.Boolean{},
 		FastlyError:                     &value.String{},
 		ClientGeoIpOverride:             &value.String{},
 		ClientSocketCongestionAlgorithm: &value.String{Value: "cubic"},
@@ -254,6 +274,8 @@ func New(options ...Option) *Context {
 		BackendResponseStatus:               &value.Integer{},
 		BackendResponseTTL:                  &value.RTime{},
 		ObjectGrace:                         &value.RTime{},
+		ObjectIsStale:                       &value.Boolean{},
+		ObjectStaleWhileRevalidate:          &value.RTime{},
 		ObjectTTL:                           &value.RTime{},
 		ObjectStatus:                        &value.Integer{Value: 500},
 		ObjectResponse:                      &value.String{Value: "error"},
@@ -263,6 +285,7 @@ func New(options ...Option) *Context {
 
 		RegexMatchedValues: make(map[string]*value.String),
 		SubroutineCalls:    make(map[string]int),
+		RatecounterEntries: make(map[string]string),
 
 		OverrideVariables: make(map[string]value.Value),
 	}
@@ -274,3 +297,11 @@ func New(options ...Option) *Context {
 
 	return ctx
 }
+
+// Now returns the current time, or fixed time if the time is fixed in testing
+func (c *Context) Now() time.Time {
+	if c.FixedTime != nil {
+		return *c.FixedTime
+	}
+	return time.Now()
+}
@@ -2,6 +2,8 @@ package context
 
 import (
 	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/interpreter/geoip"
+	"github.com/ysugimoto/falco/interpreter/har"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"github.com/ysugimoto/falco/resolver"
 	"github.com/ysugimoto/falco/snippets"
@@ -57,12 +59,36 @@ func WithInjectEdgeDictionaries(ed map[string]config.EdgeDictionary) Option {
 	}
 }
 
+func WithGeoIP(db *geoip.Database) Option {
+	return func(c *Context) {
+		c.GeoIP = db
+	}
+}
+
 func WithActualResponse(is bool) Option {
 	return func(c *Context) {
 		c.IsActualResponse = is
 	}
 }
 
+func WithShieldNode(is bool) Option {
+	return func(c *Context) {
+		c.IsShieldNode = is
+	}
+}
+
+func WithHARRecorder(r *har.Recorder) Option {
+	return func(c *Context) {
+		c.HARRecorder = r
+	}
+}
+
+func WithHARReplayer(r *har.Replayer) Option {
+	return func(c *Context) {
+		c.HARReplayer = r
+	}
+}
+
 func WithOverrideVariales(variables map[string]any) Option {
 	return func(c *Context) {
 		for k, v := range variables {
@@ -0,0 +1,175 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Cobertura XML structures
+// see: https://github.com/cobertura/cobertura/blob/master/cobertura/src/site/htdocs/xml/coverage-04.dtd
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        float64            `xml:"line-rate,attr"`
+	BranchRate      float64            `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      float64            `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string            `xml:"name,attr"`
+	Filename   string            `xml:"filename,attr"`
+	LineRate   float64           `xml:"line-rate,attr"`
+	BranchRate float64           `xml:"branch-rate,attr"`
+	Complexity float64           `xml:"complexity,attr"`
+	Methods    []coberturaMethod `xml:"methods>method"`
+	Lines      []coberturaLine   `xml:"lines>line"`
+}
+
+type coberturaMethod struct {
+	Name       string          `xml:"name,attr"`
+	Signature  string          `xml:"signature,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Complexity float64         `xml:"complexity,attr"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              int    `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+}
+
+func rate(c Counter) float64 {
+	return c.Percentage() / 100
+}
+
+// WriteCobertura writes collected coverage as Cobertura XML format
+func (c *Collector) WriteCobertura(w io.Writer, source string) error {
+	summary := c.Summarize()
+	files := c.Files()
+
+	pkg := coberturaPackage{
+		Name:       "vcl",
+		LineRate:   rate(summary.Statements),
+		BranchRate: rate(summary.Branches),
+	}
+	var linesCovered, linesValid int
+	for index, f := range files {
+		fs := summary.Files[index]
+		lines := coberturaLines(f.Statements, f.Branches)
+		for _, l := range lines {
+			linesValid++
+			if l.Hits > 0 {
+				linesCovered++
+			}
+		}
+
+		// Filename should be relative path from the source directory
+		filename := f.Name
+		if rel, err := filepath.Rel(source, f.Name); err == nil {
+			filename = rel
+		}
+		class := coberturaClass{
+			Name:       filename,
+			Filename:   filename,
+			LineRate:   rate(fs.Statements),
+			BranchRate: rate(fs.Branches),
+			Lines:      lines,
+		}
+		for _, d := range fs.Details {
+			var stmts []Statement
+			var branches []Branch
+			for _, v := range f.Statements {
+				if v.Subroutine == d.Name {
+					stmts = append(stmts, v)
+				}
+			}
+			for _, v := range f.Branches {
+				if v.Subroutine == d.Name {
+					branches = append(branches, v)
+				}
+			}
+			class.Methods = append(class.Methods, coberturaMethod{
+				Name:       d.Name,
+				LineRate:   rate(d.Statements),
+				BranchRate: rate(d.Branches),
+				Lines:      coberturaLines(stmts, branches),
+			})
+		}
+		pkg.Classes = append(pkg.Classes, class)
+	}
+
+	v := coberturaCoverage{
+		LineRate:        rate(summary.Statements),
+		BranchRate:      rate(summary.Branches),
+		LinesCovered:    linesCovered,
+		LinesValid:      linesValid,
+		BranchesCovered: summary.Branches.Covered,
+		BranchesValid:   summary.Branches.Total,
+		Version:         "falco",
+		Timestamp:       time.Now().Unix(),
+		Sources:         []string{source},
+		Packages:        []coberturaPackage{pkg},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func coberturaLines(stmts []Statement, branches []Branch) []coberturaLine {
+	hits := lineHits(&File{Statements: stmts})
+
+	conditions := make(map[int]*Counter)
+	for _, v := range branches {
+		if _, ok := conditions[v.Line]; !ok {
+			conditions[v.Line] = &Counter{}
+		}
+		conditions[v.Line].add(v.Hits)
+	}
+
+	var nums []int
+	for n := range hits {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	var lines []coberturaLine
+	for _, n := range nums {
+		line := coberturaLine{Number: n, Hits: hits[n]}
+		if c, ok := conditions[n]; ok {
+			line.Branch = true
+			line.ConditionCoverage = fmt.Sprintf("%.0f%% (%d/%d)", c.Percentage(), c.Covered, c.Total)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
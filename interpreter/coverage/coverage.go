@@ -0,0 +1,242 @@
+// Package coverage collects executed statements, branches and subroutines of VCL
+// while the interpreter runs, and reports them in various formats
+package coverage
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ysugimoto/falco/ast"
+)
+
+// Location points to the source position of the node.
+// We identify the node by its location because AST is parsed for each interpreter process
+type Location struct {
+	File     string
+	Line     int
+	Position int
+}
+
+func locationOf(node ast.Node) Location {
+	tok := node.GetMeta().Token
+	return Location{
+		File:     tok.File,
+		Line:     tok.Line,
+		Position: tok.Position,
+	}
+}
+
+type Subroutine struct {
+	Location
+	Name string
+	Hits int
+}
+
+type Statement struct {
+	Location
+	Subroutine string
+	Hits       int
+}
+
+// Branch represents one of the conditional paths of if or switch statement.
+// Index is the order of the path - for if statement, consequence is 0, else-if is 1...N,
+// and else (or implicit else) is N+1. For switch statement, index is the order of case
+// and implicit default is appended at the last if the statement does not have it.
+type Branch struct {
+	Location
+	Subroutine string
+	Index      int
+	Hits       int
+}
+
+type branchKey struct {
+	Location
+	Index int
+}
+
+type Collector struct {
+	mu          sync.Mutex
+	subroutines map[Location]*Subroutine
+	names       map[string][]*Subroutine // index by name
+	statements  map[Location]*Statement
+	branches    map[branchKey]*Branch
+}
+
+func New() *Collector {
+	return &Collector{
+		subroutines: make(map[Location]*Subroutine),
+		names:       make(map[string][]*Subroutine),
+		statements:  make(map[Location]*Statement),
+		branches:    make(map[branchKey]*Branch),
+	}
+}
+
+// RegisterSubroutine registers coverage targets in the subroutine.
+// Statements must be resolved include statements.
+func (c *Collector) RegisterSubroutine(sub *ast.SubroutineDeclaration, statements []ast.Statement) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loc := locationOf(sub)
+	if _, ok := c.subroutines[loc]; !ok {
+		v := &Subroutine{Location: loc, Name: sub.Name.Value}
+		c.subroutines[loc] = v
+		c.names[v.Name] = append(c.names[v.Name], v)
+	}
+	c.registerStatements(sub.Name.Value, statements)
+}
+
+// Caller must hold the lock
+func (c *Collector) registerStatements(sub string, statements []ast.Statement) {
+	for _, stmt := range statements {
+		loc := locationOf(stmt)
+		if _, ok := c.statements[loc]; !ok {
+			c.statements[loc] = &Statement{Location: loc, Subroutine: sub}
+		}
+
+		switch t := stmt.(type) {
+		case *ast.BlockStatement:
+			c.registerStatements(sub, t.Statements)
+		case *ast.IfStatement:
+			c.registerBranch(sub, loc, 0)
+			c.registerStatements(sub, t.Consequence.Statements)
+			for n, ei := range t.Another {
+				c.registerBranch(sub, loc, n+1)
+				c.registerStatements(sub, ei.Consequence.Statements)
+			}
+			// Else branch is registered even if it is not present because condition could fall through
+			c.registerBranch(sub, loc, len(t.Another)+1)
+			if t.Alternative != nil {
+				c.registerStatements(sub, t.Alternative.Consequence.Statements)
+			}
+		case *ast.SwitchStatement:
+			for n, cs := range t.Cases {
+				c.registerBranch(sub, loc, n)
+				c.registerStatements(sub, cs.Statements)
+			}
+			// Implicit default branch
+			if t.Default == -1 {
+				c.registerBranch(sub, loc, len(t.Cases))
+			}
+		}
+	}
+}
+
+// Caller must hold the lock
+func (c *Collector) registerBranch(sub string, loc Location, index int) {
+	key := branchKey{Location: loc, Index: index}
+	if _, ok := c.branches[key]; !ok {
+		c.branches[key] = &Branch{Location: loc, Subroutine: sub, Index: index}
+	}
+}
+
+// MarkSubroutine records the subroutine is called.
+// Mark by name because Fastly reserved subroutines declared in multiple places are concatenated into one
+func (c *Collector) MarkSubroutine(sub *ast.SubroutineDeclaration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, v := range c.names[sub.Name.Value] {
+		v.Hits++
+	}
+}
+
+// MarkStatement records the statement is executed
+func (c *Collector) MarkStatement(stmt ast.Statement) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.statements[locationOf(stmt)]; ok {
+		v.Hits++
+	}
+}
+
+// MarkBranch records the branch path of if or switch statement is taken
+func (c *Collector) MarkBranch(stmt ast.Statement, index int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.branches[branchKey{Location: locationOf(stmt), Index: index}]; ok {
+		v.Hits++
+	}
+}
+
+// Files returns collected coverage grouped by file, sorted by filename
+func (c *Collector) Files() []*File {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files := make(map[string]*File)
+	get := func(name string) *File {
+		if f, ok := files[name]; ok {
+			return f
+		}
+		f := &File{Name: name}
+		files[name] = f
+		return f
+	}
+
+	for _, v := range c.subroutines {
+		f := get(v.File)
+		f.Subroutines = append(f.Subroutines, *v)
+	}
+	for _, v := range c.statements {
+		f := get(v.File)
+		f.Statements = append(f.Statements, *v)
+	}
+	for _, v := range c.branches {
+		f := get(v.File)
+		f.Branches = append(f.Branches, *v)
+	}
+
+	var ret []*File
+	for _, f := range files {
+		f.sort()
+		ret = append(ret, f)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+func less(a, b Location) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Position < b.Position
+}
+
+type File struct {
+	Name        string
+	Subroutines []Subroutine
+	Statements  []Statement
+	Branches    []Branch
+}
+
+func (f *File) sort() {
+	sort.Slice(f.Subroutines, func(i, j int) bool {
+		return less(f.Subroutines[i].Location, f.Subroutines[j].Location)
+	})
+	sort.Slice(f.Statements, func(i, j int) bool {
+		return less(f.Statements[i].Location, f.Statements[j].Location)
+	})
+	sort.Slice(f.Branches, func(i, j int) bool {
+		if f.Branches[i].Location == f.Branches[j].Location {
+			return f.Branches[i].Index < f.Branches[j].Index
+		}
+		return less(f.Branches[i].Location, f.Branches[j].Location)
+	})
+}
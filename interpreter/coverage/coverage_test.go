@@ -0,0 +1,180 @@
+package coverage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+func parseVCL(t *testing.T, input string) *ast.VCL {
+	vcl, err := parser.New(lexer.NewFromString(input, lexer.WithFile("main.vcl"))).ParseVCL()
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %s", err)
+	}
+	return vcl
+}
+
+const testVCL = `
+sub vcl_recv {
+  set req.http.Foo = "bar";
+  if (req.http.Foo == "bar") {
+    set req.http.Bar = "baz";
+  } else if (req.http.Foo == "baz") {
+    set req.http.Bar = "qux";
+  }
+  switch (req.http.Foo) {
+  case "bar":
+    set req.http.Baz = "1";
+    break;
+  default:
+    set req.http.Baz = "2";
+    break;
+  }
+}
+
+sub vcl_deliver {
+  set resp.http.Foo = "bar";
+}
+`
+
+func TestCollector(t *testing.T) {
+	vcl := parseVCL(t, testVCL)
+	c := New()
+	for _, stmt := range vcl.Statements {
+		sub := stmt.(*ast.SubroutineDeclaration)
+		c.RegisterSubroutine(sub, sub.Block.Statements)
+	}
+
+	// Simulate vcl_recv execution: consequence of if and first case are taken
+	recv := vcl.Statements[0].(*ast.SubroutineDeclaration)
+	c.MarkSubroutine(recv)
+	for _, stmt := range recv.Block.Statements {
+		c.MarkStatement(stmt)
+	}
+	ifStmt := recv.Block.Statements[1].(*ast.IfStatement)
+	c.MarkBranch(ifStmt, 0)
+	c.MarkStatement(ifStmt.Consequence.Statements[0])
+	switchStmt := recv.Block.Statements[2].(*ast.SwitchStatement)
+	c.MarkBranch(switchStmt, 0)
+	c.MarkStatement(switchStmt.Cases[0].Statements[0])
+	c.MarkStatement(switchStmt.Cases[0].Statements[1])
+
+	summary := c.Summarize()
+	expect := &Summary{
+		Subroutines: Counter{Covered: 1, Total: 2},
+		Statements:  Counter{Covered: 6, Total: 10},
+		Branches:    Counter{Covered: 2, Total: 5},
+		Files: []*FileSummary{
+			{
+				Name:        "main.vcl",
+				Subroutines: Counter{Covered: 1, Total: 2},
+				Statements:  Counter{Covered: 6, Total: 10},
+				Branches:    Counter{Covered: 2, Total: 5},
+				Details: []*SubroutineSummary{
+					{
+						Name:       "vcl_recv",
+						Line:       2,
+						Hits:       1,
+						Statements: Counter{Covered: 6, Total: 9},
+						Branches:   Counter{Covered: 2, Total: 5},
+					},
+					{
+						Name:       "vcl_deliver",
+						Line:       19,
+						Statements: Counter{Covered: 0, Total: 1},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, summary); diff != "" {
+		t.Errorf("Summary mismatch, diff=%s", diff)
+	}
+}
+
+func TestMarkUnregisteredNode(t *testing.T) {
+	vcl := parseVCL(t, testVCL)
+	c := New()
+	sub := vcl.Statements[0].(*ast.SubroutineDeclaration)
+	c.MarkSubroutine(sub)
+	c.MarkStatement(sub.Block.Statements[0])
+	if files := c.Files(); len(files) != 0 {
+		t.Errorf("Unregistered nodes must not be collected")
+	}
+
+	// Nil collector is safe to call
+	var nilCollector *Collector
+	nilCollector.MarkStatement(sub.Block.Statements[0])
+}
+
+func TestWriteLcov(t *testing.T) {
+	vcl := parseVCL(t, `
+sub vcl_recv {
+  if (req.http.Foo) {
+    set req.http.Bar = "baz";
+  }
+}
+`)
+	c := New()
+	sub := vcl.Statements[0].(*ast.SubroutineDeclaration)
+	c.RegisterSubroutine(sub, sub.Block.Statements)
+	c.MarkSubroutine(sub)
+	c.MarkStatement(sub.Block.Statements[0])
+	c.MarkBranch(sub.Block.Statements[0], 1)
+
+	var buf bytes.Buffer
+	if err := c.WriteLcov(&buf); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expect := strings.Join([]string{
+		"TN:",
+		"SF:main.vcl",
+		"FN:2,vcl_recv",
+		"FNDA:1,vcl_recv",
+		"FNF:1",
+		"FNH:1",
+		"BRDA:3,0,0,-",
+		"BRDA:3,0,1,1",
+		"BRF:2",
+		"BRH:1",
+		"DA:3,1",
+		"DA:4,0",
+		"LF:2",
+		"LH:1",
+		"end_of_record",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("lcov output mismatch, diff=%s", diff)
+	}
+}
+
+func TestWriteCobertura(t *testing.T) {
+	vcl := parseVCL(t, testVCL)
+	c := New()
+	sub := vcl.Statements[0].(*ast.SubroutineDeclaration)
+	c.RegisterSubroutine(sub, sub.Block.Statements)
+	c.MarkSubroutine(sub)
+
+	var buf bytes.Buffer
+	if err := c.WriteCobertura(&buf, "."); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	out := buf.String()
+	for _, expect := range []string{
+		`<class name="main.vcl" filename="main.vcl"`,
+		`<method name="vcl_recv"`,
+		`<line number="4" hits="0" branch="true" condition-coverage="0% (0/3)"></line>`,
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("Cobertura output should contain %s", expect)
+		}
+	}
+}
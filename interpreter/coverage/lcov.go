@@ -0,0 +1,84 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// WriteLcov writes collected coverage as lcov tracefile format
+// see: https://github.com/linux-test-project/lcov/blob/master/man/geninfo.1
+func (c *Collector) WriteLcov(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+
+	for _, f := range c.Files() {
+		fmt.Fprintln(buf, "TN:")
+		fmt.Fprintf(buf, "SF:%s\n", f.Name)
+
+		// Functions
+		var fnHit int
+		for _, v := range f.Subroutines {
+			fmt.Fprintf(buf, "FN:%d,%s\n", v.Line, v.Name)
+		}
+		for _, v := range f.Subroutines {
+			fmt.Fprintf(buf, "FNDA:%d,%s\n", v.Hits, v.Name)
+			if v.Hits > 0 {
+				fnHit++
+			}
+		}
+		fmt.Fprintf(buf, "FNF:%d\n", len(f.Subroutines))
+		fmt.Fprintf(buf, "FNH:%d\n", fnHit)
+
+		// Branches, block number is the order of branching statement in the line
+		var brHit int
+		blocks := make(map[Location]int)
+		lineBlocks := make(map[int]int)
+		for _, v := range f.Branches {
+			block, ok := blocks[v.Location]
+			if !ok {
+				block = lineBlocks[v.Line]
+				blocks[v.Location] = block
+				lineBlocks[v.Line]++
+			}
+			taken := "-"
+			if v.Hits > 0 {
+				taken = fmt.Sprint(v.Hits)
+				brHit++
+			}
+			fmt.Fprintf(buf, "BRDA:%d,%d,%d,%s\n", v.Line, block, v.Index, taken)
+		}
+		fmt.Fprintf(buf, "BRF:%d\n", len(f.Branches))
+		fmt.Fprintf(buf, "BRH:%d\n", brHit)
+
+		// Lines, hit count of the line is the maximum count of statements in the line
+		lines := lineHits(f)
+		var nums []int
+		for n := range lines {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+		var lineHit int
+		for _, n := range nums {
+			fmt.Fprintf(buf, "DA:%d,%d\n", n, lines[n])
+			if lines[n] > 0 {
+				lineHit++
+			}
+		}
+		fmt.Fprintf(buf, "LF:%d\n", len(nums))
+		fmt.Fprintf(buf, "LH:%d\n", lineHit)
+		fmt.Fprintln(buf, "end_of_record")
+	}
+
+	return buf.Flush()
+}
+
+func lineHits(f *File) map[int]int {
+	lines := make(map[int]int)
+	for _, v := range f.Statements {
+		if hits, ok := lines[v.Line]; !ok || v.Hits > hits {
+			lines[v.Line] = v.Hits
+		}
+	}
+	return lines
+}
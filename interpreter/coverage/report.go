@@ -0,0 +1,95 @@
+package coverage
+
+import (
+	"sort"
+)
+
+// Counter holds the number of covered items and total items
+type Counter struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+// Percentage returns covered rate in percentage. Returns 100 if there is no target
+func (c Counter) Percentage() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return float64(c.Covered) / float64(c.Total) * 100
+}
+
+func (c *Counter) add(hits int) {
+	c.Total++
+	if hits > 0 {
+		c.Covered++
+	}
+}
+
+type SubroutineSummary struct {
+	Name       string  `json:"name"`
+	Line       int     `json:"line"`
+	Hits       int     `json:"hits"`
+	Statements Counter `json:"statements"`
+	Branches   Counter `json:"branches"`
+}
+
+type FileSummary struct {
+	Name        string               `json:"file"`
+	Subroutines Counter              `json:"subroutines"`
+	Statements  Counter              `json:"statements"`
+	Branches    Counter              `json:"branches"`
+	Details     []*SubroutineSummary `json:"details"`
+}
+
+type Summary struct {
+	Subroutines Counter        `json:"subroutines"`
+	Statements  Counter        `json:"statements"`
+	Branches    Counter        `json:"branches"`
+	Files       []*FileSummary `json:"files"`
+}
+
+// Summarize aggregates collected coverage per file and per subroutine
+func (c *Collector) Summarize() *Summary {
+	s := &Summary{}
+
+	for _, f := range c.Files() {
+		fs := &FileSummary{Name: f.Name}
+		subs := make(map[string]*SubroutineSummary)
+		for _, v := range f.Subroutines {
+			fs.Subroutines.add(v.Hits)
+			subs[v.Name] = &SubroutineSummary{
+				Name: v.Name,
+				Line: v.Line,
+				Hits: v.Hits,
+			}
+		}
+		for _, v := range f.Statements {
+			fs.Statements.add(v.Hits)
+			if sub, ok := subs[v.Subroutine]; ok {
+				sub.Statements.add(v.Hits)
+			}
+		}
+		for _, v := range f.Branches {
+			fs.Branches.add(v.Hits)
+			if sub, ok := subs[v.Subroutine]; ok {
+				sub.Branches.add(v.Hits)
+			}
+		}
+		for _, v := range subs {
+			fs.Details = append(fs.Details, v)
+		}
+		sort.Slice(fs.Details, func(i, j int) bool {
+			return fs.Details[i].Line < fs.Details[j].Line
+		})
+
+		s.Subroutines.Covered += fs.Subroutines.Covered
+		s.Subroutines.Total += fs.Subroutines.Total
+		s.Statements.Covered += fs.Statements.Covered
+		s.Statements.Total += fs.Statements.Total
+		s.Branches.Covered += fs.Branches.Covered
+		s.Branches.Total += fs.Branches.Total
+		s.Files = append(s.Files, fs)
+	}
+
+	return s
+}
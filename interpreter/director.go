@@ -151,6 +151,30 @@ func (i *Interpreter) setDirectorConfigProperty(conf *value.DirectorConfig, prop
 			conf.VNodesPerNode = int(v.Value)
 		}
 		return nil
+	case "shield":
+		if conf.Type != DIRECTORTYPE_SHIELD {
+			return exception.Runtime(
+				&prop.GetMeta().Token,
+				".shield field must be present only in shield director type",
+			)
+		}
+		if v, ok := prop.Value.(*ast.String); !ok {
+			return exception.Runtime(&prop.GetMeta().Token, ".shield value must be string")
+		} else {
+			conf.Shield = v.Value
+		}
+		return nil
+	case "is_ssl":
+		if conf.Type != DIRECTORTYPE_SHIELD {
+			return exception.Runtime(
+				&prop.GetMeta().Token,
+				".is_ssl field must be present only in shield director type",
+			)
+		}
+		if _, ok := prop.Value.(*ast.Boolean); !ok {
+			return exception.Runtime(&prop.GetMeta().Token, ".is_ssl value must be boolean")
+		}
+		return nil
 	}
 	return exception.Runtime(&prop.GetMeta().Token, "Unexpected director property '%s' found", prop.Key.Value)
 }
@@ -230,6 +254,11 @@ func (i *Interpreter) getDirectorConfig(d *ast.DirectorDeclaration) (*value.Dire
 }
 
 func (i *Interpreter) createDirectorRequest(ctx *context.Context, dc *value.DirectorConfig) (*http.Request, error) {
+	// Shield director forwards the request to the shield node instead of selecting backend
+	if dc.Type == DIRECTORTYPE_SHIELD {
+		return i.createShieldRequest(ctx, dc)
+	}
+
 	var backend *value.Backend
 	var err error
 
@@ -2,22 +2,16 @@ package interpreter
 
 import (
 	"bytes"
-	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"path/filepath"
-	"regexp"
-	"strings"
-	"time"
+	"strconv"
 
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/interpreter/esi"
 	"github.com/ysugimoto/falco/interpreter/exception"
-)
-
-var (
-	esiIncludeRegex = regexp.MustCompile(`<esi:include\s*src=['"]([^'"]+)['"]\s*/?\s*>`)
-	esiRemoveStart  = []byte("<esi:remove>")
-	esiRemoveEnd    = []byte("</esi:remove>")
+	"github.com/ysugimoto/falco/interpreter/limitations"
 )
 
 func (i *Interpreter) executeESI() error {
@@ -31,97 +25,117 @@ func (i *Interpreter) executeESI() error {
 		return err
 	}
 
-	body := respBody.Bytes()
-	req := i.ctx.Request
-	ctx := i.ctx.Request.Context()
-	var parsed []byte
-	for {
-		match := esiIncludeRegex.FindSubmatchIndex(body)
-		if len(match) == 0 {
-			break
-		}
-		previous := body[0:match[0]]
-		src := body[match[2]:match[3]]
-		body = body[match[1]:]
-		parsed = append(parsed, previous...)
-
-		// resolve inclusion
-		cloned := req.Clone(ctx)
-		partial, err := executeEsiInclude(ctx, cloned, src)
-		if err != nil {
-			// If ESI inclusion failed, find <esi:remove> tag and use its nodeText
-			index := bytes.Index(body, esiRemoveStart)
-			if index == -1 {
-				break
-			}
-			body = body[index+len(esiRemoveStart):]
+	nodes, err := esi.Parse(respBody.Bytes())
+	if err != nil {
+		return exception.Runtime(nil, err.Error())
+	}
 
-			// Find </esi:remove> tag
-			index = bytes.Index(body, esiRemoveEnd)
-			if index == -1 {
-				return exception.Runtime(nil, "Syntax error: does not seem to close </esi:remove>")
-			}
-			parsed = append(parsed, body[0:index]...)
-			body = body[index+len(esiRemoveEnd):]
-		} else {
-			parsed = append(parsed, partial...)
-			// If ESI inclusion succeeded, find <esi:remove>...</esi:remove> tag and remove it
-			index := bytes.Index(body, esiRemoveStart)
-			if index == -1 {
-				continue
+	var parsed bytes.Buffer
+	for _, node := range nodes {
+		switch t := node.(type) {
+		case *esi.Text:
+			parsed.Write(t.Value)
+		case *esi.Include:
+			partial, err := i.executeEsiInclude(t)
+			if err != nil {
+				return errors.WithStack(err)
 			}
-			body = body[index+len(esiRemoveStart):]
-			index = bytes.Index(body, esiRemoveEnd)
-			if index == -1 {
-				return exception.Runtime(nil, "Syntax error: does not seem to close </esi:remove>")
-			}
-			body = body[index+len(esiRemoveEnd):]
+			parsed.Write(partial)
 		}
 	}
 
-	// remaining bytes
-	if len(body) > 0 {
-		parsed = append(parsed, body...)
+	resp.Body = io.NopCloser(bytes.NewReader(parsed.Bytes()))
+	resp.ContentLength = int64(parsed.Len())
+	if resp.Header.Get("Content-Length") != "" {
+		resp.Header.Set("Content-Length", strconv.Itoa(parsed.Len()))
 	}
-	resp.Body = io.NopCloser(bytes.NewReader(parsed))
 	return nil
 }
 
-func executeEsiInclude(ctx context.Context, req *http.Request, includeUrl []byte) ([]byte, error) {
-	ctx, timeout := context.WithTimeout(ctx, 10*time.Second)
-	defer timeout()
+// executeEsiInclude resolves <esi:include> tag.
+// If src inclusion failed, try to include alt url and then skip it when onerror="continue" is specified.
+// Note that Fastly includes the fragment even if the response status is error
+func (i *Interpreter) executeEsiInclude(include *esi.Include) ([]byte, error) {
+	body, err := i.processEsiSubrequest(include.Src)
+	if err != nil && include.Alt != "" {
+		i.Debugger.Message(fmt.Sprintf("ESI include %s failed: %s, try alt %s", include.Src, err, include.Alt))
+		body, err = i.processEsiSubrequest(include.Alt)
+	}
+	if err == nil {
+		return body, nil
+	}
+	if include.ContinueOnError {
+		i.Debugger.Message(fmt.Sprintf("ESI include %s failed: %s, continue", include.Src, err))
+		return nil, nil
+	}
+	if body != nil {
+		return body, nil
+	}
+	return nil, errors.WithStack(err)
+}
 
-	if err := resolveIncludeURL(req, string(includeUrl)); err != nil {
-		return nil, err
+// processEsiSubrequest processes ESI subrequest through the VCL pipeline like Fastly does.
+// Returns response body with an error when the fragment responds error status
+func (i *Interpreter) processEsiSubrequest(src string) ([]byte, error) {
+	level := i.ctx.ESILevel.Value + 1
+	if level > limitations.MaxEsiDepth {
+		return nil, exception.Runtime(nil, "ESI include depth exceeds limit of %d", limitations.MaxEsiDepth)
 	}
 
-	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	req, err := createEsiRequest(i.ctx.Request, src)
 	if err != nil {
-		return nil, err
+		return nil, exception.Runtime(nil, "Invalid ESI include src %s: %s", src, err)
 	}
-	defer resp.Body.Close()
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(resp.Body); err != nil {
-		return nil, err
+	topURL := i.ctx.ESITopURL
+	if topURL == "" {
+		topURL = i.ctx.Request.URL.RequestURI()
+	}
+
+	// Keep parent request states and restore them after the subrequest has finished
+	ctx, process, vars := i.ctx, i.process, i.vars
+	defer func() {
+		i.ctx, i.process, i.vars = ctx, process, vars
+	}()
+
+	i.Debugger.Message(fmt.Sprintf("ESI include %s (level %d)", req.URL.String(), level))
+	if err := i.ProcessInit(req); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	i.ctx.ESILevel.Value = level
+	i.ctx.ESITopURL = topURL
+	if err := i.ProcessRecv(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if i.ctx.Response == nil {
+		return nil, exception.Runtime(nil, "ESI include %s does not respond", src)
 	}
-	return buf.Bytes(), nil
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(i.ctx.Response.Body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if i.ctx.Response.StatusCode >= http.StatusBadRequest {
+		return body.Bytes(), exception.Runtime(nil, "ESI include %s responds status %d", src, i.ctx.Response.StatusCode)
+	}
+	return body.Bytes(), nil
 }
 
-func resolveIncludeURL(req *http.Request, path string) error {
-	switch {
-	case strings.HasPrefix(path, "http://"), strings.HasPrefix(path, "https://"):
-		parsed, err := url.Parse(path)
-		if err != nil {
-			return err
-		}
-		req.URL = parsed
-		req.Header.Set("Host", parsed.Host)
-		return nil
-	case strings.HasPrefix(path, "/"):
-		req.URL.Path = path
-		return nil
-	default:
-		req.URL.Path = filepath.Join(req.URL.Path, path)
-		return nil
+// createEsiRequest creates ESI subrequest from parent request.
+// Relative src is resolved against the parent request URL
+func createEsiRequest(parent *http.Request, src string) (*http.Request, error) {
+	ref, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	req := parent.Clone(parent.Context())
+	req.Method = http.MethodGet
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	req.URL = parent.URL.ResolveReference(ref)
+	req.RequestURI = req.URL.RequestURI()
+	if ref.Host != "" {
+		req.Host = ref.Host
+		req.Header.Set("Host", ref.Host)
 	}
+	return req, nil
 }
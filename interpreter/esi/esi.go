@@ -0,0 +1,194 @@
+// Package esi implements Edge Side Includes parser which Fastly supports.
+// Fastly supports <esi:include>, <esi:remove>, <esi:comment> and <!--esi ... --> blocks,
+// other ESI tags are left as it is.
+// see: https://developer.fastly.com/reference/vcl/statements/esi/
+package esi
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+type Node interface {
+	node()
+}
+
+// Text is a plain content that is output as it is
+type Text struct {
+	Value []byte
+}
+
+// Include is a <esi:include> tag
+type Include struct {
+	Src             string
+	Alt             string
+	ContinueOnError bool
+}
+
+func (n *Text) node()    {}
+func (n *Include) node() {}
+
+var (
+	esiTagStart        = []byte("<esi:")
+	esiCommentStart    = []byte("<!--esi")
+	esiCommentEnd      = []byte("-->")
+	esiIncludeTag      = []byte("<esi:include")
+	esiIncludeEnd      = []byte("</esi:include>")
+	esiRemoveTag       = []byte("<esi:remove>")
+	esiRemoveEnd       = []byte("</esi:remove>")
+	esiCommentTag      = []byte("<esi:comment")
+	esiAttributesRegex = regexp.MustCompile(`([a-zA-Z_-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// Parse parses ESI tags in the body and returns nodes
+func Parse(body []byte) ([]Node, error) {
+	var nodes []Node
+	var text []byte
+
+	flush := func() {
+		if len(text) > 0 {
+			nodes = append(nodes, &Text{Value: text})
+			text = nil
+		}
+	}
+
+	for len(body) > 0 {
+		index := nextTagIndex(body)
+		if index == -1 {
+			text = append(text, body...)
+			break
+		}
+		text = append(text, body[:index]...)
+		body = body[index:]
+
+		switch {
+		case bytes.HasPrefix(body, esiCommentStart):
+			// Contents in <!--esi ... --> are processed as ESI and the comment is removed
+			end := bytes.Index(body, esiCommentEnd)
+			if end == -1 {
+				return nil, errors.New("Syntax error: <!--esi is not closed with -->")
+			}
+			inner, err := Parse(body[len(esiCommentStart):end])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			for _, n := range inner {
+				if t, ok := n.(*Text); ok {
+					text = append(text, t.Value...)
+					continue
+				}
+				flush()
+				nodes = append(nodes, n)
+			}
+			body = body[end+len(esiCommentEnd):]
+		case bytes.HasPrefix(body, esiIncludeTag):
+			include, rest, err := parseInclude(body)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			flush()
+			nodes = append(nodes, include)
+			body = rest
+		case bytes.HasPrefix(body, esiRemoveTag):
+			// <esi:remove> is used for the client which does not support ESI, always removed
+			end := removeEndIndex(body)
+			if end == -1 {
+				return nil, errors.New("Syntax error: <esi:remove> is not closed with </esi:remove>")
+			}
+			body = body[end+len(esiRemoveEnd):]
+		case bytes.HasPrefix(body, esiCommentTag):
+			end := bytes.Index(body, []byte("/>"))
+			if end == -1 {
+				return nil, errors.New("Syntax error: <esi:comment> is not closed with />")
+			}
+			body = body[end+2:]
+		default:
+			// Unsupported ESI tag, output as it is
+			text = append(text, esiTagStart...)
+			body = body[len(esiTagStart):]
+		}
+	}
+	flush()
+
+	return nodes, nil
+}
+
+// removeEndIndex returns the index of </esi:remove> which closes <esi:remove> at the head of the body.
+// Nested <esi:remove> blocks are skipped to remove the whole outer block
+func removeEndIndex(body []byte) int {
+	var depth int
+	for i := 0; i < len(body); {
+		next := bytes.IndexByte(body[i:], '<')
+		if next == -1 {
+			break
+		}
+		i += next
+		switch {
+		case bytes.HasPrefix(body[i:], esiRemoveTag):
+			depth++
+			i += len(esiRemoveTag)
+		case bytes.HasPrefix(body[i:], esiRemoveEnd):
+			depth--
+			if depth == 0 {
+				return i
+			}
+			i += len(esiRemoveEnd)
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+func nextTagIndex(body []byte) int {
+	tag := bytes.Index(body, esiTagStart)
+	comment := bytes.Index(body, esiCommentStart)
+	switch {
+	case tag == -1:
+		return comment
+	case comment == -1:
+		return tag
+	default:
+		return min(tag, comment)
+	}
+}
+
+func parseInclude(body []byte) (*Include, []byte, error) {
+	end := bytes.IndexByte(body, '>')
+	if end == -1 {
+		return nil, nil, errors.New("Syntax error: <esi:include> is not closed")
+	}
+	tag := body[len(esiIncludeTag):end]
+	rest := body[end+1:]
+
+	// Non self-closing tag must be closed with </esi:include>
+	if !bytes.HasSuffix(tag, []byte("/")) {
+		index := bytes.Index(rest, esiIncludeEnd)
+		if index == -1 {
+			return nil, nil, errors.New("Syntax error: <esi:include> is not closed with </esi:include>")
+		}
+		rest = rest[index+len(esiIncludeEnd):]
+	}
+
+	include := &Include{}
+	for _, match := range esiAttributesRegex.FindAllSubmatch(tag, -1) {
+		val := string(match[2])
+		if len(match[3]) > 0 {
+			val = string(match[3])
+		}
+		switch string(match[1]) {
+		case "src":
+			include.Src = val
+		case "alt":
+			include.Alt = val
+		case "onerror":
+			include.ContinueOnError = val == "continue"
+		}
+	}
+	if include.Src == "" {
+		return nil, nil, errors.New("Syntax error: <esi:include> must have src attribute")
+	}
+	return include, rest, nil
+}
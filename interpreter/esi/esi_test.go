@@ -0,0 +1,95 @@
+package esi
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []Node
+		isErr  bool
+	}{
+		{
+			name:   "plain text",
+			input:  "<p>Hello</p>",
+			expect: []Node{&Text{Value: []byte("<p>Hello</p>")}},
+		},
+		{
+			name:  "include tags",
+			input: `A<esi:include src="/a" />B<esi:include src='/b' alt="/c" onerror="continue"></esi:include>C`,
+			expect: []Node{
+				&Text{Value: []byte("A")},
+				&Include{Src: "/a"},
+				&Text{Value: []byte("B")},
+				&Include{Src: "/b", Alt: "/c", ContinueOnError: true},
+				&Text{Value: []byte("C")},
+			},
+		},
+		{
+			name:  "remove and comment tags",
+			input: `A<esi:remove><a href="/a">a</a></esi:remove>B<esi:comment text="comment" />C`,
+			expect: []Node{
+				&Text{Value: []byte("ABC")},
+			},
+		},
+		{
+			name:  "nested remove tags",
+			input: `A<esi:remove>a<esi:remove>b</esi:remove>c</esi:remove>B`,
+			expect: []Node{
+				&Text{Value: []byte("AB")},
+			},
+		},
+		{
+			name:  "esi comment block",
+			input: `A<!--esi <p><esi:include src="/a"/></p> -->B`,
+			expect: []Node{
+				&Text{Value: []byte("A <p>")},
+				&Include{Src: "/a"},
+				&Text{Value: []byte("</p> B")},
+			},
+		},
+		{
+			name:  "unsupported tags are left",
+			input: `<esi:vars>$(HTTP_HOST)</esi:vars>`,
+			expect: []Node{
+				&Text{Value: []byte(`<esi:vars>$(HTTP_HOST)</esi:vars>`)},
+			},
+		},
+		{
+			name:  "remove tag is not closed",
+			input: `<esi:remove>A`,
+			isErr: true,
+		},
+		{
+			name:  "nested remove tag is not closed",
+			input: `<esi:remove>A<esi:remove>B</esi:remove>`,
+			isErr: true,
+		},
+		{
+			name:  "include tag without src",
+			input: `<esi:include alt="/a" />`,
+			isErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		nodes, err := Parse([]byte(tt.input))
+		if tt.isErr {
+			if err == nil {
+				t.Errorf("%s: expected error but got nil", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.expect, nodes); diff != "" {
+			t.Errorf("%s: nodes mismatch, diff=%s", tt.name, diff)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package interpreter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestESI(t *testing.T) {
+	fragments := map[string]string{
+		"/":         `<p><esi:include src="/a" /></p><esi:remove>removed</esi:remove>`,
+		"/a":        `A<!--esi <esi:include src="b" /> -->`,
+		"/b":        `B`,
+		"/alt":      `<esi:include src="/notfound" alt="/b" />`,
+		"/continue": `C<esi:include src="/notfound" onerror="continue" />`,
+		"/loop":     `L<esi:include src="/loop" onerror="continue" />`,
+		"/unknown":  `<esi:vars>$(HTTP_HOST)</esi:vars>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := fragments[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("Not Found"))
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  set req.http.X-ESI = req.esi_level ":" req.topurl;
+  if (req.url == "/level") {
+    error 900;
+  }
+  return(lookup);
+}
+
+sub vcl_fetch {
+  esi;
+}
+
+sub vcl_error {
+  if (obj.status == 900) {
+    set obj.status = 200;
+    synthetic req.http.X-ESI;
+    return(deliver);
+  }
+}
+`
+	fragments["/synthetic"] = `<esi:include src="/level" />`
+
+	tests := []struct {
+		path   string
+		expect string
+	}{
+		{path: "/", expect: "<p>A B </p>"},
+		{path: "/alt", expect: "B"},
+		{path: "/continue", expect: "C"},
+		{path: "/loop", expect: "LLLLLL"},
+		{path: "/unknown", expect: "<esi:vars>$(HTTP_HOST)</esi:vars>"},
+		{path: "/synthetic", expect: "1:/synthetic"},
+	}
+
+	for _, tt := range tests {
+		ip := New(context.WithResolver(
+			resolver.NewStaticResolver("main", vcl),
+		))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		ip.serve(rec, req)
+		if ip.process.Error != nil {
+			t.Errorf("%s: unexpected error: %s", tt.path, ip.process.Error)
+			continue
+		}
+		body, _ := io.ReadAll(ip.ctx.Response.Body)
+		if string(body) != tt.expect {
+			t.Errorf("%s: body expects %s, got=%s", tt.path, tt.expect, string(body))
+		}
+	}
+}
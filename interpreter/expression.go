@@ -256,7 +256,7 @@ func (i *Interpreter) ProcessFunctionCallExpression(exp *ast.FunctionCallExpress
 		}
 		return v, nil
 	}
-	fn, err := function.Exists(i.ctx.Scope, exp.Function.Value)
+	fn, err := function.Lookup(i.functions, i.ctx.Scope, exp.Function.Value)
 	if err != nil {
 		return value.Null, errors.WithStack(err)
 	}
@@ -37,7 +37,7 @@ func Fastly_try_select_shield(ctx *context.Context, args ...value.Value) (value.
 	}
 
 	shield := value.Unwrap[*value.Backend](args[0])
-	fallback := value.Unwrap[*value.Backend](args[0])
+	fallback := value.Unwrap[*value.Backend](args[1])
 
 	// If first argument is not a shield director, return fallback
 	if shield.Director == nil {
@@ -46,7 +46,12 @@ func Fastly_try_select_shield(ctx *context.Context, args ...value.Value) (value.
 	if !strings.EqualFold(shield.Director.Type, "shield") {
 		return fallback, nil
 	}
-
-	// Note that our interpreter could not consider about director/backend is healthy.
+	// On the shield POP, the request must go to the fallback backend (typically origin)
+	if ctx.IsShieldNode {
+		return fallback, nil
+	}
+	if !shield.IsHealthy() {
+		return fallback, nil
+	}
 	return shield, nil
 }
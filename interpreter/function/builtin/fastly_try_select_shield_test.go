@@ -4,8 +4,9 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of fastly.try_select_shield
@@ -13,5 +14,33 @@ import (
 // - BACKEND, BACKEND
 // Reference: https://www.fastly.com/documentation/reference/vcl/functions/miscellaneous/fastly-try-select-shield/
 func Test_Fastly_try_select_shield(t *testing.T) {
-	t.Skip("Test Builtin function fastly.try_select_shield should be impelemented")
+	shield := &value.Backend{
+		Director: &value.DirectorConfig{Type: "shield", Name: "ssl_shield_falco"},
+	}
+	random := &value.Backend{
+		Director: &value.DirectorConfig{Type: "random", Name: "random_director"},
+	}
+	fallback := &value.Backend{}
+
+	tests := []struct {
+		name   string
+		ctx    *context.Context
+		shield *value.Backend
+		expect *value.Backend
+	}{
+		{name: "shield director is selected on the edge node", ctx: &context.Context{}, shield: shield, expect: shield},
+		{name: "fallback is selected on the shield node", ctx: &context.Context{IsShieldNode: true}, shield: shield, expect: fallback},
+		{name: "fallback is selected if not a shield director", ctx: &context.Context{}, shield: random, expect: fallback},
+	}
+
+	for _, tt := range tests {
+		ret, err := Fastly_try_select_shield(tt.ctx, tt.shield, fallback)
+		if err != nil {
+			t.Errorf("[%s] Unexpected error: %s", tt.name, err)
+			continue
+		}
+		if ret != tt.expect {
+			t.Errorf("[%s] Unexpected backend is selected", tt.name)
+		}
+	}
 }
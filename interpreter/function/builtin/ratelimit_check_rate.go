@@ -3,6 +3,8 @@
 package builtin
 
 import (
+	"time"
+
 	"github.com/ysugimoto/falco/interpreter/context"
 	"github.com/ysugimoto/falco/interpreter/function/errors"
 	"github.com/ysugimoto/falco/interpreter/value"
@@ -34,6 +36,48 @@ func Ratelimit_check_rate(ctx *context.Context, args ...value.Value) (value.Valu
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
-	return &value.Boolean{Value: false}, nil
+	entry := value.Unwrap[*value.String](args[0]).Value
+	rc := value.Unwrap[*value.Ident](args[1]).Value
+	delta := value.Unwrap[*value.Integer](args[2]).Value
+	window := value.Unwrap[*value.Integer](args[3]).Value
+	limit := value.Unwrap[*value.Integer](args[4]).Value
+	pb := value.Unwrap[*value.Ident](args[5]).Value
+	ttl := value.Unwrap[*value.RTime](args[6]).Value
+
+	penaltybox, ok := ctx.Penaltyboxes[pb]
+	if !ok {
+		return &value.Boolean{}, errors.New(Ratelimit_check_rate_Name, "penaltybox %s does not exist", pb)
+	}
+	exceeded, err := checkRate(ctx, Ratelimit_check_rate_Name, entry, rc, delta, window, limit)
+	if err != nil {
+		return &value.Boolean{}, err
+	}
+	if penaltybox.Has(entry, ctx.Now()) {
+		return &value.Boolean{Value: true}, nil
+	}
+	if exceeded {
+		penaltybox.Add(entry, ttl, ctx.Now())
+	}
+	return &value.Boolean{Value: exceeded}, nil
+}
+
+// Increment the ratecounter for the entry and report whether the rate in the window exceeds the limit
+func checkRate(ctx *context.Context, name, entry, rc string, delta, window, limit int64) (bool, error) {
+	ratecounter, ok := ctx.Ratecounters[rc]
+	if !ok {
+		return false, errors.New(name, "ratecounter %s does not exist", rc)
+	}
+	// Fastly only accepts 1, 10 or 60 seconds window
+	switch window {
+	case 1, 10, 60:
+	default:
+		return false, errors.New(name, "window must be one of 1, 10 or 60, got %d", window)
+	}
+
+	now := ctx.Now()
+	ratecounter.Increment(entry, delta, now)
+	ctx.RatecounterEntries[rc] = entry
+
+	rate := ratecounter.Rate(entry, time.Duration(window)*time.Second, now)
+	return rate > float64(limit), nil
 }
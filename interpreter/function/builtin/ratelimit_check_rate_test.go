@@ -4,8 +4,11 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.check_rate
@@ -13,5 +16,63 @@ import (
 // - STRING, ID, INTEGER, INTEGER, INTEGER, ID, RTIME
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-check-rate/
 func Test_Ratelimit_check_rate(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.check_rate should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := &context.Context{
+		FixedTime:          &now,
+		Ratecounters:       map[string]*ratelimit.Ratecounter{"rc": ratelimit.NewRatecounter("rc")},
+		Penaltyboxes:       map[string]*ratelimit.Penaltybox{"pb": ratelimit.NewPenaltybox("pb")},
+		RatecounterEntries: map[string]string{},
+	}
+	check := func() bool {
+		ret, err := Ratelimit_check_rate(
+			ctx,
+			&value.String{Value: "192.0.2.1"},
+			&value.Ident{Value: "rc"},
+			&value.Integer{Value: 1},
+			&value.Integer{Value: 1},
+			&value.Integer{Value: 3},
+			&value.Ident{Value: "pb"},
+			&value.RTime{Value: 2 * time.Minute},
+		)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+		return value.Unwrap[*value.Boolean](ret).Value
+	}
+
+	for i := 0; i < 3; i++ {
+		if check() {
+			t.Errorf("Rate limit should not be exceeded on %d request", i+1)
+		}
+	}
+	if !check() {
+		t.Errorf("Rate limit should be exceeded on 4th request")
+	}
+	if ctx.RatecounterEntries["rc"] != "192.0.2.1" {
+		t.Errorf("Most recently incremented entry should be recorded")
+	}
+
+	// Entry stays in penaltybox even if rate is decreased
+	now = now.Add(time.Minute)
+	if !check() {
+		t.Errorf("Entry should be in penaltybox")
+	}
+	now = now.Add(time.Minute)
+	if check() {
+		t.Errorf("Entry should be released from penaltybox")
+	}
+
+	_, err := Ratelimit_check_rate(
+		ctx,
+		&value.String{Value: "192.0.2.1"},
+		&value.Ident{Value: "rc"},
+		&value.Integer{Value: 1},
+		&value.Integer{Value: 5},
+		&value.Integer{Value: 3},
+		&value.Ident{Value: "pb"},
+		&value.RTime{Value: 2 * time.Minute},
+	)
+	if err == nil {
+		t.Errorf("Expected error for invalid window")
+	}
 }
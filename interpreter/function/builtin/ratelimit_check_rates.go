@@ -34,6 +34,37 @@ func Ratelimit_check_rates(ctx *context.Context, args ...value.Value) (value.Val
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
-	return &value.Boolean{Value: false}, nil
+	entry := value.Unwrap[*value.String](args[0]).Value
+	pb := value.Unwrap[*value.Ident](args[9]).Value
+	ttl := value.Unwrap[*value.RTime](args[10]).Value
+
+	penaltybox, ok := ctx.Penaltyboxes[pb]
+	if !ok {
+		return &value.Boolean{}, errors.New(Ratelimit_check_rates_Name, "penaltybox %s does not exist", pb)
+	}
+
+	// Both ratecounters are incremented, rate limit is exceeded when either of rates exceeds its limit
+	var exceeded bool
+	for _, offset := range []int{1, 5} {
+		v, err := checkRate(
+			ctx,
+			Ratelimit_check_rates_Name,
+			entry,
+			value.Unwrap[*value.Ident](args[offset]).Value,
+			value.Unwrap[*value.Integer](args[offset+1]).Value,
+			value.Unwrap[*value.Integer](args[offset+2]).Value,
+			value.Unwrap[*value.Integer](args[offset+3]).Value,
+		)
+		if err != nil {
+			return &value.Boolean{}, err
+		}
+		exceeded = exceeded || v
+	}
+	if penaltybox.Has(entry, ctx.Now()) {
+		return &value.Boolean{Value: true}, nil
+	}
+	if exceeded {
+		penaltybox.Add(entry, ttl, ctx.Now())
+	}
+	return &value.Boolean{Value: exceeded}, nil
 }
@@ -4,8 +4,11 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.check_rates
@@ -13,5 +16,49 @@ import (
 // - STRING, ID, INTEGER, INTEGER, INTEGER, ID, INTEGER, INTEGER, INTEGER, ID, RTIME
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-check-rates/
 func Test_Ratelimit_check_rates(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.check_rates should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := &context.Context{
+		FixedTime: &now,
+		Ratecounters: map[string]*ratelimit.Ratecounter{
+			"rc1": ratelimit.NewRatecounter("rc1"),
+			"rc2": ratelimit.NewRatecounter("rc2"),
+		},
+		Penaltyboxes:       map[string]*ratelimit.Penaltybox{"pb": ratelimit.NewPenaltybox("pb")},
+		RatecounterEntries: map[string]string{},
+	}
+
+	check := func() bool {
+		ret, err := Ratelimit_check_rates(
+			ctx,
+			&value.String{Value: "192.0.2.1"},
+			&value.Ident{Value: "rc1"},
+			&value.Integer{Value: 1},
+			&value.Integer{Value: 1},
+			&value.Integer{Value: 100},
+			&value.Ident{Value: "rc2"},
+			&value.Integer{Value: 10},
+			&value.Integer{Value: 10},
+			&value.Integer{Value: 2},
+			&value.Ident{Value: "pb"},
+			&value.RTime{Value: time.Minute},
+		)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+		return value.Unwrap[*value.Boolean](ret).Value
+	}
+
+	// Second ratecounter counts 10 per request over 10 seconds window: 1.0, 2.0, 3.0 rps
+	if check() {
+		t.Errorf("Rate limit should not be exceeded on first request")
+	}
+	if check() {
+		t.Errorf("Rate limit should not be exceeded on second request")
+	}
+	if !check() {
+		t.Errorf("Rate limit should be exceeded on third request")
+	}
+	if !ctx.Penaltyboxes["pb"].Has("192.0.2.1", now) {
+		t.Errorf("Entry should be added to penaltybox")
+	}
 }
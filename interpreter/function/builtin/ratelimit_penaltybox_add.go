@@ -34,6 +34,14 @@ func Ratelimit_penaltybox_add(ctx *context.Context, args ...value.Value) (value.
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
+	pb := value.Unwrap[*value.Ident](args[0]).Value
+	entry := value.Unwrap[*value.String](args[1]).Value
+	ttl := value.Unwrap[*value.RTime](args[2]).Value
+
+	penaltybox, ok := ctx.Penaltyboxes[pb]
+	if !ok {
+		return value.Null, errors.New(Ratelimit_penaltybox_add_Name, "penaltybox %s does not exist", pb)
+	}
+	penaltybox.Add(entry, ttl, ctx.Now())
 	return value.Null, nil
 }
@@ -4,8 +4,11 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.penaltybox_add
@@ -13,5 +16,35 @@ import (
 // - ID, STRING, RTIME
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-penaltybox-add/
 func Test_Ratelimit_penaltybox_add(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.penaltybox_add should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := &context.Context{
+		FixedTime:    &now,
+		Penaltyboxes: map[string]*ratelimit.Penaltybox{"pb": ratelimit.NewPenaltybox("pb")},
+	}
+	_, err := Ratelimit_penaltybox_add(
+		ctx,
+		&value.Ident{Value: "pb"},
+		&value.String{Value: "192.0.2.1"},
+		&value.RTime{Value: 30 * time.Second},
+	)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	// TTL is at least one minute
+	if !ctx.Penaltyboxes["pb"].Has("192.0.2.1", now.Add(59*time.Second)) {
+		t.Errorf("Entry should be in penaltybox")
+	}
+	if ctx.Penaltyboxes["pb"].Has("192.0.2.1", now.Add(time.Minute)) {
+		t.Errorf("Entry should be expired")
+	}
+
+	_, err = Ratelimit_penaltybox_add(
+		ctx,
+		&value.Ident{Value: "undefined"},
+		&value.String{Value: "192.0.2.1"},
+		&value.RTime{Value: time.Minute},
+	)
+	if err == nil {
+		t.Errorf("Expected error for undefined penaltybox")
+	}
 }
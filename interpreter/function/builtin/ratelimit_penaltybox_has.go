@@ -34,6 +34,12 @@ func Ratelimit_penaltybox_has(ctx *context.Context, args ...value.Value) (value.
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
-	return &value.Boolean{Value: false}, nil
+	pb := value.Unwrap[*value.Ident](args[0]).Value
+	entry := value.Unwrap[*value.String](args[1]).Value
+
+	penaltybox, ok := ctx.Penaltyboxes[pb]
+	if !ok {
+		return &value.Boolean{}, errors.New(Ratelimit_penaltybox_has_Name, "penaltybox %s does not exist", pb)
+	}
+	return &value.Boolean{Value: penaltybox.Has(entry, ctx.Now())}, nil
 }
@@ -4,8 +4,11 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.penaltybox_has
@@ -13,5 +16,32 @@ import (
 // - ID, STRING
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-penaltybox-has/
 func Test_Ratelimit_penaltybox_has(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.penaltybox_has should be impelemented")
+	now := time.Unix(1700000000, 0)
+	pb := ratelimit.NewPenaltybox("pb")
+	pb.Add("192.0.2.1", time.Minute, now)
+	ctx := &context.Context{
+		FixedTime:    &now,
+		Penaltyboxes: map[string]*ratelimit.Penaltybox{"pb": pb},
+	}
+
+	tests := []struct {
+		entry  string
+		expect bool
+	}{
+		{entry: "192.0.2.1", expect: true},
+		{entry: "192.0.2.2", expect: false},
+	}
+	for _, tt := range tests {
+		ret, err := Ratelimit_penaltybox_has(
+			ctx,
+			&value.Ident{Value: "pb"},
+			&value.String{Value: tt.entry},
+		)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+		if v := value.Unwrap[*value.Boolean](ret).Value; v != tt.expect {
+			t.Errorf("Return value unmatch for %s, expect=%t, got=%t", tt.entry, tt.expect, v)
+		}
+	}
 }
@@ -34,6 +34,17 @@ func Ratelimit_ratecounter_increment(ctx *context.Context, args ...value.Value)
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
+	rc := value.Unwrap[*value.Ident](args[0]).Value
+	entry := value.Unwrap[*value.String](args[1]).Value
+	delta := value.Unwrap[*value.Integer](args[2]).Value
+
+	ratecounter, ok := ctx.Ratecounters[rc]
+	if !ok {
+		return &value.Integer{}, errors.New(Ratelimit_ratecounter_increment_Name, "ratecounter %s does not exist", rc)
+	}
+	ratecounter.Increment(entry, delta, ctx.Now())
+	ctx.RatecounterEntries[rc] = entry
+
+	// Fastly always returns 0
 	return &value.Integer{Value: 0}, nil
 }
@@ -4,8 +4,11 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.ratecounter_increment
@@ -13,5 +16,28 @@ import (
 // - ID, STRING, INTEGER
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-ratecounter-increment/
 func Test_Ratelimit_ratecounter_increment(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.ratecounter_increment should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := &context.Context{
+		FixedTime:          &now,
+		Ratecounters:       map[string]*ratelimit.Ratecounter{"rc": ratelimit.NewRatecounter("rc")},
+		Penaltyboxes:       map[string]*ratelimit.Penaltybox{"pb": ratelimit.NewPenaltybox("pb")},
+		RatecounterEntries: map[string]string{},
+	}
+	for i := 0; i < 3; i++ {
+		_, err := Ratelimit_ratecounter_increment(
+			ctx,
+			&value.Ident{Value: "rc"},
+			&value.String{Value: "192.0.2.1"},
+			&value.Integer{Value: 2},
+		)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	}
+	if c := ctx.Ratecounters["rc"].Count("192.0.2.1", 10*time.Second, now); c != 6 {
+		t.Errorf("Counter value expects 6, got=%d", c)
+	}
+	if ctx.RatecounterEntries["rc"] != "192.0.2.1" {
+		t.Errorf("Most recently incremented entry should be recorded")
+	}
 }
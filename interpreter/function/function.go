@@ -16,7 +16,17 @@ type Function struct {
 }
 
 func Exists(scope context.Scope, name string) (*Function, error) {
-	fn, ok := builtinFunctions[name]
+	return Lookup(nil, scope, name)
+}
+
+// Lookup finds the function from provided functions first, and then builtin functions.
+// Provided functions are used for injecting functions per interpreter instance
+// because builtin functions are shared globally.
+func Lookup(fns map[string]*Function, scope context.Scope, name string) (*Function, error) {
+	fn, ok := fns[name]
+	if !ok {
+		fn, ok = builtinFunctions[name]
+	}
 	if !ok {
 		return nil, errors.WithStack(
 			fmt.Errorf("Function %s is not defined", name),
@@ -0,0 +1,174 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CSV database must have a header row. "network" column is required and accepts CIDR or single IP address,
+// other columns are named as variable names, for example:
+//
+//	network,client.geo.country_code,client.geo.city,client.as.number,client.as.name
+//	192.0.2.0/24,JP,Tokyo,64496,Example
+//
+// Unknown columns are ignored and the most specific network is used when networks overlap.
+type csvEntry struct {
+	network *net.IPNet
+	record  *Record
+}
+
+type csvSource struct {
+	entries []csvEntry
+}
+
+func openCSV(file string) (*csvSource, error) {
+	fp, err := os.Open(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer fp.Close()
+
+	reader := csv.NewReader(fp)
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.Errorf("CSV GeoIP database %s is empty", file)
+	}
+
+	header := rows[0]
+	networkIndex := -1
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if header[i] == "network" {
+			networkIndex = i
+		}
+	}
+	if networkIndex == -1 {
+		return nil, errors.Errorf("CSV GeoIP database %s must have network column", file)
+	}
+
+	src := &csvSource{}
+	for line, row := range rows[1:] {
+		network, err := parseNetwork(row[networkIndex])
+		if err != nil {
+			return nil, errors.Errorf("%s:%d: %s", file, line+2, err)
+		}
+		record := &Record{}
+		for i, name := range header {
+			if i == networkIndex || i >= len(row) || row[i] == "" {
+				continue
+			}
+			if err := setRecordField(record, name, row[i]); err != nil {
+				return nil, errors.Errorf("%s:%d: %s", file, line+2, err)
+			}
+		}
+		src.entries = append(src.entries, csvEntry{network: network, record: record})
+	}
+	return src, nil
+}
+
+func parseNetwork(v string) (*net.IPNet, error) {
+	v = strings.TrimSpace(v)
+	if !strings.Contains(v, "/") {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil, errors.Errorf("Invalid network %s", v)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(v)
+	if err != nil {
+		return nil, errors.Errorf("Invalid network %s", v)
+	}
+	return network, nil
+}
+
+func setRecordField(r *Record, name, v string) error {
+	parseFloat := func() (*float64, error) {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.Errorf("Invalid float value %s for %s", v, name)
+		}
+		return &f, nil
+	}
+	parseInt := func() (*int64, error) {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("Invalid integer value %s for %s", v, name)
+		}
+		return &i, nil
+	}
+
+	var err error
+	switch name {
+	case "client.geo.city":
+		r.City = v
+	case "client.geo.conn_speed":
+		r.ConnSpeed = v
+	case "client.geo.conn_type":
+		r.ConnType = v
+	case "client.geo.continent_code":
+		r.ContinentCode = v
+	case "client.geo.country_code":
+		r.CountryCode = v
+	case "client.geo.country_code3":
+		r.CountryCode3 = v
+	case "client.geo.country_name":
+		r.CountryName = v
+	case "client.geo.postal_code":
+		r.PostalCode = v
+	case "client.geo.proxy_description":
+		r.ProxyDescription = v
+	case "client.geo.proxy_type":
+		r.ProxyType = v
+	case "client.geo.region":
+		r.Region = v
+	case "client.geo.latitude":
+		r.Latitude, err = parseFloat()
+	case "client.geo.longitude":
+		r.Longitude, err = parseFloat()
+	case "client.geo.area_code":
+		r.AreaCode, err = parseInt()
+	case "client.geo.metro_code":
+		r.MetroCode, err = parseInt()
+	case "client.geo.utc_offset":
+		r.UTCOffset, err = parseInt()
+	case "client.as.number":
+		r.ASNumber, err = parseInt()
+	case "client.as.name":
+		r.ASName = v
+	}
+	return err
+}
+
+func (s *csvSource) lookup(ip net.IP) (*Record, error) {
+	var found *csvEntry
+	var foundBits int
+	for i := range s.entries {
+		e := &s.entries[i]
+		if !e.network.Contains(ip) {
+			continue
+		}
+		if ones, _ := e.network.Mask.Size(); found == nil || ones > foundBits {
+			found, foundBits = e, ones
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	// Return copy because record may be merged with other database records
+	record := *found.record
+	return &record, nil
+}
@@ -0,0 +1,131 @@
+// Package geoip resolves client.geo.* and client.as.* variables from local GeoIP databases.
+// MaxMind DB format (.mmdb) and CSV format (.csv) are supported.
+package geoip
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Record represents geolocation and autonomous system information for an IP address.
+// Empty fields mean unknown and the interpreter returns its default value
+type Record struct {
+	City             string
+	ConnSpeed        string
+	ConnType         string
+	ContinentCode    string
+	CountryCode      string
+	CountryCode3     string
+	CountryName      string
+	PostalCode       string
+	ProxyDescription string
+	ProxyType        string
+	Region           string
+	Latitude         *float64
+	Longitude        *float64
+	AreaCode         *int64
+	MetroCode        *int64
+	UTCOffset        *int64
+	ASNumber         *int64
+	ASName           string
+}
+
+// merge fills empty fields from other record
+func (r *Record) merge(other *Record) {
+	mergeString := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	mergeString(&r.City, other.City)
+	mergeString(&r.ConnSpeed, other.ConnSpeed)
+	mergeString(&r.ConnType, other.ConnType)
+	mergeString(&r.ContinentCode, other.ContinentCode)
+	mergeString(&r.CountryCode, other.CountryCode)
+	mergeString(&r.CountryCode3, other.CountryCode3)
+	mergeString(&r.CountryName, other.CountryName)
+	mergeString(&r.PostalCode, other.PostalCode)
+	mergeString(&r.ProxyDescription, other.ProxyDescription)
+	mergeString(&r.ProxyType, other.ProxyType)
+	mergeString(&r.Region, other.Region)
+	mergeString(&r.ASName, other.ASName)
+	if r.Latitude == nil {
+		r.Latitude = other.Latitude
+	}
+	if r.Longitude == nil {
+		r.Longitude = other.Longitude
+	}
+	if r.AreaCode == nil {
+		r.AreaCode = other.AreaCode
+	}
+	if r.MetroCode == nil {
+		r.MetroCode = other.MetroCode
+	}
+	if r.UTCOffset == nil {
+		r.UTCOffset = other.UTCOffset
+	}
+	if r.ASNumber == nil {
+		r.ASNumber = other.ASNumber
+	}
+}
+
+type source interface {
+	lookup(ip net.IP) (*Record, error)
+}
+
+// Database looks up records from multiple database files.
+// For example, MaxMind City and ASN databases could be combined
+type Database struct {
+	sources []source
+}
+
+// Open opens database files, the format is determined by file extension
+func Open(files ...string) (*Database, error) {
+	db := &Database{}
+	for _, file := range files {
+		var src source
+		var err error
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".mmdb":
+			src, err = openMMDB(file)
+		case ".csv":
+			src, err = openCSV(file)
+		default:
+			return nil, errors.Errorf("Unsupported GeoIP database format: %s", file)
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		db.sources = append(db.sources, src)
+	}
+	return db, nil
+}
+
+// Lookup finds the record for the IP address. Returns nil if not found in any databases
+func (d *Database) Lookup(ip net.IP) (*Record, error) {
+	if d == nil || ip == nil {
+		return nil, nil
+	}
+	var record *Record
+	for _, src := range d.sources {
+		r, err := src.lookup(ip)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if r == nil {
+			continue
+		}
+		if record == nil {
+			record = r
+			continue
+		}
+		record.merge(r)
+	}
+	if record != nil && record.CountryCode3 == "" {
+		record.CountryCode3 = countryCode3[record.CountryCode]
+	}
+	return record, nil
+}
@@ -0,0 +1,133 @@
+package geoip
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestDatabaseLookup(t *testing.T) {
+	db, err := Open("testdata/geoip.csv", "testdata/asn.csv")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	tests := []struct {
+		ip     string
+		expect *Record
+	}{
+		{
+			ip: "192.0.2.1",
+			expect: &Record{
+				CountryCode:  "JP",
+				CountryCode3: "JPN",
+				CountryName:  "Japan",
+				City:         "Tokyo",
+				Latitude:     ptr(35.6895),
+				UTCOffset:    ptr(int64(900)),
+				ASNumber:     ptr(int64(64496)),
+				ASName:       "Example AS",
+			},
+		},
+		{
+			ip: "192.0.2.200",
+			expect: &Record{
+				CountryCode:  "US",
+				CountryCode3: "USA",
+				CountryName:  "United States",
+				City:         "San Francisco",
+				Latitude:     ptr(37.7749),
+				UTCOffset:    ptr(int64(-800)),
+				ASNumber:     ptr(int64(64496)),
+				ASName:       "Example AS",
+			},
+		},
+		{
+			ip: "2001:db8::1",
+			expect: &Record{
+				CountryCode:  "DE",
+				CountryCode3: "DEU",
+				CountryName:  "Germany",
+				City:         "Berlin",
+				Latitude:     ptr(52.52),
+				UTCOffset:    ptr(int64(100)),
+			},
+		},
+		{
+			ip:     "198.51.100.1",
+			expect: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		record, err := db.Lookup(net.ParseIP(tt.ip))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.ip, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.expect, record); diff != "" {
+			t.Errorf("%s: record mismatch, diff=%s", tt.ip, diff)
+		}
+	}
+}
+
+func TestOpenUnsupportedFormat(t *testing.T) {
+	if _, err := Open("testdata/geoip.json"); err == nil {
+		t.Errorf("Expected error for unsupported format")
+	}
+}
+
+func TestMMDBRecord(t *testing.T) {
+	m := &mmdbRecord{}
+	m.City.Names = map[string]string{"en": "Tokyo"}
+	m.Continent.Code = "AS"
+	m.Country.IsoCode = "JP"
+	m.Country.Names = map[string]string{"en": "Japan"}
+	m.Location.Latitude = ptr(35.6895)
+	m.Location.MetroCode = ptr(uint(0))
+	m.Subdivisions = append(m.Subdivisions, struct {
+		IsoCode string `maxminddb:"iso_code"`
+	}{IsoCode: "13"})
+	m.ASNumber = ptr(uint(64496))
+	m.IsHostingProvider = true
+
+	expect := &Record{
+		City:          "Tokyo",
+		ContinentCode: "AS",
+		CountryCode:   "JP",
+		CountryName:   "Japan",
+		Region:        "13",
+		Latitude:      ptr(35.6895),
+		MetroCode:     ptr(int64(0)),
+		ASNumber:      ptr(int64(64496)),
+		ProxyType:     "hosting",
+	}
+	if diff := cmp.Diff(expect, m.toRecord()); diff != "" {
+		t.Errorf("Record mismatch, diff=%s", diff)
+	}
+}
+
+func TestUTCOffset(t *testing.T) {
+	tests := []struct {
+		offset int
+		expect int64
+	}{
+		{offset: 9 * 3600, expect: 900},
+		{offset: -8 * 3600, expect: -800},
+		{offset: 5*3600 + 30*60, expect: 530},
+		{offset: -(3*3600 + 30*60), expect: -330},
+	}
+	for _, tt := range tests {
+		tm := time.Date(2024, 1, 1, 0, 0, 0, 0, time.FixedZone("", tt.offset))
+		if v := utcOffset(tm); v != tt.expect {
+			t.Errorf("utcOffset expects %d, got=%d", tt.expect, v)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"net"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/pkg/errors"
+)
+
+// mmdbRecord is a subset of MaxMind GeoIP2/GeoLite2 City, ASN and Anonymous IP database records
+type mmdbRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Continent struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"continent"`
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+		MetroCode *uint    `maxminddb:"metro_code"`
+		TimeZone  string   `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+	Subdivisions []struct {
+		IsoCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	ASNumber          *uint  `maxminddb:"autonomous_system_number"`
+	ASOrganization    string `maxminddb:"autonomous_system_organization"`
+	IsAnonymous       bool   `maxminddb:"is_anonymous"`
+	IsHostingProvider bool   `maxminddb:"is_hosting_provider"`
+	IsPublicProxy     bool   `maxminddb:"is_public_proxy"`
+}
+
+func (m *mmdbRecord) toRecord() *Record {
+	r := &Record{
+		City:          m.City.Names["en"],
+		ContinentCode: m.Continent.Code,
+		CountryCode:   m.Country.IsoCode,
+		CountryName:   m.Country.Names["en"],
+		PostalCode:    m.Postal.Code,
+		Latitude:      m.Location.Latitude,
+		Longitude:     m.Location.Longitude,
+		ASName:        m.ASOrganization,
+	}
+	if len(m.Subdivisions) > 0 {
+		r.Region = m.Subdivisions[0].IsoCode
+	}
+	if m.Location.MetroCode != nil {
+		v := int64(*m.Location.MetroCode)
+		r.MetroCode = &v
+	}
+	if m.Location.TimeZone != "" {
+		if loc, err := time.LoadLocation(m.Location.TimeZone); err == nil {
+			v := utcOffset(time.Now().In(loc))
+			r.UTCOffset = &v
+		}
+	}
+	if m.ASNumber != nil {
+		v := int64(*m.ASNumber)
+		r.ASNumber = &v
+	}
+	switch {
+	case m.IsPublicProxy:
+		r.ProxyType = "public"
+	case m.IsHostingProvider:
+		r.ProxyType = "hosting"
+	case m.IsAnonymous:
+		r.ProxyType = "anonymous"
+	}
+	return r
+}
+
+// utcOffset returns offset as Fastly format, hours and minutes like -800 for -08:00
+func utcOffset(t time.Time) int64 {
+	_, offset := t.Zone()
+	minutes := int64(offset / 60)
+	return minutes/60*100 + minutes%60
+}
+
+type mmdbSource struct {
+	reader *maxminddb.Reader
+}
+
+func openMMDB(file string) (*mmdbSource, error) {
+	reader, err := maxminddb.Open(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &mmdbSource{reader: reader}, nil
+}
+
+func (s *mmdbSource) lookup(ip net.IP) (*Record, error) {
+	var m mmdbRecord
+	_, ok, err := s.reader.LookupNetwork(ip, &m)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return m.toRecord(), nil
+}
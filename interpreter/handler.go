@@ -20,14 +20,46 @@ func (i *Interpreter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "loop detected", http.StatusServiceUnavailable)
 		return
 	}
-	i.lock.Lock()
-	defer i.lock.Unlock()
 
-	if err := i.ProcessInit(r); err != nil {
+	// Interactive debugger inspects the interpreter state while processing the request,
+	// so requests are processed one by one on this interpreter.
+	// Otherwise, each request is processed concurrently on the forked interpreter
+	if _, ok := i.Debugger.(DefaultDebugger); !ok {
+		i.lock.Lock()
+		defer i.lock.Unlock()
+		i.serve(w, r)
+		return
+	}
+	i.fork().serve(w, r)
+}
+
+func (i *Interpreter) serve(w http.ResponseWriter, r *http.Request) {
+	// Purge requests are handled before VCL processing
+	if i.handlePurge(w, r) {
+		return
+	}
+
+	if err := i.processRequest(r); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if i.ctx.IsActualResponse {
+		// If we need to respond actual response, send it
+		i.sendResponse(w)
+		return
+	}
+	// Otherwise, responds process flow JSON
+	i.sendProcessResponse(w)
+}
+
+// processRequest processes the request through VCL lifecycle.
+// Returns error only when the VCL could not be loaded, runtime errors are stored in the process
+func (i *Interpreter) processRequest(r *http.Request) error {
+	if err := i.ProcessInit(r); err != nil {
+		return err
+	}
+
 	handleError := func(err error) {
 		// If debug is true, print with stacktrace
 		i.process.Error = err
@@ -46,14 +78,7 @@ func (i *Interpreter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	i.process.Restarts = i.ctx.Restarts
 	i.process.Backend = i.ctx.Backend
-
-	if i.ctx.IsActualResponse {
-		// If we need to respond actual response, send it
-		i.sendResponse(w)
-		return
-	}
-	// Otherwise, responds process flow JSON
-	i.sendProcessResponse(w)
+	return nil
 }
 
 func (i *Interpreter) sendProcessResponse(w http.ResponseWriter) {
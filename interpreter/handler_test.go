@@ -0,0 +1,322 @@
+package interpreter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestConcurrentRequests(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow backend blocks until the other requests have been processed
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+ratecounter rc {}
+penaltybox pb {}
+
+sub vcl_recv {
+  if (ratelimit.check_rate("192.0.2.1", rc, 1, 10, 1000, pb, 1m)) {
+    error 429;
+  }
+  return(lookup);
+}
+`
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+		context.WithActualResponse(true),
+	)
+
+	send := func(path string) int {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	slow := make(chan int)
+	go func() {
+		slow <- send("/slow")
+	}()
+
+	// Other requests must not be blocked by the slow backend request
+	var wg sync.WaitGroup
+	codes := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- send("/fast")
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Requests are blocked by the slow backend request")
+	}
+	close(release)
+
+	if code := <-slow; code != http.StatusOK {
+		t.Errorf("Slow request expects status 200, got=%d", code)
+	}
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("Fast request expects status 200, got=%d", code)
+		}
+	}
+
+	// Cache is shared across the requests
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if v := rec.Header().Get("X-Cache"); v != "HIT" {
+		t.Errorf("X-Cache expects HIT, got=%s", v)
+	}
+}
+
+func TestRequestCollapsing(t *testing.T) {
+	tests := []struct {
+		name         string
+		recv         string
+		fetch        string
+		expectFetch  int32
+		expectXCache string
+	}{
+		{
+			name:         "concurrent misses are collapsed into one fetch",
+			expectFetch:  1,
+			expectXCache: "HIT",
+		},
+		{
+			name:         "req.hash_ignore_busy does not wait for the in-flight fetch",
+			recv:         "set req.hash_ignore_busy = true;",
+			expectFetch:  5,
+			expectXCache: "HIT",
+		},
+		{
+			name:         "uncacheable response creates hit-for-pass object",
+			fetch:        "set beresp.cacheable = false;",
+			expectFetch:  6,
+			expectXCache: "HITPASS",
+		},
+		{
+			name:         "return(pass) in vcl_fetch creates hit-for-pass object",
+			fetch:        "return(pass);",
+			expectFetch:  6,
+			expectXCache: "HITPASS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetches.Add(1)
+				// Keep the fetch in-flight while other requests arrive
+				time.Sleep(200 * time.Millisecond)
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK")) // nolint:errcheck
+			}))
+			defer server.Close()
+
+			parsed, err := url.Parse(server.URL)
+			if err != nil {
+				t.Errorf("Test server URL parsing error: %s", err)
+				return
+			}
+			vcl := defaultBackend(parsed) + fmt.Sprintf(`
+sub vcl_recv {
+  %s
+  return(lookup);
+}
+sub vcl_fetch {
+  %s
+}
+`, tt.recv, tt.fetch)
+			ip := New(
+				context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+				context.WithActualResponse(true),
+			)
+			send := func() *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				return rec
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if rec := send(); rec.Code != http.StatusOK {
+						t.Errorf("Expected status 200, got=%d", rec.Code)
+					}
+				}()
+			}
+			wg.Wait()
+
+			rec := send()
+			if v := rec.Header().Get("X-Cache"); v != tt.expectXCache {
+				t.Errorf("X-Cache expects %s, got=%s", tt.expectXCache, v)
+			}
+			if n := fetches.Load(); n != tt.expectFetch {
+				t.Errorf("Backend fetch count expects %d, got=%d", tt.expectFetch, n)
+			}
+		})
+	}
+}
+
+func TestHitForPassIsNotOverwritten(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// First response is not cacheable, following responses are cacheable
+		if fetches.Add(1) == 1 {
+			w.Header().Set("X-Uncacheable", "1")
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(lookup);
+}
+sub vcl_fetch {
+  if (beresp.http.X-Uncacheable) {
+    set beresp.cacheable = false;
+  }
+  return(deliver);
+}
+`
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+		context.WithActualResponse(true),
+	)
+
+	for n, expect := range []string{"MISS", "HITPASS", "HITPASS"} {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if v := rec.Header().Get("X-Cache"); v != expect {
+			t.Errorf("Request %d: X-Cache expects %s, got=%s", n+1, expect, v)
+		}
+	}
+	if n := fetches.Load(); n != 3 {
+		t.Errorf("Backend fetch count expects 3, got=%d", n)
+	}
+}
+
+// mutableResolver resolves main VCL which could be changed between requests
+type mutableResolver struct {
+	*resolver.StaticResolver
+	mu   sync.Mutex
+	data string
+}
+
+func (r *mutableResolver) set(data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = data
+}
+
+func (r *mutableResolver) MainVCL() (*resolver.VCL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &resolver.VCL{Name: "main", Data: r.data}, nil
+}
+
+func TestParseVCLOnce(t *testing.T) {
+	vcl := `
+table tbl STRING {
+  "key": "value",
+}
+sub vcl_recv {
+  set req.http.X-Recv = "a";
+}
+sub vcl_recv {
+  set req.http.X-Recv = req.http.X-Recv "%s";
+  error 200;
+}
+sub vcl_error {
+  set obj.http.X-Recv = req.http.X-Recv;
+  set obj.http.X-Table = table.lookup(tbl, "injected");
+  return(deliver);
+}
+`
+	rs := &mutableResolver{StaticResolver: resolver.NewStaticResolver("main", "")}
+	ip := New(
+		context.WithResolver(rs),
+		context.WithActualResponse(true),
+		context.WithInjectEdgeDictionaries(map[string]config.EdgeDictionary{
+			"tbl": {"injected": "yes"},
+		}),
+	)
+	send := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+		return rec
+	}
+
+	// Parse failure must not be kept
+	rs.set("sub vcl_recv {")
+	if rec := send(); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Invalid VCL expects status 500, got=%d", rec.Code)
+	}
+
+	rs.set(fmt.Sprintf(vcl, "b"))
+	var parsed []ast.Statement
+	for n := 0; n < 3; n++ {
+		rec := send()
+		// Concatenated subroutine must not be accumulated across the requests
+		if v := rec.Header().Get("X-Recv"); v != "ab" {
+			t.Errorf("X-Recv header expects ab, got=%s", v)
+		}
+		if v := rec.Header().Get("X-Table"); v != "yes" {
+			t.Errorf("X-Table header expects yes, got=%s", v)
+		}
+		if n > 0 && &ip.parsed.statements[0] != &parsed[0] {
+			t.Errorf("VCL should be parsed once while it is not changed")
+		}
+		parsed = ip.parsed.statements
+	}
+
+	// Changed VCL is parsed again
+	rs.set(fmt.Sprintf(vcl, "c"))
+	if v := send().Header().Get("X-Recv"); v != "ac" {
+		t.Errorf("X-Recv header expects ac after VCL is changed, got=%s", v)
+	}
+}
@@ -0,0 +1,158 @@
+package har
+
+import (
+	"encoding/base64"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR represents HTTP Archive format which records backend traffic.
+// Only the fields which are needed to replay the traffic are implemented.
+// see: http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log *Log `json:"log"`
+}
+
+type Log struct {
+	Version string   `json:"version"`
+	Creator *Creator `json:"creator"`
+	Entries []*Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         *Request  `json:"request"`
+	Response        *Response `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         *Timings  `json:"timings"`
+}
+
+type Request struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []*NameValue `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	QueryString []*NameValue `json:"queryString"`
+	PostData    *PostData    `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []*NameValue `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	Content     *Content     `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Body returns decoded response body
+func (c *Content) Body() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+func newEntry(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, started time.Time) *Entry {
+	elapsed := float64(time.Since(started).Microseconds()) / 1000
+
+	entry := &Entry{
+		StartedDateTime: started,
+		Time:            elapsed,
+		Request: &Request{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []*NameValue{},
+			Headers:     toNameValues(req.Header),
+			QueryString: []*NameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: &Response{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []*NameValue{},
+			Headers:     toNameValues(resp.Header),
+			Content:     newContent(resp.Header.Get("Content-Type"), respBody),
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		},
+		Timings: &Timings{Wait: elapsed},
+	}
+	for key, values := range req.URL.Query() {
+		for _, v := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, &NameValue{Name: key, Value: v})
+		}
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &PostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(reqBody),
+		}
+	}
+	return entry
+}
+
+// newContent creates response content, binary body is encoded as base64
+func newContent(mimeType string, body []byte) *Content {
+	c := &Content{
+		Size:     len(body),
+		MimeType: mimeType,
+	}
+	if utf8.Valid(body) {
+		c.Text = string(body)
+	} else {
+		c.Text = base64.StdEncoding.EncodeToString(body)
+		c.Encoding = "base64"
+	}
+	return c
+}
+
+func toNameValues(h http.Header) []*NameValue {
+	nv := []*NameValue{}
+	for key, values := range h {
+		for _, v := range values {
+			nv = append(nv, &NameValue{Name: key, Value: v})
+		}
+	}
+	return nv
+}
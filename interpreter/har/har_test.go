@@ -0,0 +1,79 @@
+package har
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backend.har")
+	r := NewRecorder(path, "test")
+
+	record := func(url string, status int, body []byte) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		resp := &http.Response{
+			StatusCode: status,
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+		}
+		r.Record(req, nil, resp, body, time.Now())
+	}
+	record("http://example.com/", 200, []byte("first"))
+	record("http://example.com/", 503, []byte("second"))
+	record("http://example.com/image.png", 200, []byte{0x89, 0x50, 0x4e, 0x47, 0xff})
+
+	// Recorded entries are buffered until flushed
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("HAR file should not be written before flush, err=%v", err)
+		return
+	}
+	if err := r.Flush(); err != nil {
+		t.Errorf("Unexpected flush error: %s", err)
+		return
+	}
+
+	rp, err := NewReplayer(path)
+	if err != nil {
+		t.Errorf("Unexpected replayer error: %s", err)
+		return
+	}
+
+	tests := []struct {
+		url    string
+		status int
+		body   []byte
+	}{
+		{url: "http://example.com/", status: 200, body: []byte("first")},
+		{url: "http://example.com/", status: 503, body: []byte("second")},
+		// The last recorded response is served repeatedly
+		{url: "http://example.com/", status: 503, body: []byte("second")},
+		{url: "http://example.com/image.png", status: 200, body: []byte{0x89, 0x50, 0x4e, 0x47, 0xff}},
+	}
+	for _, tt := range tests {
+		resp, err := rp.Replay(httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if err != nil {
+			t.Errorf("Unexpected replay error: %s", err)
+			continue
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("Status code expects %d, got=%d", tt.status, resp.StatusCode)
+		}
+		if v := resp.Header.Get("Content-Type"); v != "text/plain" {
+			t.Errorf("Content-Type expects text/plain, got=%s", v)
+		}
+		body, _ := io.ReadAll(resp.Body) // nolint:errcheck
+		if !bytes.Equal(body, tt.body) {
+			t.Errorf("Body expects %v, got=%v", tt.body, body)
+		}
+	}
+
+	if _, err := rp.Replay(httptest.NewRequest(http.MethodPost, "http://example.com/", nil)); err == nil {
+		t.Errorf("Expected error for not recorded request but not")
+	}
+}
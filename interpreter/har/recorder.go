@@ -0,0 +1,122 @@
+package har
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// flushInterval is the interval of writing recorded entries to the HAR file
+const flushInterval = time.Second
+
+// Recorder records backend requests and responses to HAR file.
+// Entries are buffered in memory and written to the file periodically by Run and on Flush,
+// so that recording does not block backend fetches by file I/O
+type Recorder struct {
+	mu      sync.Mutex // guards har and dirty
+	har     *HAR
+	dirty   bool
+	writeMu sync.Mutex // serializes file writing
+	path    string
+}
+
+func NewRecorder(path, version string) *Recorder {
+	return &Recorder{
+		path: path,
+		har: &HAR{
+			Log: &Log{
+				Version: "1.2",
+				Creator: &Creator{Name: "falco", Version: version},
+				Entries: []*Entry{},
+			},
+		},
+	}
+}
+
+// Record appends the backend request and response to the buffered entries.
+// Request and response bodies must be passed separately because they have already been consumed
+func (r *Recorder) Record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, started time.Time) {
+	entry := newEntry(req, reqBody, resp, respBody, started)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.har.Log.Entries = append(r.har.Log.Entries, entry)
+	r.dirty = true
+}
+
+// Run writes recorded entries to the HAR file periodically until the context is canceled.
+// Flush must be called on shutdown to write the entries which are recorded after the last tick
+func (r *Recorder) Run(ctx context.Context, onError func(err error)) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Flush(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Flush writes recorded entries to the HAR file if there are entries which are not written yet
+func (r *Recorder) Flush() error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	// Recorded entries are never modified, so the slice can be marshaled without the lock
+	h := &HAR{
+		Log: &Log{
+			Version: r.har.Log.Version,
+			Creator: r.har.Log.Creator,
+			Entries: r.har.Log.Entries,
+		},
+	}
+	r.dirty = false
+	r.mu.Unlock()
+
+	if err := r.save(h); err != nil {
+		// Retry on the next flush
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (r *Recorder) save(h *HAR) error {
+	buf, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Write to temporary file and rename it to avoid broken file on the process is killed
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return errors.WithStack(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmp.Name(), r.path))
+}
@@ -0,0 +1,106 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Replayer serves backend responses from the recorded HAR file without network.
+// Entries are matched by request method and URL. When the same request is recorded multiple times,
+// responses are served in the recorded order and the last one is served repeatedly
+type Replayer struct {
+	mu      sync.Mutex
+	entries map[string][]*Entry
+	served  map[string]int
+}
+
+func NewReplayer(path string) (*Replayer, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var h HAR
+	if err := json.Unmarshal(buf, &h); err != nil {
+		return nil, errors.WithStack(fmt.Errorf("Failed to parse HAR file %s: %w", path, err))
+	}
+	if h.Log == nil {
+		return nil, errors.WithStack(fmt.Errorf("HAR file %s does not have log field", path))
+	}
+
+	r := &Replayer{
+		entries: make(map[string][]*Entry),
+		served:  make(map[string]int),
+	}
+	for _, e := range h.Log.Entries {
+		if e.Request == nil || e.Response == nil {
+			continue
+		}
+		key := entryKey(e.Request.Method, e.Request.URL)
+		r.entries[key] = append(r.entries[key], e)
+	}
+	return r, nil
+}
+
+// Replay returns the recorded response for the request.
+// Returns error if the request is not recorded
+func (r *Replayer) Replay(req *http.Request) (*http.Response, error) {
+	key := entryKey(req.Method, req.URL.String())
+
+	r.mu.Lock()
+	entries, ok := r.entries[key]
+	if !ok {
+		r.mu.Unlock()
+		return nil, errors.WithStack(fmt.Errorf("Request %s %s is not recorded", req.Method, req.URL))
+	}
+	index := r.served[key]
+	if index < len(entries)-1 {
+		r.served[key]++
+	}
+	r.mu.Unlock()
+
+	res := entries[index].Response
+	var body []byte
+	if res.Content != nil {
+		b, err := res.Content.Body()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		body = b
+	}
+
+	header := http.Header{}
+	for _, h := range res.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	proto := res.HTTPVersion
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	major, minor, ok := http.ParseHTTPVersion(proto)
+	if !ok {
+		major, minor = 1, 1
+	}
+	return &http.Response{
+		StatusCode:    res.Status,
+		Status:        fmt.Sprintf("%d %s", res.Status, res.StatusText),
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func entryKey(method, url string) string {
+	return strings.ToUpper(method) + " " + url
+}
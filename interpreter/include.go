@@ -64,6 +64,8 @@ func (i *Interpreter) includeFile(include *ast.IncludeStatement, isRoot bool) ([
 	}
 
 	if isRoot {
+		// Root modules are resolved only on parsing VCL, record to detect changes
+		i.parsed.sources = append(i.parsed.sources, resolvedSource{include: include, data: module.Data})
 		return loadRootVCL(module.Name, module.Data)
 	}
 	return loadStatementVCL(module.Name, module.Data)
@@ -6,20 +6,22 @@ import (
 	"net/http"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/ysugimoto/falco/ast"
 	"github.com/ysugimoto/falco/interpreter/cache"
 	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	"github.com/ysugimoto/falco/interpreter/exception"
+	"github.com/ysugimoto/falco/interpreter/function"
 	"github.com/ysugimoto/falco/interpreter/limitations"
 	"github.com/ysugimoto/falco/interpreter/process"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"github.com/ysugimoto/falco/interpreter/variable"
 	"github.com/ysugimoto/falco/lexer"
 	"github.com/ysugimoto/falco/parser"
+	"github.com/ysugimoto/falco/resolver"
 )
 
 type Interpreter struct {
@@ -28,20 +30,34 @@ type Interpreter struct {
 	lock      sync.Mutex
 
 	options []context.Option
-
-	ctx           *context.Context
-	process       *process.Process
-	cache         *cache.Cache
+	// Root statements of VCL which are parsed once and shared with the forked interpreters
+	// until the VCL is changed
+	parsed *parsedVCL
+
+	ctx     *context.Context
+	process *process.Process
+	cache   *cache.Cache
+	shared  *sharedState
+	// Shield POP node in multi-node simulation, requests for shield director are processed on it
+	shieldNode    *Interpreter
 	Debugger      Debugger
 	IdentResolver func(v string) value.Value
 
+	// Functions which are available only in this interpreter instance, like testing functions
+	functions map[string]*function.Function
+
+	// Coverage collector, statement and branch executions are recorded when it is set
+	Coverage *coverage.Collector
+
 	TestingState State
 }
 
 func New(options ...context.Option) *Interpreter {
 	return &Interpreter{
 		options:      options,
+		parsed:       &parsedVCL{},
 		cache:        cache.New(),
+		shared:       newSharedState(),
 		localVars:    variable.LocalVariables{},
 		Debugger:     DefaultDebugger{},
 		TestingState: NONE,
@@ -49,6 +65,37 @@ func New(options ...context.Option) *Interpreter {
 	}
 }
 
+// fork creates per-request interpreter which shares cache, ratelimit and backend health with this interpreter.
+// Request context, variables and process are created on ProcessInit so the forked interpreter
+// could process the request concurrently with others
+func (i *Interpreter) fork() *Interpreter {
+	return &Interpreter{
+		options:       i.options,
+		parsed:        i.parsed,
+		cache:         i.cache,
+		shared:        i.shared,
+		shieldNode:    i.shieldNode,
+		localVars:     variable.LocalVariables{},
+		Debugger:      i.Debugger,
+		IdentResolver: i.IdentResolver,
+		functions:     i.functions,
+		Coverage:      i.Coverage,
+		TestingState:  NONE,
+		process:       process.New(),
+	}
+}
+
+// InjectFunctions adds functions which are available only in this interpreter instance.
+// Unlike function.Inject, injected functions do not affect other interpreters.
+func (i *Interpreter) InjectFunctions(fns map[string]*function.Function) {
+	if i.functions == nil {
+		i.functions = make(map[string]*function.Function)
+	}
+	for key, fn := range fns {
+		i.functions[key] = fn
+	}
+}
+
 func (i *Interpreter) SetScope(scope context.Scope) {
 	i.ctx.Scope = scope
 	switch scope {
@@ -74,12 +121,15 @@ func (i *Interpreter) SetScope(scope context.Scope) {
 }
 
 func (i *Interpreter) restart() error {
+	// Restarted request looks up the cache again so the in-flight fetch must be completed
+	i.releaseBusyObject(false)
 	i.ctx.Restarts++
 	i.Debugger.Message(fmt.Sprintf("Restarted (%d) time", i.ctx.Restarts))
 	i.ctx.BackendRequest = nil
 	i.ctx.BackendResponse = nil
 	i.ctx.Object = nil
 	i.ctx.Response = nil
+	i.ctx.IsPass = false
 
 	if err := i.ProcessRecv(); err != nil {
 		return err
@@ -87,66 +137,134 @@ func (i *Interpreter) restart() error {
 	return nil
 }
 
+// parsedVCL holds the result of parsing VCL. Parsed statements are read-only
+// because they are shared between the concurrent requests.
+// Resolved sources are kept in order to parse VCL again when they are changed
+type parsedVCL struct {
+	mu         sync.Mutex
+	statements []ast.Statement
+	sources    []resolvedSource
+}
+
+// resolvedSource is the content of main VCL or root included module at the time of parsing
+type resolvedSource struct {
+	include *ast.IncludeStatement // nil for main VCL
+	data    string
+}
+
 func (i *Interpreter) ProcessInit(r *http.Request) error {
 	ctx := context.New(i.options...)
+	ctx.RequestStartTime = time.Now()
+	i.ctx = ctx
+	i.ctx.Request = r
+	r.Header.Set("Host", r.Host)
+
+	// OriginalHost value may be overridden. If not empty, set the request value
+	if i.ctx.OriginalHost == "" {
+		i.ctx.OriginalHost = r.Host
+	}
 
-	main, err := ctx.Resolver.MainVCL()
+	i.process = process.New()
+	i.ctx.Scope = context.InitScope
+	i.vars = variable.NewAllScopeVariables(i.ctx)
+
+	// Forked interpreters process declarations from the shared parsed statements
+	statements, err := i.parsedStatements()
 	if err != nil {
 		i.Debugger.Message(err.Error())
 		return err
 	}
-	if err := limitations.CheckFastlyVCLLimitation(main.Data); err != nil {
-		i.Debugger.Message(err.Error())
+	if err := i.ProcessDeclarations(statements); err != nil {
 		return err
 	}
+	if err := limitations.CheckFastlyResourceLimit(i.ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parsedStatements returns the parsed root statements.
+// VCL is parsed again when main VCL or root included modules are changed so that VCL edits are applied
+// without restarting, and parse failure is not kept so that the next request parses VCL again
+func (i *Interpreter) parsedStatements() ([]ast.Statement, error) {
+	i.parsed.mu.Lock()
+	defer i.parsed.mu.Unlock()
+
+	if i.parsed.sources != nil && !i.sourcesChanged(i.parsed.sources) {
+		return i.parsed.statements, nil
+	}
+
+	// Resolved sources are recorded while parsing
+	i.parsed.sources = nil
+	statements, err := i.parseVCL()
+	if err != nil {
+		i.parsed.statements, i.parsed.sources = nil, nil
+		return nil, err
+	}
+	i.parsed.statements = statements
+	return statements, nil
+}
+
+// sourcesChanged returns true if some of resolved sources are changed or could not be resolved
+func (i *Interpreter) sourcesChanged(sources []resolvedSource) bool {
+	for _, s := range sources {
+		var v *resolver.VCL
+		var err error
+		if s.include == nil {
+			v, err = i.ctx.Resolver.MainVCL()
+		} else {
+			v, err = i.ctx.Resolver.Resolve(s.include)
+		}
+		if err != nil || v.Data != s.data {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVCL parses main VCL with remote snippets and resolves root include statements.
+// Caller must hold i.parsed.mu
+func (i *Interpreter) parseVCL() ([]ast.Statement, error) {
+	main, err := i.ctx.Resolver.MainVCL()
+	if err != nil {
+		return nil, err
+	}
+	i.parsed.sources = append(i.parsed.sources, resolvedSource{data: main.Data})
+	if err := limitations.CheckFastlyVCLLimitation(main.Data); err != nil {
+		return nil, err
+	}
 	vcl, err := parser.New(
 		lexer.NewFromString(main.Data, lexer.WithFile(main.Name)),
 	).ParseVCL()
 	if err != nil {
 		// parse error
-		i.Debugger.Message(err.Error())
-		return err
+		return nil, err
 	}
 
 	// If remote snippets exists, prepare parse and prepend to main VCL
-	if ctx.FastlySnippets != nil {
-		for _, snip := range ctx.FastlySnippets.EmbedSnippets() {
+	if i.ctx.FastlySnippets != nil {
+		for _, snip := range i.ctx.FastlySnippets.EmbedSnippets() {
 			s, err := parser.New(
 				lexer.NewFromString(snip.Data, lexer.WithFile(snip.Name)),
 			).ParseVCL()
 			if err != nil {
 				// parse error
-				i.Debugger.Message(err.Error())
-				return err
+				return nil, err
 			}
 			vcl.Statements = append(s.Statements, vcl.Statements...)
 		}
 	}
-	ctx.RequestStartTime = time.Now()
-	i.ctx = ctx
-	i.ctx.Request = r
-	r.Header.Set("Host", r.Host)
-
-	// OriginalHost value may be overridden. If not empty, set the request value
-	if i.ctx.OriginalHost == "" {
-		i.ctx.OriginalHost = r.Host
-	}
-
-	i.process = process.New()
-	i.ctx.Scope = context.InitScope
-	i.vars = variable.NewAllScopeVariables(i.ctx)
 
 	statements, err := i.resolveIncludeStatement(vcl.Statements, true)
 	if err != nil {
-		return err
-	}
-	if err := i.ProcessDeclarations(statements); err != nil {
-		return err
+		return nil, err
 	}
-	if err := limitations.CheckFastlyResourceLimit(i.ctx); err != nil {
-		return err
+	if i.Coverage != nil {
+		if err := i.registerCoverage(statements); err != nil {
+			return nil, err
+		}
 	}
-	return nil
+	return statements, nil
 }
 
 func (i *Interpreter) ProcessDeclarations(statements []ast.Statement) error {
@@ -181,18 +299,21 @@ func (i *Interpreter) ProcessDeclarations(statements []ast.Statement) error {
 			if _, ok := i.ctx.Tables[t.Name.Value]; ok {
 				return exception.Runtime(&t.Token, "Table %s is duplicated", t.Name.Value)
 			}
-			i.ctx.Tables[t.Name.Value] = t
-
 			// Set items if injected edge dictionaries exists
 			if inject, ok := i.ctx.InjectEdgeDictionaries[t.Name.Value]; ok {
 				// Edge Dictionary value type must be STRING
 				if t.ValueType.Value != "STRING" {
 					return exception.System("EdgeDictionary injection error: %s value type is not STRING", t.Name.Value)
 				}
-				if err := i.InjectEdgeDictionaryItem(t, inject); err != nil {
+				// Inject items to the copied table because parsed declaration is shared between requests
+				table := *t
+				table.Properties = append([]*ast.TableProperty{}, t.Properties...)
+				if err := i.InjectEdgeDictionaryItem(&table, inject); err != nil {
 					return errors.WithStack(err)
 				}
+				t = &table
 			}
+			i.ctx.Tables[t.Name.Value] = t
 		case *ast.SubroutineDeclaration:
 			i.Debugger.Run(stmt)
 			if t.ReturnType != nil {
@@ -210,8 +331,14 @@ func (i *Interpreter) ProcessDeclarations(statements []ast.Statement) error {
 
 			// Duplicated fastly reserved subroutines should be concatenated
 			// ref: https://developer.fastly.com/reference/vcl/subroutines/#concatenation
+			// Parsed declaration is shared between requests so concatenate on the copied subroutine
 			if _, ok := context.FastlyReservedSubroutine[t.Name.Value]; ok {
-				exists.Block.Statements = append(exists.Block.Statements, t.Block.Statements...)
+				sub := *exists
+				sub.Block = &ast.BlockStatement{
+					Meta:       exists.Block.Meta,
+					Statements: append(append([]ast.Statement{}, exists.Block.Statements...), t.Block.Statements...),
+				}
+				i.ctx.Subroutines[t.Name.Value] = &sub
 				continue
 			}
 			// Other custom user subroutine could not be duplicated
@@ -221,18 +348,37 @@ func (i *Interpreter) ProcessDeclarations(statements []ast.Statement) error {
 			if _, ok := i.ctx.Penaltyboxes[t.Name.Value]; ok {
 				return exception.Runtime(&t.Token, "Penaltybox %s is duplicated", t.Name.Value)
 			}
-			i.ctx.Penaltyboxes[t.Name.Value] = t
+			// Penaltybox entries must be kept across the requests
+			i.ctx.Penaltyboxes[t.Name.Value] = i.shared.penaltybox(t.Name.Value)
 		case *ast.RatecounterDeclaration:
 			i.Debugger.Run(stmt)
 			if _, ok := i.ctx.Ratecounters[t.Name.Value]; ok {
 				return exception.Runtime(&t.Token, "Ratecounter %s is duplicated", t.Name.Value)
 			}
-			i.ctx.Ratecounters[t.Name.Value] = t
+			// Ratecounter entries must be kept across the requests
+			i.ctx.Ratecounters[t.Name.Value] = i.shared.ratecounter(t.Name.Value)
 		}
 	}
 	return nil
 }
 
+// Register subroutines in main VCL as coverage targets.
+// This must be called before processing declarations because reserved subroutines are concatenated on it.
+func (i *Interpreter) registerCoverage(statements []ast.Statement) error {
+	for _, stmt := range statements {
+		sub, ok := stmt.(*ast.SubroutineDeclaration)
+		if !ok {
+			continue
+		}
+		resolved, err := i.resolveIncludeStatement(sub.Block.Statements, false)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		i.Coverage.RegisterSubroutine(sub, resolved)
+	}
+	return nil
+}
+
 func (i *Interpreter) ProcessBackends(statements []ast.Statement) error {
 	for _, stmt := range statements {
 		t, ok := stmt.(*ast.BackendDeclaration)
@@ -240,8 +386,14 @@ func (i *Interpreter) ProcessBackends(statements []ast.Statement) error {
 			continue
 		}
 		i.Debugger.Run(stmt)
-		h := &atomic.Bool{}
-		h.Store(true)
+		// Backend health must be kept across the requests
+		h := i.shared.health(t.Name.Value)
+		// Backend could be marked as unhealthy explicitly by config
+		if ob, err := getOverrideBackend(i.ctx, t.Name.Value); err != nil {
+			return errors.WithStack(err)
+		} else if ob != nil && ob.Unhealthy {
+			h.Store(false)
+		}
 		// Determine default backend
 		if i.ctx.Backend == nil {
 			i.ctx.Backend = &value.Backend{Value: t, Literal: true, Healthy: h}
@@ -291,17 +443,36 @@ func (i *Interpreter) ProcessRecv() error {
 		if err = i.ProcessHash(); err != nil {
 			return errors.WithStack(err)
 		}
-		if v := i.cache.Get(i.ctx.RequestHash.Value); v != nil {
+		v, pass := i.lookupCollapsedCache()
+		if pass {
+			// Hit-for-pass object exists, or the in-flight fetch which this request waited for was not cacheable
+			i.ctx.State = "PASS"
+			if v != nil {
+				i.ctx.State = "HITPASS"
+			}
+			i.Debugger.Message(fmt.Sprintf("Move state: %s -> PASS", i.ctx.Scope))
+			err = i.ProcessPass()
+		} else if v != nil {
+			v.Hit()
 			i.process.Cached = true
 			i.ctx.State = "HIT"
+			if i.ctx.ObjectIsStale.Value {
+				i.ctx.State = "HIT-STALE"
+			}
 			i.ctx.CacheHitItem = v
-			i.ctx.Object = i.cloneResponse(v.Response)
+			i.ctx.Object = i.cloneCachedResponse(v)
+			i.ctx.TriggerESI = v.DoESI
+			i.ctx.ObjectGrace = &value.RTime{Value: v.StaleIfError}
+			i.ctx.ObjectStaleWhileRevalidate = &value.RTime{Value: v.StaleWhileRevalidate}
 			i.Debugger.Message(fmt.Sprintf("Move state: %s -> HIT", i.ctx.Scope))
 			err = i.ProcessHit()
 		} else {
 			i.ctx.State = "MISS"
 			i.Debugger.Message(fmt.Sprintf("Move state: %s -> MISS", i.ctx.Scope))
 			err = i.ProcessMiss()
+			// Wake up waiting requests even if this request did not fetch from backend by return(pass), error, etc.
+			// Then one of them will fetch instead
+			i.releaseBusyObject(false)
 		}
 	default:
 		return exception.Runtime(
@@ -319,6 +490,99 @@ func (i *Interpreter) ProcessRecv() error {
 	return nil
 }
 
+// lookupCache finds the cache item for the request hash.
+// Stale item is treated as HIT while it is in stale-while-revalidate window,
+// and is kept as stale content while it is in stale-if-error window
+// so that it could be delivered by return(deliver_stale)
+func (i *Interpreter) lookupCache() *cache.CacheItem {
+	// req.hash_always_miss forces cache miss even if the object exists
+	if i.ctx.HashAlwaysMiss.Value {
+		return nil
+	}
+	item := i.cache.Get(i.ctx.RequestHash.Value)
+	if item == nil {
+		return nil
+	}
+	now := time.Now()
+	if !item.IsStale(now) {
+		return item
+	}
+	if item.CanServeOnError(now, i.ctx.MaxStaleIfError.Value) {
+		i.ctx.StaleItem = item
+		i.ctx.StaleExists = &value.Boolean{Value: true}
+	}
+	// Note: Fastly revalidates the object in background but falco does not.
+	// The object will be fetched again after stale-while-revalidate window has passed
+	if item.CanRevalidate(now, i.ctx.MaxStaleWhileRevalidate.Value) {
+		i.ctx.Stale = &value.Boolean{Value: true}
+		i.ctx.StaleIsRevalidating = &value.Boolean{Value: true}
+		i.ctx.ObjectIsStale = &value.Boolean{Value: true}
+		return item
+	}
+	return nil
+}
+
+// lookupCollapsedCache finds the cache item with request collapsing.
+// When other request is fetching for the same hash, this request is put on the waiting list
+// and looks up the cache again after the fetch is completed.
+// Returns true as the second value when the request should go to pass by hit-for-pass object or pass-on-miss
+func (i *Interpreter) lookupCollapsedCache() (*cache.CacheItem, bool) {
+	hash := i.ctx.RequestHash.Value
+	for {
+		if item := i.lookupCache(); item != nil {
+			return item, item.HitForPass
+		}
+		// req.hash_ignore_busy makes the request fetch from backend without waiting for the in-flight fetch
+		if i.ctx.HashIgnoreBusy.Value {
+			return nil, false
+		}
+
+		busy, owner := i.cache.Acquire(hash)
+		if owner {
+			// The object may be stored just before acquiring
+			if item := i.lookupCache(); item != nil {
+				i.cache.Release(hash, busy, false)
+				return item, item.HitForPass
+			}
+			i.ctx.CacheBusyObject = busy
+			return nil, false
+		}
+
+		i.Debugger.Message("Waiting for the in-flight fetch of the same hash")
+		if pass := busy.Wait(); pass {
+			return nil, true
+		}
+	}
+}
+
+// releaseBusyObject completes the in-flight fetch which this request is responsible for
+func (i *Interpreter) releaseBusyObject(pass bool) {
+	if i.ctx.CacheBusyObject == nil {
+		return
+	}
+	i.cache.Release(i.ctx.RequestHash.Value, i.ctx.CacheBusyObject, pass)
+	i.ctx.CacheBusyObject = nil
+}
+
+// deliverStale delivers the stale content instead of the backend response or error object
+func (i *Interpreter) deliverStale(isError bool) error {
+	item := i.ctx.StaleItem
+	item.Hit()
+	i.process.Cached = true
+	i.ctx.State = "HIT-STALE"
+	i.ctx.CacheHitItem = item
+	i.ctx.Object = i.cloneCachedResponse(item)
+	i.ctx.TriggerESI = item.DoESI
+	i.ctx.Stale = &value.Boolean{Value: true}
+	i.ctx.StaleIsError = &value.Boolean{Value: isError}
+	i.ctx.ObjectIsStale = &value.Boolean{Value: true}
+	i.ctx.ObjectGrace = &value.RTime{Value: item.StaleIfError}
+	i.ctx.ObjectStaleWhileRevalidate = &value.RTime{Value: item.StaleWhileRevalidate}
+
+	i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER (stale)", i.ctx.Scope))
+	return i.ProcessDeliver()
+}
+
 func (i *Interpreter) ProcessHash() error {
 	i.SetScope(context.HashScope)
 
@@ -378,6 +642,10 @@ func (i *Interpreter) ProcessMiss() error {
 
 	switch state {
 	case DELIVER_STALE:
+		if i.ctx.StaleItem != nil {
+			err = i.deliverStale(false)
+			break
+		}
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
 		err = i.ProcessDeliver()
 	case PASS:
@@ -455,6 +723,7 @@ func (i *Interpreter) ProcessHit() error {
 
 func (i *Interpreter) ProcessPass() error {
 	i.SetScope(context.PassScope)
+	i.ctx.IsPass = true
 
 	if i.ctx.Backend == nil {
 		return exception.Runtime(nil, "No backend determined in PASS")
@@ -517,7 +786,16 @@ func (i *Interpreter) ProcessFetch() error {
 	var err error
 	i.ctx.BackendResponse, err = i.sendBackendRequest(i.ctx.Backend)
 	if err != nil {
-		return errors.WithStack(err)
+		// When stale content exists, the backend failure is handled in vcl_error
+		// so that the stale content could be delivered by return(deliver_stale)
+		if i.ctx.StaleItem == nil {
+			return errors.WithStack(err)
+		}
+		i.Debugger.Message(fmt.Sprintf("Backend fetch failed: %s", err))
+		i.ctx.ObjectStatus = &value.Integer{Value: http.StatusServiceUnavailable}
+		i.ctx.ObjectResponse = &value.String{Value: "backend read error"}
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> ERROR", i.ctx.Scope))
+		return i.ProcessError()
 	}
 
 	// Mark request process has ended
@@ -531,24 +809,12 @@ func (i *Interpreter) ProcessFetch() error {
 			Value: i.determineCacheTTL(i.ctx.BackendResponse),
 		}
 	}
-	// TODO: consider stale-white-revalidate and stale-if-error TTL
-
-	// Update cache
-	defer func() {
-		resp := i.cloneResponse(i.ctx.BackendResponse)
-		// Note: compare BackendResponseCacheable value
-		// because this value will be changed by user in vcl_fetch directive
-		if i.ctx.BackendResponseCacheable.Value {
-			if i.ctx.BackendResponseTTL.Value.Seconds() > 0 {
-				now := time.Now()
-				i.cache.Set(i.ctx.RequestHash.String(), &cache.CacheItem{
-					Response:  resp,
-					Expires:   now.Add(i.ctx.BackendResponseTTL.Value),
-					EntryTime: now,
-				})
-			}
-		}
-	}()
+	i.ctx.BackendResponseStaleWhileRevalidate = &value.RTime{
+		Value: i.determineStaleTTL(i.ctx.BackendResponse, "stale-while-revalidate"),
+	}
+	i.ctx.BackendResponseStaleIfError = &value.RTime{
+		Value: i.determineStaleTTL(i.ctx.BackendResponse, "stale-if-error"),
+	}
 
 	// Simulate Fastly statement lifecycle
 	// see: https://developer.fastly.com/learning/vcl/using/#the-vcl-request-lifecycle
@@ -563,9 +829,26 @@ func (i *Interpreter) ProcessFetch() error {
 			state = DELIVER
 		}
 	}
+	// beresp.do_esi is an alternative way to enable ESI processing
+	if i.ctx.BackendResponseDoESI.Value {
+		i.ctx.TriggerESI = true
+	}
+
+	// Fastly never stores the response which is fetched in pass mode, neither hit-for-pass object.
+	// Stale content is delivered instead of the backend response so it must not be cached
+	if !i.ctx.IsPass && (state != DELIVER_STALE || i.ctx.StaleItem == nil) {
+		i.storeCache(state)
+	}
 
 	switch state {
-	case DELIVER, DELIVER_STALE, PASS:
+	case DELIVER_STALE:
+		if i.ctx.StaleItem != nil {
+			err = i.deliverStale(true)
+			break
+		}
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
+		err = i.ProcessDeliver()
+	case DELIVER, PASS:
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
 		err = i.ProcessDeliver()
 	case ERROR:
@@ -587,6 +870,39 @@ func (i *Interpreter) ProcessFetch() error {
 	return nil
 }
 
+// storeCache stores the backend response to the cache and wakes up the requests which wait for this fetch.
+// When the response is not cacheable by beresp.cacheable = false or return(pass) in vcl_fetch,
+// hit-for-pass object is stored instead so that following requests go to pass without waiting
+func (i *Interpreter) storeCache(state State) {
+	// Note: compare BackendResponseCacheable value
+	// because this value will be changed by user in vcl_fetch directive
+	cacheable := i.ctx.BackendResponseCacheable.Value && state != PASS
+	defer i.releaseBusyObject(!cacheable)
+
+	ttl := i.ctx.BackendResponseTTL.Value
+	if ttl.Seconds() <= 0 {
+		return
+	}
+	now := time.Now()
+	if !cacheable {
+		i.cache.Set(i.ctx.RequestHash.String(), &cache.CacheItem{
+			Expires:    now.Add(ttl),
+			EntryTime:  now,
+			HitForPass: true,
+		})
+		return
+	}
+	i.cache.Set(i.ctx.RequestHash.String(), &cache.CacheItem{
+		Response:             i.cloneResponse(i.ctx.BackendResponse),
+		Expires:              now.Add(ttl),
+		EntryTime:            now,
+		StaleWhileRevalidate: i.ctx.BackendResponseStaleWhileRevalidate.Value,
+		// beresp.grace is treated as an alias of beresp.stale_if_error
+		StaleIfError: max(i.ctx.BackendResponseStaleIfError.Value, i.ctx.BackendResponseGrace.Value),
+		DoESI:        i.ctx.TriggerESI,
+	})
+}
+
 func (i *Interpreter) ProcessError() error {
 	i.SetScope(context.ErrorScope)
 
@@ -622,6 +938,13 @@ func (i *Interpreter) ProcessError() error {
 	}
 
 	switch state {
+	case DELIVER_STALE:
+		if i.ctx.StaleItem != nil {
+			err = i.deliverStale(true)
+			break
+		}
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
+		err = i.ProcessDeliver()
 	case DELIVER:
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
 		err = i.ProcessDeliver()
@@ -677,15 +1000,20 @@ func (i *Interpreter) ProcessDeliver() error {
 		}
 
 		// Add Fastly related server info but values are falco's one
-		i.ctx.Response.Header.Set("X-Served-By", cache.LocalDatacenterString)
-		i.ctx.Response.Header.Set("X-Cache", i.ctx.State)
+		servedBy := cache.LocalDatacenterString
+		if i.ctx.IsShieldNode {
+			servedBy = variable.FALCO_SHIELD_SERVER_HOSTNAME + "-" + variable.FALCO_SHIELD_DATACENTER
+		}
+		i.setServerInfoHeader("X-Served-By", servedBy)
+		i.setServerInfoHeader("X-Cache", i.ctx.State)
 
 		// Additionally set cache related headers
 		if i.ctx.CacheHitItem != nil {
-			i.ctx.Response.Header.Set("X-Cache-Hits", fmt.Sprint(i.ctx.CacheHitItem.Hits))
+			hits, _ := i.ctx.CacheHitItem.HitStats()
+			i.setServerInfoHeader("X-Cache-Hits", fmt.Sprint(hits))
 			i.ctx.Response.Header.Set("Age", fmt.Sprintf("%.0f", time.Since(i.ctx.CacheHitItem.EntryTime).Seconds()))
 		} else {
-			i.ctx.Response.Header.Set("X-Cache-Hits", "0")
+			i.setServerInfoHeader("X-Cache-Hits", "0")
 		}
 		// When Fastly-Debug header is present, add debug header but values are fakes
 		if i.ctx.Request.Header.Get("Fastly-Debug") != "" {
@@ -694,7 +1022,7 @@ func (i *Interpreter) ProcessDeliver() error {
 				fmt.Sprintf("(D %s 0) (F %s 0)", cache.LocalDatacenterString, cache.LocalDatacenterString),
 			)
 			cacheHit := "M"
-			if i.ctx.State == "HIT" {
+			if strings.HasPrefix(i.ctx.State, "HIT") && i.ctx.State != "HITPASS" {
 				cacheHit = "H"
 			}
 			i.ctx.Response.Header.Set(
@@ -745,24 +1073,14 @@ func (i *Interpreter) ProcessLog() error {
 var expiresValueLayout = "Mon, 02 Jan 2006 15:04:05 MST"
 
 func (i *Interpreter) determineCacheTTL(resp *http.Response) time.Duration {
-	if v := resp.Header.Get("Surrogate-Control"); v != "" {
-		if strings.HasPrefix(v, "max-age=") {
-			if dur, err := time.ParseDuration(strings.TrimPrefix(v, "max-age=") + "s"); err == nil {
-				return dur
-			}
-		}
+	if v, ok := lookupCacheDirective(resp, "Surrogate-Control", "max-age"); ok {
+		return v
 	}
-	if v := resp.Header.Get("Cache-Control"); v != "" {
-		if strings.HasPrefix(v, "s-maxage=") {
-			if dur, err := time.ParseDuration(strings.TrimPrefix(v, "s-maxage=") + "s"); err == nil {
-				return dur
-			}
-		}
-		if strings.HasPrefix(v, "max-age=") {
-			if dur, err := time.ParseDuration(strings.TrimPrefix(v, "max-age=") + "s"); err == nil {
-				return dur
-			}
-		}
+	if v, ok := lookupCacheDirective(resp, "Cache-Control", "s-maxage"); ok {
+		return v
+	}
+	if v, ok := lookupCacheDirective(resp, "Cache-Control", "max-age"); ok {
+		return v
 	}
 	if v := resp.Header.Get("Expires"); v != "" {
 		if d, err := time.Parse(expiresValueLayout, v); err == nil {
@@ -771,3 +1089,41 @@ func (i *Interpreter) determineCacheTTL(resp *http.Response) time.Duration {
 	}
 	return time.Duration(2 * time.Minute)
 }
+
+// Stale directives are respected in order of Surrogate-Control, Cache-Control
+// see: https://developer.fastly.com/learning/concepts/stale/
+func (i *Interpreter) determineStaleTTL(resp *http.Response, directive string) time.Duration {
+	for _, header := range []string{"Surrogate-Control", "Cache-Control"} {
+		if v, ok := lookupCacheDirective(resp, header, directive); ok {
+			return v
+		}
+	}
+	return 0
+}
+
+func lookupCacheDirective(resp *http.Response, header, directive string) (time.Duration, bool) {
+	v := resp.Header.Get(header)
+	if v == "" {
+		return 0, false
+	}
+	seconds, ok := cache.ParseCacheControl(v)[directive]
+	if !ok {
+		return 0, false
+	}
+	dur, err := time.ParseDuration(seconds + "s")
+	if err != nil {
+		return 0, false
+	}
+	return dur, true
+}
+
+// setServerInfoHeader sets Fastly server info header to the response.
+// In multi-node simulation, the value is appended to the shield node's one like Fastly does
+func (i *Interpreter) setServerInfoHeader(key, val string) {
+	if i.shieldNode != nil {
+		if v := i.ctx.Response.Header.Get(key); v != "" {
+			val = v + ", " + val
+		}
+	}
+	i.ctx.Response.Header.Set(key, val)
+}
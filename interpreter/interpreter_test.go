@@ -2,7 +2,9 @@ package interpreter
 
 import (
 	"fmt"
+	"io"
 	"testing"
+	"time"
 
 	"net/http"
 	"net/http/httptest"
@@ -47,7 +49,7 @@ func assertInterpreter(t *testing.T, vcl string, scope context.Scope, assertions
 	))
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
-	ip.ServeHTTP(rec, req)
+	ip.serve(rec, req)
 
 	if rec.Result().StatusCode != 200 {
 		if !isError {
@@ -179,3 +181,155 @@ func TestProcessBackends(t *testing.T) {
 		}
 	})
 }
+
+func TestRateLimitAcrossRequests(t *testing.T) {
+	vcl := `
+ratecounter rc {}
+penaltybox pb {}
+
+sub vcl_recv {
+  if (ratelimit.check_rate("192.0.2.1", rc, 1, 10, 1, pb, 1m)) {
+    error 429;
+  }
+  error 200;
+}
+`
+	ip := New(context.WithResolver(
+		resolver.NewStaticResolver("main", vcl),
+	))
+
+	var limited int
+	for i := 0; i < 12; i++ {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		ip.serve(rec, req)
+		if ip.process.Error != nil {
+			t.Errorf("Unexpected error: %s", ip.process.Error)
+			return
+		}
+		if ip.ctx.Response.StatusCode == http.StatusTooManyRequests {
+			limited++
+		}
+		if v, err := ip.vars.Get(context.LogScope, "ratecounter.rc.bucket.10s"); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		} else if c := value.Unwrap[*value.Integer](v).Value; c != int64(i+1) {
+			t.Errorf("ratecounter.rc.bucket.10s expects %d, got=%d", i+1, c)
+		}
+	}
+	// Rate exceeds 1 rps in 10 seconds window from 11th request
+	if limited != 2 {
+		t.Errorf("Expected 2 rate limited requests, got=%d", limited)
+	}
+}
+
+func TestServeStale(t *testing.T) {
+	var status int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=60, stale-while-revalidate=30, stale-if-error=300")
+		w.WriteHeader(status)
+		w.Write([]byte(fmt.Sprint(status)))
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(lookup);
+}
+
+sub vcl_fetch {
+  if (beresp.status >= 500 && stale.exists) {
+    return(deliver_stale);
+  }
+}
+
+sub vcl_error {
+  if (stale.exists) {
+    return(deliver_stale);
+  }
+}
+
+sub vcl_deliver {
+  set resp.http.X-Stale = if(resp.stale, "1", "0");
+  set resp.http.X-Stale-Is-Error = if(resp.stale.is_error, "1", "0");
+}
+`
+	ip := New(context.WithResolver(
+		resolver.NewStaticResolver("main", vcl),
+	))
+	expire := func(d time.Duration) {
+		if item := ip.cache.Get(ip.ctx.RequestHash.Value); item != nil {
+			item.Expires = time.Now().Add(-d)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		setup      func()
+		body       string
+		xCache     string
+		stale      string
+		staleError string
+	}{
+		{
+			name:   "first request is cached",
+			setup:  func() { status = http.StatusOK },
+			body:   "200",
+			xCache: "MISS",
+			stale:  "0", staleError: "0",
+		},
+		{
+			name:   "stale content is served in stale-while-revalidate window",
+			setup:  func() { expire(10 * time.Second) },
+			body:   "200",
+			xCache: "HIT-STALE",
+			stale:  "1", staleError: "0",
+		},
+		{
+			name: "stale content is served for backend error response",
+			setup: func() {
+				status = http.StatusInternalServerError
+				expire(60 * time.Second)
+			},
+			body:   "200",
+			xCache: "HIT-STALE",
+			stale:  "1", staleError: "1",
+		},
+		{
+			name:   "stale content is served for backend failure",
+			setup:  func() { server.Close() },
+			body:   "200",
+			xCache: "HIT-STALE",
+			stale:  "1", staleError: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setup()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		ip.serve(rec, req)
+		if ip.process.Error != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, ip.process.Error)
+			continue
+		}
+		resp := ip.ctx.Response
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != tt.body {
+			t.Errorf("%s: body expects %s, got=%s", tt.name, tt.body, string(body))
+		}
+		if v := resp.Header.Get("X-Cache"); v != tt.xCache {
+			t.Errorf("%s: X-Cache expects %s, got=%s", tt.name, tt.xCache, v)
+		}
+		if v := resp.Header.Get("X-Stale"); v != tt.stale {
+			t.Errorf("%s: X-Stale expects %s, got=%s", tt.name, tt.stale, v)
+		}
+		if v := resp.Header.Get("X-Stale-Is-Error"); v != tt.staleError {
+			t.Errorf("%s: X-Stale-Is-Error expects %s, got=%s", tt.name, tt.staleError, v)
+		}
+	}
+}
@@ -37,6 +37,7 @@ const (
 	MaxCustomVCLFileSize = 1 * MB
 	MaxVarnishRestarts   = 3
 	MaxLogLineSize       = 16 * KB
+	MaxEsiDepth          = 5
 
 	// Increasable limitations by contacting Fastly support
 	// These are defaults, you can override by configuration
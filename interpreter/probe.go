@@ -0,0 +1,345 @@
+package interpreter
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter/exception"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+// Default values of backend health check probe
+// see: https://developer.fastly.com/reference/vcl/declarations/backend/#health-checks
+const (
+	defaultProbeExpectedResponse = 200
+	defaultProbeInterval         = 5 * time.Second
+	defaultProbeTimeout          = 2 * time.Second
+	defaultProbeWindow           = 5
+	defaultProbeThreshold        = 3
+)
+
+// probe periodically sends health check request to the backend
+// and updates the backend health which is shared across the requests
+type probe struct {
+	backend  string
+	health   *atomic.Bool
+	request  *http.Request
+	client   *http.Client
+	expected int
+	interval time.Duration
+	timeout  time.Duration
+	// Sliding window of the recent check results, the last item is the newest
+	window    []bool
+	threshold int
+	message   func(string)
+}
+
+// StartProbes starts health check probes for the backends which have .probe property,
+// and probes are running until the context is canceled.
+// Backends which are marked as unhealthy by override_backends configuration are not probed
+func (i *Interpreter) StartProbes(ctx context.Context) error {
+	// Declarations are processed on the forked interpreter which is not bound to the request.
+	// Interactive debugger and coverage must not be affected by the processing
+	ip := i.fork()
+	ip.Debugger = DefaultDebugger{}
+	ip.Coverage = nil
+	// Health transitions are reported to the actual debugger
+	message := i.Debugger.Message
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ip.ProcessInit(req); err != nil {
+		return errors.WithStack(err)
+	}
+	// Backends are not reachable in replay mode, keep them healthy
+	if ip.ctx.HARReplayer != nil {
+		return nil
+	}
+
+	for _, backend := range ip.ctx.Backends {
+		if backend.Value == nil {
+			continue
+		}
+		p, err := ip.newProbe(backend, message)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if p == nil {
+			continue
+		}
+		go p.run(ctx)
+	}
+
+	// Shield node probes backends by itself like a separate POP
+	if i.shieldNode != nil {
+		return i.shieldNode.StartProbes(ctx)
+	}
+	return nil
+}
+
+// newProbe creates probe from .probe property of the backend.
+// Health transitions are reported by message function.
+// Returns nil if the backend does not need to be probed
+func (i *Interpreter) newProbe(backend *value.Backend, message func(string)) (*probe, error) {
+	name := backend.Value.Name.Value
+	var obj *ast.BackendProbeObject
+	for _, prop := range backend.Value.Properties {
+		if prop.Key.Value != "probe" {
+			continue
+		}
+		if v, ok := prop.Value.(*ast.BackendProbeObject); ok {
+			obj = v
+		}
+	}
+	if obj == nil {
+		return nil, nil
+	}
+
+	if ob, err := getOverrideBackend(i.ctx, name); err != nil {
+		return nil, errors.WithStack(err)
+	} else if ob != nil && ob.Unhealthy {
+		return nil, nil
+	}
+
+	p := &probe{
+		backend:   name,
+		health:    backend.Healthy,
+		expected:  defaultProbeExpectedResponse,
+		interval:  defaultProbeInterval,
+		timeout:   defaultProbeTimeout,
+		threshold: defaultProbeThreshold,
+		message:   message,
+	}
+
+	// Dummy probe always treats the backend as healthy without sending requests
+	if v, err := i.getProbeProperty(obj, "dummy", value.BooleanType); err != nil {
+		return nil, errors.WithStack(err)
+	} else if v != nil && value.Unwrap[*value.Boolean](v).Value {
+		p.health.Store(true)
+		return nil, nil
+	}
+
+	if v, err := i.getProbeProperty(obj, "expected_response", value.IntegerType); err != nil {
+		return nil, errors.WithStack(err)
+	} else if v != nil {
+		p.expected = int(value.Unwrap[*value.Integer](v).Value)
+	}
+	if v, err := i.getProbeProperty(obj, "interval", value.RTimeType); err != nil {
+		return nil, errors.WithStack(err)
+	} else if v != nil {
+		p.interval = value.Unwrap[*value.RTime](v).Value
+	}
+	if v, err := i.getProbeProperty(obj, "timeout", value.RTimeType); err != nil {
+		return nil, errors.WithStack(err)
+	} else if v != nil {
+		p.timeout = value.Unwrap[*value.RTime](v).Value
+	}
+	if v, err := i.getProbeProperty(obj, "threshold", value.IntegerType); err != nil {
+		return nil, errors.WithStack(err)
+	} else if v != nil {
+		p.threshold = int(value.Unwrap[*value.Integer](v).Value)
+	}
+
+	window := defaultProbeWindow
+	if v, err := i.getProbeProperty(obj, "window", value.IntegerType); err != nil {
+		return nil, errors.WithStack(err)
+	} else if v != nil {
+		window = int(value.Unwrap[*value.Integer](v).Value)
+	}
+	// Initial value is treated as the number of successful checks on startup,
+	// and defaults to threshold so the backend starts as healthy
+	initial := p.threshold
+	if v, err := i.getProbeProperty(obj, "initial", value.IntegerType); err != nil {
+		return nil, errors.WithStack(err)
+	} else if v != nil {
+		initial = int(value.Unwrap[*value.Integer](v).Value)
+	}
+	if window < 1 || p.threshold > window || initial > window {
+		return nil, exception.Runtime(
+			&backend.Value.Token,
+			"Invalid probe of backend %s, threshold and initial must not exceed window", name,
+		)
+	}
+	p.window = make([]bool, window)
+	for n := window - initial; n < window; n++ {
+		p.window[n] = true
+	}
+	p.health.Store(p.isHealthy())
+
+	req, err := i.createProbeRequest(backend, obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p.request = req
+	p.client = &http.Client{
+		Timeout: p.timeout,
+		// Probe checks the response status code of the backend itself
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if req.URL.Scheme == HTTPS_SCHEME {
+		p.client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				ServerName: req.URL.Hostname(),
+			},
+		}
+	}
+	return p, nil
+}
+
+// createProbeRequest creates health check request from .request or .url property.
+// Request is sent to the backend host which may be overridden by config
+func (i *Interpreter) createProbeRequest(backend *value.Backend, obj *ast.BackendProbeObject) (*http.Request, error) {
+	origin, err := i.getBackendOrigin(i.ctx, backend)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	raw, err := i.getProbeRequest(obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if raw == "" {
+		path := "/"
+		if v, err := i.getProbeProperty(obj, "url", value.StringType); err != nil {
+			return nil, errors.WithStack(err)
+		} else if v != nil {
+			path = value.Unwrap[*value.String](v).Value
+		}
+		raw = fmt.Sprintf("HEAD %s HTTP/1.1\r\nHost: %s\r\nConnection: close", path, origin.host)
+	}
+
+	req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(raw + "\r\n\r\n")))
+	if err != nil {
+		return nil, exception.Runtime(
+			&backend.Value.Token,
+			"Failed to parse probe request of backend %s: %s", backend.Value.Name.Value, err,
+		)
+	}
+	// Convert server request to client request
+	req.RequestURI = ""
+	req.URL.Scheme = origin.scheme
+	req.URL.Host = fmt.Sprintf("%s:%s", origin.host, origin.port)
+	if req.Host == "" {
+		req.Host = origin.host
+	}
+	return req, nil
+}
+
+// getProbeRequest returns raw HTTP request of .request property.
+// Multiple string literals are treated as request lines like:
+// .request = "HEAD / HTTP/1.1" "Host: example.com" "Connection: close";
+func (i *Interpreter) getProbeRequest(obj *ast.BackendProbeObject) (string, error) {
+	var exp ast.Expression
+	for _, v := range obj.Values {
+		if v.Key.Value == "request" {
+			exp = v.Value
+		}
+	}
+	if exp == nil {
+		return "", nil
+	}
+
+	var lines []string
+	var collect func(e ast.Expression) error
+	collect = func(e ast.Expression) error {
+		// Implicit string concatenation joins the request lines
+		if infix, ok := e.(*ast.InfixExpression); ok && infix.Operator == "+" && !infix.Explicit {
+			if err := collect(infix.Left); err != nil {
+				return err
+			}
+			return collect(infix.Right)
+		}
+		v, err := i.ProcessExpression(e, false)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		lines = append(lines, v.String())
+		return nil
+	}
+	if err := collect(exp); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\r\n"), nil
+}
+
+func (i *Interpreter) getProbeProperty(obj *ast.BackendProbeObject, key string, t value.Type) (value.Value, error) {
+	v, err := i.getBackendProperty(obj.Values, key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if v == nil {
+		return nil, nil
+	}
+	if v.Type() != t {
+		return nil, exception.Runtime(nil, "Probe property .%s must be %s type but got %s", key, t, v.Type())
+	}
+	return v, nil
+}
+
+func (p *probe) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		ok := p.check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		p.record(ok)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check sends health check request and returns true if the backend responds expected status code
+func (p *probe) check(ctx context.Context) bool {
+	resp, err := p.client.Do(p.request.Clone(ctx))
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // nolint: errcheck
+
+	return resp.StatusCode == p.expected
+}
+
+// record puts the check result into the window and updates the backend health
+func (p *probe) record(ok bool) {
+	copy(p.window, p.window[1:])
+	p.window[len(p.window)-1] = ok
+
+	healthy := p.isHealthy()
+	if p.health.Swap(healthy) != healthy {
+		state := "unhealthy"
+		if healthy {
+			state = "healthy"
+		}
+		p.message(fmt.Sprintf("Backend (%s) becomes %s by health check", p.backend, state))
+	}
+}
+
+// isHealthy returns true if the number of successful checks in the window reaches the threshold
+func (p *probe) isHealthy() bool {
+	var good int
+	for _, ok := range p.window {
+		if ok {
+			good++
+		}
+	}
+	return good >= p.threshold
+}
@@ -0,0 +1,180 @@
+package interpreter
+
+import (
+	gocontext "context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+// messageDebugger collects messages which are reported to the debugger
+type messageDebugger struct {
+	DefaultDebugger
+	mu       sync.Mutex
+	messages []string
+}
+
+func (d *messageDebugger) Message(msg string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = append(d.messages, msg)
+}
+
+func (d *messageDebugger) String() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return strings.Join(d.messages, "\n")
+}
+
+func TestBackendProbe(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	var probeRequest atomic.Value
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			probeRequest.Store(r.Method + " " + r.Header.Get("X-Probe"))
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		w.Write([]byte("primary")) // nolint:errcheck
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secondary")) // nolint:errcheck
+	}))
+	defer secondary.Close()
+
+	p, err := url.Parse(primary.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	s, err := url.Parse(secondary.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := fmt.Sprintf(`
+backend primary {
+  .host = "%s";
+  .port = "%s";
+  .probe = {
+    .request = "GET /health HTTP/1.1" "Host: example.com" "X-Probe: falco" "Connection: close";
+    .interval = 20ms;
+    .timeout = 1s;
+    .window = 3;
+    .threshold = 2;
+  }
+}
+backend secondary {
+  .host = "%s";
+  .port = "%s";
+}
+director fallback_director fallback {
+  { .backend = primary; }
+  { .backend = secondary; }
+}
+sub vcl_recv {
+  set req.backend = fallback_director;
+  return(pass);
+}
+sub vcl_deliver {
+  set resp.http.Primary-Healthy = if(backend.primary.healthy, "yes", "no");
+}
+`, p.Hostname(), p.Port(), s.Hostname(), s.Port())
+
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+		context.WithActualResponse(true),
+	)
+	debugger := &messageDebugger{}
+	ip.Debugger = debugger
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+	if err := ip.StartProbes(ctx); err != nil {
+		t.Errorf("Unexpected error on starting probes: %s", err)
+		return
+	}
+
+	assertResponse := func(body, healthy string) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			rec := httptest.NewRecorder()
+			ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			b, _ := io.ReadAll(rec.Body) // nolint:errcheck
+			if string(b) == body && rec.Header().Get("Primary-Healthy") == healthy {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Errorf("Response expects body=%s, healthy=%s, got body=%s, healthy=%s",
+					body, healthy, string(b), rec.Header().Get("Primary-Healthy"))
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	assertResponse("primary", "yes")
+	if v := probeRequest.Load(); v != "GET falco" {
+		t.Errorf("Probe request expects GET with X-Probe header, got=%v", v)
+	}
+
+	// Backend becomes unhealthy after failed checks reach the window, then fallback to secondary
+	status.Store(http.StatusInternalServerError)
+	assertResponse("secondary", "no")
+	if v := debugger.String(); !strings.Contains(v, "Backend (primary) becomes unhealthy by health check") {
+		t.Errorf("Health transition should be reported to the debugger, got=%s", v)
+	}
+
+	// Backend recovers when successful checks reach the threshold
+	status.Store(http.StatusOK)
+	assertResponse("primary", "yes")
+}
+
+func TestBackendProbeOverrideUnhealthy(t *testing.T) {
+	vcl := `
+backend origin {
+  .host = "example.com";
+  .probe = {
+    .url = "/health";
+    .interval = 1s;
+  }
+}
+sub vcl_recv {
+  if (!backend.origin.healthy) {
+    error 503;
+  }
+}
+`
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+		context.WithActualResponse(true),
+		context.WithOverrideBackends(map[string]*config.OverrideBackend{
+			"origin": {Host: "localhost", Unhealthy: true},
+		}),
+	)
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+	if err := ip.StartProbes(ctx); err != nil {
+		t.Errorf("Unexpected error on starting probes: %s", err)
+		return
+	}
+
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got=%d", rec.Code)
+	}
+}
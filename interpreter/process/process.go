@@ -20,6 +20,8 @@ type Process struct {
 	Error     error
 	StartTime int64
 	Response  *http.Response
+	// Process of the shield node when the request is forwarded to the shield POP
+	Shield *Process
 }
 
 func New() *Process {
@@ -33,7 +35,16 @@ func New() *Process {
 func (p *Process) Finalize(resp *http.Response) ([]byte, error) {
 	var backend string
 	if p.Backend != nil {
-		backend = p.Backend.Value.Name.Value
+		backend = p.Backend.String()
+	}
+
+	var shield json.RawMessage
+	if p.Shield != nil {
+		out, err := p.Shield.Finalize(p.Shield.Response)
+		if err != nil {
+			return nil, err
+		}
+		shield = out
 	}
 
 	var statusCode int
@@ -57,14 +68,15 @@ func (p *Process) Finalize(resp *http.Response) ([]byte, error) {
 	}
 
 	return json.MarshalIndent(struct {
-		Flows          []*Flow `json:"flows"`
-		Logs           []*Log  `json:"logs"`
-		Restarts       int     `json:"restarts"`
-		Backend        string  `json:"backend"`
-		Cached         bool    `json:"cached"`
-		ElapsedTimeUs  int64   `json:"elapsed_time_us"`
-		ElapsedTimeMs  int64   `json:"elapsed_time_ms"`
-		Error          error   `json:"error,omitempty"`
+		Flows          []*Flow         `json:"flows"`
+		Logs           []*Log          `json:"logs"`
+		Restarts       int             `json:"restarts"`
+		Backend        string          `json:"backend"`
+		Cached         bool            `json:"cached"`
+		ElapsedTimeUs  int64           `json:"elapsed_time_us"`
+		ElapsedTimeMs  int64           `json:"elapsed_time_ms"`
+		Error          error           `json:"error,omitempty"`
+		Shield         json.RawMessage `json:"shield,omitempty"`
 		ClientResponse struct {
 			StatusCode    int               `json:"status_code"`
 			ResponseBytes int               `json:"body_bytes"`
@@ -79,6 +91,7 @@ func (p *Process) Finalize(resp *http.Response) ([]byte, error) {
 		ElapsedTimeUs: time.Now().UnixMicro() - p.StartTime,
 		ElapsedTimeMs: time.Now().UnixMilli() - (p.StartTime / 1000),
 		Error:         p.Error,
+		Shield:        shield,
 		ClientResponse: struct {
 			StatusCode    int               `json:"status_code"`
 			ResponseBytes int               `json:"body_bytes"`
@@ -0,0 +1,99 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/rs/xid"
+	"github.com/ysugimoto/falco/interpreter/context"
+)
+
+// Simulate Fastly purge API on the simulator server.
+// URL purge is requested by PURGE method for the target URL,
+// surrogate key purge and purge all are requested to the same paths as Fastly API.
+// see: https://developer.fastly.com/reference/api/purging/
+var (
+	purgeAllPathRegex  = regexp.MustCompile(`^/service/[^/]+/purge_all$`)
+	purgeKeyPathRegex  = regexp.MustCompile(`^/service/[^/]+/purge/([^/]+)$`)
+	purgeKeysPathRegex = regexp.MustCompile(`^/service/[^/]+/purge$`)
+)
+
+const (
+	purgeMethod        = "PURGE"
+	softPurgeHeaderKey = "Fastly-Soft-Purge"
+)
+
+// handlePurge handles purge requests and returns true if the request is treated as purge
+func (i *Interpreter) handlePurge(w http.ResponseWriter, r *http.Request) bool {
+	soft := r.Header.Get(softPurgeHeaderKey) == "1"
+
+	switch {
+	case r.Method == purgeMethod:
+		i.purgeURL(w, r, soft)
+	case r.Method == http.MethodPost && purgeAllPathRegex.MatchString(r.URL.Path):
+		purged := i.cache.PurgeAll()
+		i.Debugger.Message(fmt.Sprintf("Purged all %d objects", purged))
+		writePurgeResponse(w, map[string]string{"status": "ok"})
+	case r.Method == http.MethodPost && purgeKeyPathRegex.MatchString(r.URL.Path):
+		key := purgeKeyPathRegex.FindStringSubmatch(r.URL.Path)[1]
+		purged := i.cache.PurgeKey(key, soft)
+		i.Debugger.Message(fmt.Sprintf("Purged %d objects for surrogate key %s", purged, key))
+		writePurgeResponse(w, map[string]string{"status": "ok", "id": xid.New().String()})
+	case r.Method == http.MethodPost && purgeKeysPathRegex.MatchString(r.URL.Path):
+		// Batch purge accepts space separated surrogate keys in Surrogate-Key header
+		ids := make(map[string]string)
+		for _, key := range strings.Fields(r.Header.Get("Surrogate-Key")) {
+			purged := i.cache.PurgeKey(key, soft)
+			i.Debugger.Message(fmt.Sprintf("Purged %d objects for surrogate key %s", purged, key))
+			ids[key] = xid.New().String()
+		}
+		writePurgeResponse(w, ids)
+	default:
+		return false
+	}
+	return true
+}
+
+// purgeURL purges the cache object for the request URL.
+// Like Fastly, the request is processed by vcl_recv first so that the URL normalization is applied,
+// then the cache key is calculated by vcl_hash subroutine
+func (i *Interpreter) purgeURL(w http.ResponseWriter, r *http.Request, soft bool) {
+	if err := i.ProcessInit(r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if sub, ok := i.ctx.Subroutines[context.FastlyVclNameRecv]; ok {
+		i.SetScope(context.RecvScope)
+		i.Debugger.Message("Process vcl_recv for URL purge")
+		state, err := i.ProcessSubroutine(sub, DebugPass)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Purge request could be rejected by error statement, e.g. authenticated purging
+		if state == ERROR {
+			i.Debugger.Message(fmt.Sprintf("URL purge is rejected in vcl_recv with status %d", i.ctx.ObjectStatus.Value))
+			http.Error(w, i.ctx.ObjectResponse.Value, int(i.ctx.ObjectStatus.Value))
+			return
+		}
+	}
+
+	if err := i.ProcessHash(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if i.cache.Purge(i.ctx.RequestHash.Value, soft) {
+		i.Debugger.Message(fmt.Sprintf("Purged object for %s", i.ctx.RequestHash.Value))
+	}
+	writePurgeResponse(w, map[string]string{"status": "ok", "id": xid.New().String()})
+}
+
+func writePurgeResponse(w http.ResponseWriter, body map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body) // nolint:errcheck
+}
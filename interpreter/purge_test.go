@@ -0,0 +1,143 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestPurge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60, stale-while-revalidate=30")
+		w.Header().Set("Surrogate-Key", "all "+strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(lookup);
+}
+`
+	ip := New(context.WithResolver(
+		resolver.NewStaticResolver("main", vcl),
+	))
+
+	send := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		ip.serve(rec, req)
+		return rec
+	}
+	assertCache := func(name, path, expect string) {
+		send(http.MethodGet, path, nil)
+		if ip.process.Error != nil {
+			t.Errorf("%s: unexpected error: %s", name, ip.process.Error)
+			return
+		}
+		if v := ip.ctx.Response.Header.Get("X-Cache"); v != expect {
+			t.Errorf("%s: X-Cache for %s expects %s, got=%s", name, path, expect, v)
+		}
+	}
+
+	assertCache("initial request", "/foo", "MISS")
+	assertCache("initial request", "/bar", "MISS")
+	assertCache("cached request", "/foo", "HIT")
+
+	// Soft purge marks object as stale
+	send(http.MethodPost, "/service/dummy/purge/foo", map[string]string{"Fastly-Soft-Purge": "1"})
+	assertCache("soft purge", "/foo", "HIT-STALE")
+	assertCache("soft purge", "/bar", "HIT")
+
+	// Hard purge removes object
+	if rec := send("PURGE", "/foo", nil); rec.Code != http.StatusOK {
+		t.Errorf("URL purge expects status 200, got=%d", rec.Code)
+	}
+	assertCache("url purge", "/foo", "MISS")
+	assertCache("url purge", "/bar", "HIT")
+
+	send(http.MethodPost, "/service/dummy/purge", map[string]string{"Surrogate-Key": "foo bar"})
+	assertCache("batch purge", "/foo", "MISS")
+	assertCache("batch purge", "/bar", "MISS")
+
+	send(http.MethodPost, "/service/dummy/purge_all", nil)
+	assertCache("purge all", "/foo", "MISS")
+	assertCache("purge all", "/bar", "MISS")
+}
+
+func TestPurgeURLProcessesRecv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  if (req.method == "PURGE" && !req.http.Purge-Token) {
+    error 401 "Unauthorized";
+  }
+  # Normalize URL to be cached
+  set req.url = querystring.remove(req.url);
+  return(lookup);
+}
+`
+	ip := New(context.WithResolver(
+		resolver.NewStaticResolver("main", vcl),
+	))
+
+	send := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		ip.serve(rec, req)
+		return rec
+	}
+	assertCache := func(name, path, expect string) {
+		send(http.MethodGet, path, nil)
+		if ip.process.Error != nil {
+			t.Errorf("%s: unexpected error: %s", name, ip.process.Error)
+			return
+		}
+		if v := ip.ctx.Response.Header.Get("X-Cache"); v != expect {
+			t.Errorf("%s: X-Cache for %s expects %s, got=%s", name, path, expect, v)
+		}
+	}
+
+	assertCache("initial request", "/foo?bar=baz", "MISS")
+	assertCache("cached request", "/foo", "HIT")
+
+	// Purge request is rejected by vcl_recv
+	if rec := send("PURGE", "/foo?q=1", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Rejected URL purge expects status 401, got=%d", rec.Code)
+	}
+	assertCache("rejected purge", "/foo", "HIT")
+
+	// Purge request URL is normalized by vcl_recv
+	if rec := send("PURGE", "/foo?q=1", map[string]string{"Purge-Token": "1"}); rec.Code != http.StatusOK {
+		t.Errorf("URL purge expects status 200, got=%d", rec.Code)
+	}
+	assertCache("normalized purge", "/foo", "MISS")
+}
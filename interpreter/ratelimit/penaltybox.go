@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Fastly penaltybox TTL is truncated to the minute and limited in this range
+// see: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-penaltybox-add/
+const (
+	minPenaltyTTL = time.Minute
+	maxPenaltyTTL = time.Hour
+)
+
+type Penaltybox struct {
+	Name string
+
+	mu      sync.Mutex
+	entries map[string]time.Time // entry -> expiration time
+}
+
+func NewPenaltybox(name string) *Penaltybox {
+	return &Penaltybox{
+		Name:    name,
+		entries: make(map[string]time.Time),
+	}
+}
+
+// Add puts the entry into the penaltybox until now + ttl
+func (p *Penaltybox) Add(entry string, ttl time.Duration, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.entries[entry] = now.Add(PenaltyTTL(ttl))
+}
+
+// Has reports whether the entry is still in the penaltybox at now
+func (p *Penaltybox) Has(entry string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	expires, ok := p.entries[entry]
+	if !ok {
+		return false
+	}
+	if !now.Before(expires) {
+		delete(p.entries, entry)
+		return false
+	}
+	return true
+}
+
+// Remove deletes the entry from the penaltybox
+func (p *Penaltybox) Remove(entry string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.entries, entry)
+}
+
+// PenaltyTTL normalizes ttl as Fastly does - truncate to the minute and clamp between 1m and 1h
+func PenaltyTTL(ttl time.Duration) time.Duration {
+	ttl = ttl.Truncate(time.Minute)
+	if ttl < minPenaltyTTL {
+		return minPenaltyTTL
+	}
+	if ttl > maxPenaltyTTL {
+		return maxPenaltyTTL
+	}
+	return ttl
+}
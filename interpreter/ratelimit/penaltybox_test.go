@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPenaltybox(t *testing.T) {
+	pb := NewPenaltybox("example")
+	base := time.Unix(1700000000, 0)
+
+	pb.Add("client", 90*time.Second, base)
+	tests := []struct {
+		elapsed time.Duration
+		expect  bool
+	}{
+		{elapsed: 0, expect: true},
+		{elapsed: 59 * time.Second, expect: true},
+		{elapsed: 60 * time.Second, expect: false},
+	}
+	for _, tt := range tests {
+		if v := pb.Has("client", base.Add(tt.elapsed)); v != tt.expect {
+			t.Errorf("Has after %s expects %t, got=%t", tt.elapsed, tt.expect, v)
+		}
+	}
+
+	pb.Add("client", time.Minute, base)
+	pb.Remove("client")
+	if pb.Has("client", base) {
+		t.Errorf("Removed entry should not be in penaltybox")
+	}
+}
+
+func TestPenaltyTTL(t *testing.T) {
+	tests := []struct {
+		input  time.Duration
+		expect time.Duration
+	}{
+		{input: 0, expect: time.Minute},
+		{input: 30 * time.Second, expect: time.Minute},
+		{input: 150 * time.Second, expect: 2 * time.Minute},
+		{input: 2 * time.Hour, expect: time.Hour},
+	}
+	for _, tt := range tests {
+		if v := PenaltyTTL(tt.input); v != tt.expect {
+			t.Errorf("PenaltyTTL(%s) expects %s, got=%s", tt.input, tt.expect, v)
+		}
+	}
+}
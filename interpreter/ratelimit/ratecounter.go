@@ -0,0 +1,113 @@
+// Falco's rate counter and penalty box are simply in-memory
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Fastly ratecounter holds counts for the last 60 seconds per entry.
+// We store counts into one second slots and sum them up for the requested window.
+// see: https://developer.fastly.com/reference/vcl/declarations/ratecounter/
+const slotSize = 60
+
+type counter struct {
+	counts    [slotSize]int64
+	seconds   [slotSize]int64 // unix second which corresponds to each slot
+	updatedAt time.Time
+}
+
+func (c *counter) add(delta int64, now time.Time) {
+	sec := now.Unix()
+	idx := slotIndex(sec)
+	if c.seconds[idx] != sec {
+		c.seconds[idx] = sec
+		c.counts[idx] = 0
+	}
+	c.counts[idx] += delta
+	c.updatedAt = now
+}
+
+func (c *counter) sum(window time.Duration, now time.Time) int64 {
+	sec := now.Unix()
+	from := sec - int64(window/time.Second)
+
+	var total int64
+	for i := range c.seconds {
+		if c.seconds[i] > from && c.seconds[i] <= sec {
+			total += c.counts[i]
+		}
+	}
+	return total
+}
+
+func slotIndex(sec int64) int {
+	return int(((sec % slotSize) + slotSize) % slotSize)
+}
+
+type Ratecounter struct {
+	Name string
+
+	mu      sync.Mutex
+	entries map[string]*counter
+}
+
+func NewRatecounter(name string) *Ratecounter {
+	return &Ratecounter{
+		Name:    name,
+		entries: make(map[string]*counter),
+	}
+}
+
+// Increment adds delta to the entry's counter at now
+func (r *Ratecounter) Increment(entry string, delta int64, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(now)
+	c, ok := r.entries[entry]
+	if !ok {
+		c = &counter{}
+		r.entries[entry] = c
+	}
+	c.add(delta, now)
+}
+
+// Count returns the sum of counts of the entry in the sliding window which ends at now
+func (r *Ratecounter) Count(entry string, window time.Duration, now time.Time) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.entries[entry]
+	if !ok {
+		return 0
+	}
+	return c.sum(window, now)
+}
+
+// Rate returns the average count per second of the entry in the sliding window which ends at now
+func (r *Ratecounter) Rate(entry string, window time.Duration, now time.Time) float64 {
+	seconds := window.Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(r.Count(entry, window, now)) / seconds
+}
+
+// Clear removes all counts of the entry
+func (r *Ratecounter) Clear(entry string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, entry)
+}
+
+// Remove entries which are not updated in the counting period.
+// Caller must hold the lock.
+func (r *Ratecounter) expire(now time.Time) {
+	for key, c := range r.entries {
+		if now.Sub(c.updatedAt) >= slotSize*time.Second {
+			delete(r.entries, key)
+		}
+	}
+}
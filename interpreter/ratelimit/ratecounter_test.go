@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRatecounterSlidingWindow(t *testing.T) {
+	rc := NewRatecounter("example")
+	base := time.Unix(1700000000, 0)
+
+	for i := 0; i < 30; i++ {
+		rc.Increment("client", 1, base.Add(time.Duration(i)*time.Second))
+	}
+	now := base.Add(29 * time.Second)
+
+	tests := []struct {
+		window time.Duration
+		count  int64
+		rate   float64
+	}{
+		{window: time.Second, count: 1, rate: 1},
+		{window: 10 * time.Second, count: 10, rate: 1},
+		{window: 60 * time.Second, count: 30, rate: 0.5},
+	}
+	for _, tt := range tests {
+		if c := rc.Count("client", tt.window, now); c != tt.count {
+			t.Errorf("Count in %s window expects %d, got=%d", tt.window, tt.count, c)
+		}
+		if r := rc.Rate("client", tt.window, now); r != tt.rate {
+			t.Errorf("Rate in %s window expects %f, got=%f", tt.window, tt.rate, r)
+		}
+	}
+
+	// Counts move out of the window as time goes on
+	later := now.Add(45 * time.Second)
+	if c := rc.Count("client", 60*time.Second, later); c != 15 {
+		t.Errorf("Count after 45 seconds expects 15, got=%d", c)
+	}
+	if c := rc.Count("other", 60*time.Second, now); c != 0 {
+		t.Errorf("Count of unknown entry expects 0, got=%d", c)
+	}
+}
+
+func TestRatecounterExpireEntry(t *testing.T) {
+	rc := NewRatecounter("example")
+	base := time.Unix(1700000000, 0)
+
+	rc.Increment("client", 10, base)
+	// Increment another entry after the counting period, old entry should be swept
+	rc.Increment("other", 1, base.Add(61*time.Second))
+	if _, ok := rc.entries["client"]; ok {
+		t.Errorf("Expired entry should be removed")
+	}
+
+	rc.Clear("other")
+	if c := rc.Count("other", 60*time.Second, base.Add(61*time.Second)); c != 0 {
+		t.Errorf("Cleared entry count expects 0, got=%d", c)
+	}
+}
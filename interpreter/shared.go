@@ -0,0 +1,65 @@
+package interpreter
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+)
+
+// sharedState holds the resources which live across the requests like Fastly POP does.
+// It is shared between per-request interpreters so all accesses must be safe for concurrent use
+type sharedState struct {
+	mu            sync.Mutex
+	ratecounters  map[string]*ratelimit.Ratecounter
+	penaltyboxes  map[string]*ratelimit.Penaltybox
+	backendHealth map[string]*atomic.Bool
+}
+
+func newSharedState() *sharedState {
+	return &sharedState{
+		ratecounters:  make(map[string]*ratelimit.Ratecounter),
+		penaltyboxes:  make(map[string]*ratelimit.Penaltybox),
+		backendHealth: make(map[string]*atomic.Bool),
+	}
+}
+
+// ratecounter returns the ratecounter for the name, create it if not exists
+func (s *sharedState) ratecounter(name string) *ratelimit.Ratecounter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rc, ok := s.ratecounters[name]
+	if !ok {
+		rc = ratelimit.NewRatecounter(name)
+		s.ratecounters[name] = rc
+	}
+	return rc
+}
+
+// penaltybox returns the penaltybox for the name, create it if not exists
+func (s *sharedState) penaltybox(name string) *ratelimit.Penaltybox {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pb, ok := s.penaltyboxes[name]
+	if !ok {
+		pb = ratelimit.NewPenaltybox(name)
+		s.penaltyboxes[name] = pb
+	}
+	return pb
+}
+
+// health returns the health flag of the backend, the backend is healthy on creation
+func (s *sharedState) health(name string) *atomic.Bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.backendHealth[name]
+	if !ok {
+		h = &atomic.Bool{}
+		h.Store(true)
+		s.backendHealth[name] = h
+	}
+	return h
+}
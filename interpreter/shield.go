@@ -0,0 +1,92 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/exception"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+// Client address of the request which the edge node forwards to the shield node
+const shieldClientAddr = "127.0.0.1:0"
+
+// EnableShieldNode enables multi-node simulation.
+// Another interpreter runs as the shield POP from the same VCL, and requests for the shield director
+// are processed on it with Fastly-FF header instead of being sent over the network.
+// The shield node has its own cache, ratelimit and backend health like a separate POP
+func (i *Interpreter) EnableShieldNode() {
+	options := make([]context.Option, len(i.options), len(i.options)+2)
+	copy(options, i.options)
+	options = append(options, context.WithActualResponse(true), context.WithShieldNode(true))
+	i.shieldNode = New(options...)
+}
+
+func (i *Interpreter) createShieldRequest(ctx *context.Context, dc *value.DirectorConfig) (*http.Request, error) {
+	if i.shieldNode == nil {
+		return nil, exception.Runtime(nil, "Shield director %s is selected but multi-node simulation is not enabled", dc.Name)
+	}
+	if ctx.IsShieldNode {
+		return nil, exception.Runtime(
+			nil,
+			"Shield director %s is selected on the shield node, use fastly.try_select_shield to avoid the loop",
+			dc.Name,
+		)
+	}
+
+	url := fmt.Sprintf("http://%s%s", ctx.Request.Host, ctx.Request.URL.RequestURI())
+	i.Debugger.Message(fmt.Sprintf("Forwarding request to the shield node (%s) %s", dc.Name, url))
+
+	req, err := http.NewRequest(ctx.Request.Method, url, ctx.Request.Body)
+	if err != nil {
+		return nil, exception.Runtime(nil, "Failed to create shield request: %s", err)
+	}
+	req.Header = ctx.Request.Header.Clone()
+	setupFastlyHeaders(ctx, req)
+	return req, nil
+}
+
+// sendShieldRequest processes the backend request on the shield node and returns its response.
+// Process of the shield node is reported in the process of this node
+func (i *Interpreter) sendShieldRequest() (*http.Response, error) {
+	req := i.ctx.BackendRequest.Clone(i.ctx.Request.Context())
+	req.RemoteAddr = shieldClientAddr
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	ip := i.shieldNode.fork()
+	if err := ip.processRequest(req); err != nil {
+		return nil, exception.Runtime(nil, "Failed to process request on the shield node: %s", err)
+	}
+	i.process.Shield = ip.process
+	if ip.ctx.Response == nil {
+		return nil, exception.Runtime(nil, "Shield node does not respond: %s", ip.process.Error)
+	}
+
+	// Response body is read by this node and the process output of the shield node
+	resp := ip.ctx.Response
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+	ip.process.Response = resp
+
+	i.Debugger.Message(fmt.Sprintf("Shield node responds status code %d", resp.StatusCode))
+	return &http.Response{
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		Proto:         resp.Proto,
+		ProtoMajor:    resp.ProtoMajor,
+		ProtoMinor:    resp.ProtoMinor,
+		Header:        resp.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(buf.Bytes())),
+		ContentLength: int64(buf.Len()),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,136 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/variable"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func shieldTestVCL(origin *url.URL) string {
+	return fmt.Sprintf(`
+backend F_origin {
+  .host = "%s";
+  .port = "%s";
+}
+director ssl_shield_falco shield {
+  .shield = "falco-shield";
+  .is_ssl = true;
+}
+sub vcl_recv {
+  set req.backend = fastly.try_select_shield(ssl_shield_falco, F_origin);
+  if (!req.backend.is_shield) {
+    set req.http.X-Shield-Visits = fastly.ff.visits_this_service;
+  }
+  return(pass);
+}
+sub vcl_deliver {
+  add resp.http.X-Hostname = server.hostname;
+}
+`, origin.Hostname(), origin.Port())
+}
+
+func TestShieldNode(t *testing.T) {
+	var fastlyFF, visits string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fastlyFF = r.Header.Get("Fastly-FF")
+		visits = r.Header.Get("X-Shield-Visits")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", shieldTestVCL(parsed))),
+		context.WithActualResponse(true),
+	)
+	ip.EnableShieldNode()
+
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got=%d", rec.Code)
+		return
+	}
+
+	// Request passes through the edge node and the shield node
+	entries := strings.Split(fastlyFF, ", ")
+	if len(entries) != 2 {
+		t.Errorf("Fastly-FF expects 2 entries, got=%s", fastlyFF)
+	} else {
+		if !strings.HasSuffix(entries[0], "!"+variable.FALCO_SERVER_HOSTNAME) {
+			t.Errorf("First Fastly-FF entry expects the edge node, got=%s", entries[0])
+		}
+		if !strings.HasSuffix(entries[1], "!"+variable.FALCO_SHIELD_SERVER_HOSTNAME) {
+			t.Errorf("Second Fastly-FF entry expects the shield node, got=%s", entries[1])
+		}
+	}
+	if visits != "1" {
+		t.Errorf("fastly.ff.visits_this_service on the shield node expects 1, got=%s", visits)
+	}
+	hostnames := rec.Header().Values("X-Hostname")
+	expect := []string{variable.FALCO_SHIELD_SERVER_HOSTNAME, variable.FALCO_SERVER_HOSTNAME}
+	if strings.Join(hostnames, ",") != strings.Join(expect, ",") {
+		t.Errorf("X-Hostname expects %v, got=%v", expect, hostnames)
+	}
+	if v := rec.Header().Get("X-Cache"); v != "MISS, MISS" {
+		t.Errorf("X-Cache expects MISS, MISS, got=%s", v)
+	}
+}
+
+func TestShieldNodeProcess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", shieldTestVCL(parsed))))
+	ip.EnableShieldNode()
+
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var result struct {
+		Flows   []any  `json:"flows"`
+		Backend string `json:"backend"`
+		Shield  *struct {
+			Flows   []any  `json:"flows"`
+			Backend string `json:"backend"`
+		} `json:"shield"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Errorf("Unexpected JSON decode error: %s", err)
+		return
+	}
+	if result.Backend != "ssl_shield_falco" {
+		t.Errorf("Edge backend expects ssl_shield_falco, got=%s", result.Backend)
+	}
+	if result.Shield == nil {
+		t.Errorf("Shield process must be reported")
+		return
+	}
+	if result.Shield.Backend != "F_origin" {
+		t.Errorf("Shield backend expects F_origin, got=%s", result.Shield.Backend)
+	}
+	if len(result.Shield.Flows) == 0 {
+		t.Errorf("Shield flows must not be empty")
+	}
+}
@@ -34,6 +34,7 @@ func (i *Interpreter) ProcessBlockStatement(
 		if debugState != DebugStepOut {
 			debugState = i.Debugger.Run(stmt)
 		}
+		i.Coverage.MarkStatement(stmt)
 
 		// Find process marker and add flow if found
 		if name, found := findProcessMark(stmt.GetMeta().Leading); found {
@@ -420,7 +421,7 @@ func (i *Interpreter) ProcessFunctionCallStatement(stmt *ast.FunctionCallStateme
 	}
 
 	// Builtin function will not change any state
-	fn, err := function.Exists(i.ctx.Scope, stmt.Function.Value)
+	fn, err := function.Lookup(i.functions, i.ctx.Scope, stmt.Function.Value)
 	if err != nil {
 		return NONE, exception.Runtime(&stmt.GetMeta().Token, err.Error())
 	}
@@ -490,6 +491,7 @@ func (i *Interpreter) ProcessIfStatement(
 	switch t := cond.(type) {
 	case *value.Boolean:
 		if t.Value {
+			i.Coverage.MarkBranch(stmt, 0)
 			val, state, _, err := i.ProcessBlockStatement(stmt.Consequence.Statements, ds, isReturnAsValue)
 			if err != nil {
 				return value.Null, NONE, errors.WithStack(err)
@@ -501,6 +503,7 @@ func (i *Interpreter) ProcessIfStatement(
 		}
 	case *value.String:
 		if !t.IsNotSet {
+			i.Coverage.MarkBranch(stmt, 0)
 			val, state, _, err := i.ProcessBlockStatement(stmt.Consequence.Statements, ds, isReturnAsValue)
 			if err != nil {
 				return value.Null, NONE, errors.WithStack(err)
@@ -520,7 +523,7 @@ func (i *Interpreter) ProcessIfStatement(
 	}
 
 	// else if
-	for _, ei := range stmt.Another {
+	for n, ei := range stmt.Another {
 		// Call debugger
 		if ds != DebugStepOut {
 			ds = i.Debugger.Run(ei)
@@ -533,6 +536,7 @@ func (i *Interpreter) ProcessIfStatement(
 		switch t := cond.(type) {
 		case *value.Boolean:
 			if t.Value {
+				i.Coverage.MarkBranch(stmt, n+1)
 				val, state, _, err := i.ProcessBlockStatement(ei.Consequence.Statements, ds, isReturnAsValue)
 				if err != nil {
 					return value.Null, NONE, errors.WithStack(err)
@@ -544,6 +548,7 @@ func (i *Interpreter) ProcessIfStatement(
 			}
 		case *value.String:
 			if !t.IsNotSet {
+				i.Coverage.MarkBranch(stmt, n+1)
 				val, state, _, err := i.ProcessBlockStatement(ei.Consequence.Statements, ds, isReturnAsValue)
 				if err != nil {
 					return value.Null, NONE, errors.WithStack(err)
@@ -563,7 +568,8 @@ func (i *Interpreter) ProcessIfStatement(
 		}
 	}
 
-	// else
+	// else, also mark implicit else branch when the statement does not have it
+	i.Coverage.MarkBranch(stmt, len(stmt.Another)+1)
 	if stmt.Alternative != nil {
 		val, state, _, err := i.ProcessBlockStatement(stmt.Alternative.Consequence.Statements, ds, isReturnAsValue)
 		if err != nil {
@@ -633,6 +639,8 @@ func (i *Interpreter) ProcessSwitchStatement(
 		return value.Null, state, nil
 	}
 
+	// Implicit default branch
+	i.Coverage.MarkBranch(stmt, len(stmt.Cases))
 	return value.Null, NONE, nil
 }
 
@@ -668,6 +676,10 @@ func (i *Interpreter) ProcessCaseStatement(
 	}
 
 	if matched {
+		// Fallthrough case is not a branch which is taken by the control expression
+		if !isFallthrough {
+			i.Coverage.MarkBranch(stmt, offset)
+		}
 		val, state, _, err := i.ProcessBlockStatement(stmt.Cases[offset].Statements, ds, isReturnAsValue)
 		if err != nil {
 			return value.Null, NONE, false, errors.WithStack(err)
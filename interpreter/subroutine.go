@@ -14,6 +14,7 @@ import (
 
 func (i *Interpreter) ProcessSubroutine(sub *ast.SubroutineDeclaration, ds DebugState) (State, error) {
 	i.process.Flows = append(i.process.Flows, process.NewFlow(i.ctx, process.WithSubroutine(sub)))
+	i.Coverage.MarkSubroutine(sub)
 
 	// Store the current values and restore after subroutine has ended
 	regex := i.ctx.RegexMatchedValues
@@ -28,11 +29,12 @@ func (i *Interpreter) ProcessSubroutine(sub *ast.SubroutineDeclaration, ds Debug
 	}()
 
 	// Try to extract fastly reserved subroutine macro
-	if err := i.extractBoilerplateMacro(sub); err != nil {
+	statements, err := i.extractBoilerplateMacro(sub)
+	if err != nil {
 		return NONE, errors.WithStack(err)
 	}
 
-	statements, err := i.resolveIncludeStatement(sub.Block.Statements, false)
+	statements, err = i.resolveIncludeStatement(statements, false)
 	if err != nil {
 		return NONE, errors.WithStack(err)
 	}
@@ -45,6 +47,7 @@ func (i *Interpreter) ProcessSubroutine(sub *ast.SubroutineDeclaration, ds Debug
 // nolint: gocognit
 func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration, ds DebugState) (value.Value, State, error) {
 	i.process.Flows = append(i.process.Flows, process.NewFlow(i.ctx, process.WithSubroutine(sub)))
+	i.Coverage.MarkSubroutine(sub)
 
 	// Store the current values and restore after subroutine has ended
 	regex := i.ctx.RegexMatchedValues
@@ -66,6 +69,7 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 		if debugState != DebugStepOut {
 			debugState = i.Debugger.Run(stmt)
 		}
+		i.Coverage.MarkStatement(stmt)
 
 		// Find process marker and add flow if found
 		if name, found := findProcessMark(stmt.GetMeta().Leading); found {
@@ -225,19 +229,21 @@ func (i *Interpreter) ProcessExpressionReturnStatement(stmt *ast.ReturnStatement
 	}
 }
 
-func (i *Interpreter) extractBoilerplateMacro(sub *ast.SubroutineDeclaration) error {
+// extractBoilerplateMacro returns subroutine statements which the scoped snippets are extracted into.
+// Subroutine declaration must not be modified because it is shared between requests
+func (i *Interpreter) extractBoilerplateMacro(sub *ast.SubroutineDeclaration) ([]ast.Statement, error) {
 	if i.ctx.FastlySnippets == nil {
-		return nil
+		return sub.Block.Statements, nil
 	}
 
 	// If subroutine name is fastly subroutine, find and extract boilerplate macro
 	macro, ok := context.FastlyReservedSubroutine[sub.Name.Value]
 	if !ok {
-		return nil
+		return sub.Block.Statements, nil
 	}
 	snippets, ok := i.ctx.FastlySnippets.ScopedSnippets[macro]
 	if !ok || len(snippets) == 0 {
-		return nil
+		return sub.Block.Statements, nil
 	}
 
 	macroName := strings.ToUpper("fastly " + macro)
@@ -248,13 +254,12 @@ func (i *Interpreter) extractBoilerplateMacro(sub *ast.SubroutineDeclaration) er
 		for _, s := range snippets {
 			statements, err := loadStatementVCL(s.Name, s.Data)
 			if err != nil {
-				return errors.WithStack(err)
+				return nil, errors.WithStack(err)
 			}
 			resolved = append(resolved, statements...)
 		}
 		// Prevent to block statements
-		sub.Block.Statements = append(resolved, sub.Block.Statements...)
-		return nil
+		return append(resolved, sub.Block.Statements...), nil
 	}
 
 	// Find "FASTLY [macro]" comment and extract inside block statement
@@ -264,7 +269,7 @@ func (i *Interpreter) extractBoilerplateMacro(sub *ast.SubroutineDeclaration) er
 			for _, s := range snippets {
 				statements, err := loadStatementVCL(s.Name, s.Data)
 				if err != nil {
-					return errors.WithStack(err)
+					return nil, errors.WithStack(err)
 				}
 				resolved = append(resolved, statements...)
 			}
@@ -272,8 +277,7 @@ func (i *Interpreter) extractBoilerplateMacro(sub *ast.SubroutineDeclaration) er
 		}
 		resolved = append(resolved, stmt) // don't forget to append original statement
 	}
-	sub.Block.Statements = resolved
-	return nil
+	return resolved, nil
 }
 
 func hasFastlyBoilerplateMacro(cs ast.Comments, macroName string) bool {
@@ -3,10 +3,7 @@ package interpreter
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"crypto/tls"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/ysugimoto/falco/ast"
 	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/interpreter/cache"
 	icontext "github.com/ysugimoto/falco/interpreter/context"
 	"github.com/ysugimoto/falco/interpreter/exception"
 	"github.com/ysugimoto/falco/interpreter/limitations"
@@ -39,27 +37,33 @@ func getOverrideBackend(ctx *icontext.Context, backendName string) (*config.Over
 	return nil, nil
 }
 
-func setupFastlyHeaders(req *http.Request) {
+func setupFastlyHeaders(ctx *icontext.Context, req *http.Request) {
 	// Fastly-FF
 	// https://www.fastly.com/documentation/reference/http/http-headers/Fastly-FF/#format
-	mac := hmac.New(sha256.New, []byte("falco"))
-	mac.Write([]byte(variable.FALCO_VIRTUAL_SERVICE_ID))
-	hash := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-	ff := fmt.Sprintf("%s!%s!%s", hash, variable.FALCO_DATACENTER, variable.FALCO_SERVER_HOSTNAME)
-	if req.Header.Get("Fastly-FF") != "" {
-		req.Header.Add("Fastly-FF", ","+ff)
+	ff := variable.FastlyFF(ctx)
+	if v := req.Header.Get("Fastly-FF"); v != "" {
+		req.Header.Set("Fastly-FF", v+", "+ff)
 	} else {
 		req.Header.Set("Fastly-FF", ff)
 	}
 	// TODO: cdn-loop, fastly-client, fastly-client-ip, x-forwarded-for, x-forwarded-host, x-forwarded-server, x-varnish,
 }
 
-func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value.Backend) (*http.Request, error) {
-	var port string
+// backendOrigin represents the origin server address of the backend
+type backendOrigin struct {
+	scheme     string
+	host       string
+	port       string
+	overridden bool
+}
+
+// getBackendOrigin resolves the origin address of the backend, host and ssl may be overridden by config
+func (i *Interpreter) getBackendOrigin(ctx *icontext.Context, backend *value.Backend) (*backendOrigin, error) {
+	origin := &backendOrigin{scheme: "http"}
 	if v, err := i.getBackendProperty(backend.Value.Properties, "port"); err != nil {
 		return nil, errors.WithStack(err)
 	} else if v != nil {
-		port = value.Unwrap[*value.String](v).Value
+		origin.port = value.Unwrap[*value.String](v).Value
 	}
 
 	// Get override backend host from configuration
@@ -69,35 +73,50 @@ func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value
 	}
 
 	// scheme may be overrided by config
-	scheme := "http"
 	if overrideBackend != nil {
+		origin.overridden = true
 		if overrideBackend.SSL {
-			scheme = HTTPS_SCHEME
+			origin.scheme = HTTPS_SCHEME
 		}
 	} else {
 		if v, err := i.getBackendProperty(backend.Value.Properties, "ssl"); err != nil {
 			return nil, errors.WithStack(err)
 		} else if v != nil {
 			if value.Unwrap[*value.Boolean](v).Value {
-				scheme = HTTPS_SCHEME
+				origin.scheme = HTTPS_SCHEME
 			}
 		}
 	}
 
 	// host may be overrided by config
-	var host string
 	if overrideBackend != nil {
-		host = overrideBackend.Host
+		origin.host = overrideBackend.Host
 	} else {
 		if v, err := i.getBackendProperty(backend.Value.Properties, "host"); err != nil {
 			return nil, errors.WithStack(err)
 		} else if v != nil {
-			host = value.Unwrap[*value.String](v).Value
+			origin.host = value.Unwrap[*value.String](v).Value
 		} else {
 			return nil, exception.Runtime(nil, "Failed to find host for backend %s", backend)
 		}
 	}
 
+	if origin.port == "" {
+		if origin.scheme == HTTPS_SCHEME {
+			origin.port = "443"
+		} else {
+			origin.port = "80"
+		}
+	}
+	return origin, nil
+}
+
+func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value.Backend) (*http.Request, error) {
+	origin, err := i.getBackendOrigin(ctx, backend)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	var alwaysHost bool
 	if v, err := i.getBackendProperty(backend.Value.Properties, "always_use_host_header"); err != nil {
 		return nil, errors.WithStack(err)
@@ -105,15 +124,7 @@ func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value
 		alwaysHost = value.Unwrap[*value.Boolean](v).Value
 	}
 
-	if port == "" {
-		if scheme == HTTPS_SCHEME {
-			port = "443"
-		} else {
-			port = "80"
-		}
-	}
-
-	url := fmt.Sprintf("%s://%s:%s%s", scheme, host, port, i.ctx.Request.URL.Path)
+	url := fmt.Sprintf("%s://%s:%s%s", origin.scheme, origin.host, origin.port, i.ctx.Request.URL.Path)
 	query := i.ctx.Request.URL.Query()
 	if v := query.Encode(); v != "" {
 		url += "?" + v
@@ -121,7 +132,7 @@ func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value
 
 	// Debug message
 	var suffix string
-	if overrideBackend != nil {
+	if origin.overridden {
 		suffix = " (overrided by config)"
 	}
 	i.Debugger.Message(
@@ -137,23 +148,29 @@ func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value
 		return nil, exception.Runtime(nil, "Failed to create backend request: %s", err)
 	}
 	req.Header = i.ctx.Request.Header.Clone()
-	setupFastlyHeaders(req)
+	setupFastlyHeaders(ctx, req)
 
 	if alwaysHost {
-		req.Header.Set("Host", host)
+		req.Header.Set("Host", origin.host)
 	}
 	return req, nil
 }
 
 func (i *Interpreter) sendBackendRequest(backend *value.Backend) (*http.Response, error) {
-	fbt, err := i.getBackendProperty(backend.Value.Properties, "first_byte_timeout")
-	if err != nil {
-		return nil, errors.WithStack(err)
+	if backend.IsShield() {
+		return i.sendShieldRequest()
 	}
 
 	firstByteTimeout := 15 * time.Second
-	if fbt != nil {
-		firstByteTimeout = value.Unwrap[*value.RTime](fbt).Value
+	// Director does not have backend properties, the backend is selected on creating backend request
+	if backend.Value != nil {
+		fbt, err := i.getBackendProperty(backend.Value.Properties, "first_byte_timeout")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if fbt != nil {
+			firstByteTimeout = value.Unwrap[*value.RTime](fbt).Value
+		}
 	}
 
 	ctx, timeout := context.WithTimeout(i.ctx.Request.Context(), firstByteTimeout)
@@ -166,29 +183,54 @@ func (i *Interpreter) sendBackendRequest(backend *value.Backend) (*http.Response
 		return nil, errors.WithStack(err)
 	}
 
-	client := http.DefaultClient
-	if req.URL.Scheme == HTTPS_SCHEME {
-		client = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					ServerName: req.URL.Hostname(),
-				},
-			},
+	// Keep request body to record it
+	var reqBody []byte
+	if i.ctx.HARRecorder != nil && req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, errors.WithStack(err)
 		}
+		reqBody = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
 	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, exception.Runtime(nil, "Failed to retrieve backend response: %s", err)
+
+	started := time.Now()
+	var resp *http.Response
+	var err error
+	if i.ctx.HARReplayer != nil {
+		// Serve recorded response without network
+		resp, err = i.ctx.HARReplayer.Replay(req)
+		if err != nil {
+			return nil, exception.Runtime(nil, "Failed to replay backend response: %s", err)
+		}
+	} else {
+		client := http.DefaultClient
+		if req.URL.Scheme == HTTPS_SCHEME {
+			client = &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						ServerName: req.URL.Hostname(),
+					},
+				},
+			}
+		}
+		resp, err = client.Do(req)
+		if err != nil {
+			return nil, exception.Runtime(nil, "Failed to retrieve backend response: %s", err)
+		}
 	}
 
 	// Debug message
-	i.Debugger.Message(fmt.Sprintf("Backend (%s) responds status code %d", backend.Value.Name.Value, resp.StatusCode))
+	i.Debugger.Message(fmt.Sprintf("Backend (%s) responds status code %d", backend, resp.StatusCode))
 
 	// read all response body to suppress memory leak
 	var buf bytes.Buffer
 	if _, err = buf.ReadFrom(resp.Body); err != nil {
 		return nil, errors.WithStack(err)
 	}
+	if i.ctx.HARRecorder != nil {
+		i.ctx.HARRecorder.Record(req, reqBody, resp, buf.Bytes(), started)
+	}
 	resp.Body.Close()
 	resp.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
 	return resp, nil
@@ -214,6 +256,15 @@ func (i *Interpreter) getBackendProperty(props []*ast.BackendProperty, key strin
 	return val, nil
 }
 
+// cloneCachedResponse clones the response of cache item which may be read by concurrent requests
+func (i *Interpreter) cloneCachedResponse(item *cache.CacheItem) *http.Response {
+	var resp *http.Response
+	item.ReadResponse(func(r *http.Response) {
+		resp = i.cloneResponse(r)
+	})
+	return resp
+}
+
 func (i *Interpreter) cloneResponse(resp *http.Response) *http.Response {
 	// rewind body reader
 	var buf bytes.Buffer
@@ -0,0 +1,72 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/har"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestHARRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Origin", "recorded")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(pass);
+}
+`
+	path := filepath.Join(t.TempDir(), "backend.har")
+
+	send := func(option context.Option) *httptest.ResponseRecorder {
+		ip := New(
+			context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+			context.WithActualResponse(true),
+			option,
+		)
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/path?q=1", nil))
+		return rec
+	}
+
+	recorder := har.NewRecorder(path, "test")
+	if rec := send(context.WithHARRecorder(recorder)); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 on recording, got=%d", rec.Code)
+		return
+	}
+	if err := recorder.Flush(); err != nil {
+		t.Errorf("Unexpected flush error: %s", err)
+		return
+	}
+	// Backend is not reachable anymore, response must be served from the recording
+	server.Close()
+
+	rp, err := har.NewReplayer(path)
+	if err != nil {
+		t.Errorf("Unexpected replayer error: %s", err)
+		return
+	}
+	rec := send(context.WithHARReplayer(rp))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 on replaying, got=%d", rec.Code)
+	}
+	if v := rec.Header().Get("X-Origin"); v != "recorded" {
+		t.Errorf("X-Origin header expects recorded, got=%s", v)
+	}
+	if v := rec.Body.String(); v != "OK" {
+		t.Errorf("Body expects OK, got=%s", v)
+	}
+}
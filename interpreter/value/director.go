@@ -8,6 +8,7 @@ type DirectorConfig struct {
 	Key           string // only exists on chash
 	Seed          uint32 // only exists on chash
 	VNodesPerNode int    // only exists on chash
+	Shield        string // only exists on shield
 	Backends      []*DirectorConfigBackend
 }
 
@@ -16,3 +17,24 @@ type DirectorConfigBackend struct {
 	Id      string
 	Weight  int
 }
+
+// IsHealthy returns true when the director has healthy backends enough to reach the quorum weight
+func (d *DirectorConfig) IsHealthy() bool {
+	// Shield POP is always treated as healthy
+	if d.Type == "shield" {
+		return true
+	}
+	if len(d.Backends) == 0 {
+		return false
+	}
+	var healthy int
+	for _, v := range d.Backends {
+		if v.Backend.IsHealthy() {
+			healthy++
+		}
+	}
+	if healthy == 0 {
+		return false
+	}
+	return int((float64(healthy)/float64(len(d.Backends)))*100) >= d.Quorum
+}
@@ -243,7 +243,24 @@ func (v *Backend) String() string {
 func (v *Backend) Type() Type      { return BackendType }
 func (v *Backend) IsLiteral() bool { return v.Literal }
 func (v *Backend) Copy() Value {
-	return &Backend{Value: v.Value, Director: v.Director, Literal: v.Literal}
+	return &Backend{Value: v.Value, Director: v.Director, Literal: v.Literal, Healthy: v.Healthy}
+}
+
+// IsShield returns true if the backend is a shield director which forwards the request to the shield POP
+func (v *Backend) IsShield() bool {
+	return v.Director != nil && v.Director.Type == "shield"
+}
+
+// IsHealthy returns the backend health which is updated by health check probe.
+// Director is healthy when its backends reach the quorum weight
+func (v *Backend) IsHealthy() bool {
+	if v.Director != nil {
+		return v.Director.IsHealthy()
+	}
+	if v.Healthy == nil {
+		return true
+	}
+	return v.Healthy.Load()
 }
 
 type Acl struct {
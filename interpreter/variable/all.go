@@ -76,19 +76,39 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		CLIENT_CLASS_MASQUERADING,
 		CLIENT_CLASS_SPAM,
 		CLIENT_PLATFORM_MEDIAPLAYER,
-		REQ_BACKEND_IS_SHIELD,
 		REQ_IS_BACKGROUND_FETCH,
 		REQ_IS_CLUSTERING,
-		REQ_IS_ESI_SUBREQ,
-		RESP_STALE,
-		RESP_STALE_IS_ERROR,
-		RESP_STALE_IS_REVALIDATING,
 		WORKSPACE_OVERFLOWED:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
 		return &value.Boolean{Value: false}, nil
 
+	case REQ_BACKEND_IS_SHIELD:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		if v.ctx.Backend == nil {
+			return &value.Boolean{Value: false}, nil
+		}
+		return &value.Boolean{Value: v.ctx.Backend.IsShield()}, nil
+
+	case RESP_STALE:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return v.ctx.Stale, nil
+	case RESP_STALE_IS_ERROR:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return v.ctx.StaleIsError, nil
+	case RESP_STALE_IS_REVALIDATING:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return v.ctx.StaleIsRevalidating, nil
+
 	case CLIENT_DISPLAY_TOUCHSCREEN:
 		ua := uasurfer.Parse(req.Header.Get("User-Agent"))
 		isTouch := ua.DeviceType == uasurfer.DevicePhone ||
@@ -136,9 +156,12 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		}
 		return &value.String{Value: "|00|1:0:0:16|m,s,p,a"}, nil
 
-	// Backend is always healthy on simulator
+	// Backend health is updated by health check probe on simulator
 	case REQ_BACKEND_HEALTHY:
-		return &value.Boolean{Value: true}, nil
+		if v.ctx.Backend == nil {
+			return &value.Boolean{Value: false}, nil
+		}
+		return &value.Boolean{Value: v.ctx.Backend.IsHealthy()}, nil
 
 	case REQ_IS_SSL:
 		return &value.Boolean{Value: req.TLS != nil}, nil
@@ -152,11 +175,17 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v := v.getGeoIPValue(name); v != nil {
+			return v, nil
+		}
 		return &value.Float{Value: 37.7786941}, nil
 	case CLIENT_GEO_LONGITUDE:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v := v.getGeoIPValue(name); v != nil {
+			return v, nil
+		}
 		return &value.Float{Value: -122.3981452}, nil
 	case FASTLY_ERROR:
 		if v := lookupOverride(v.ctx, name); v != nil {
@@ -218,11 +247,17 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v := v.getGeoIPValue(name); v != nil {
+			return v, nil
+		}
 		return &value.Integer{Value: 4294967294}, nil
 	case CLIENT_AS_NAME:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v := v.getGeoIPValue(name); v != nil {
+			return v, nil
+		}
 		return &value.String{Value: "Reserved"}, nil
 
 	// Client display infos are unknown. Always returns -1
@@ -241,6 +276,9 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v := v.getGeoIPValue(name); v != nil {
+			return v, nil
+		}
 		return &value.Integer{Value: 0}, nil
 
 	// Alias of client.geo.utc_offset
@@ -276,15 +314,15 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 	case FASTLY_FF_VISITS_THIS_POP:
 		return &value.Integer{Value: 1}, nil
 
-	// Returns common value -- do not consider of clustering
+	// Count nodes in Fastly-FF header and the current node after the cache lookup -- do not consider of clustering
 	// see: https://developer.fastly.com/reference/vcl/variables/miscellaneous/fastly-ff-visits-this-service/
 	case FASTLY_FF_VISITS_THIS_SERVICE:
+		visits := countServiceVisits(req)
 		switch s {
 		case context.MissScope, context.HitScope, context.FetchScope:
-			return &value.Integer{Value: 1}, nil
-		default:
-			return &value.Integer{Value: 0}, nil
+			visits++
 		}
+		return &value.Integer{Value: int64(visits)}, nil
 
 	// Returns tentative value -- you may know your customer_id in the contraction :-)
 	case REQ_CUSTOMER_ID:
@@ -429,7 +467,6 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		CLIENT_GEO_COUNTRY_NAME_ASCII,
 		CLIENT_GEO_COUNTRY_NAME_LATIN1,
 		CLIENT_GEO_COUNTRY_NAME_UTF8,
-		CLIENT_GEO_IP_OVERRIDE,
 		CLIENT_GEO_POSTAL_CODE,
 		CLIENT_GEO_PROXY_DESCRIPTION,
 		CLIENT_GEO_PROXY_TYPE,
@@ -440,7 +477,15 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v := v.getGeoIPValue(name); v != nil {
+			return v, nil
+		}
 		return &value.String{Value: "unknown"}, nil
+	case CLIENT_GEO_IP_OVERRIDE:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return v.ctx.ClientGeoIpOverride, nil
 
 	case CLIENT_IDENTITY:
 		if v.ctx.ClientIdentity == nil {
@@ -556,7 +601,16 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 			id = FALCO_VIRTUAL_SERVICE_ID
 		}
 		return &value.String{Value: id}, nil
-	case REQ_TOPURL: // FIXME: what is the difference of req.url ?
+	case REQ_IS_ESI_SUBREQ:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return &value.Boolean{Value: v.ctx.ESILevel.Value > 0}, nil
+	case REQ_TOPURL:
+		// ESI subrequest returns the URL of top level request
+		if v.ctx.ESITopURL != "" {
+			return &value.String{Value: v.ctx.ESITopURL}, nil
+		}
 		u := req.URL.EscapedPath()
 		if v := req.URL.RawQuery; v != "" {
 			u += "?" + v
@@ -616,16 +670,25 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v.ctx.IsShieldNode {
+			return &value.String{Value: FALCO_SHIELD_DATACENTER}, nil
+		}
 		return &value.String{Value: FALCO_DATACENTER}, nil
 	case SERVER_HOSTNAME:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v.ctx.IsShieldNode {
+			return &value.String{Value: FALCO_SHIELD_SERVER_HOSTNAME}, nil
+		}
 		return &value.String{Value: FALCO_SERVER_HOSTNAME}, nil
 	case SERVER_IDENTITY:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if v.ctx.IsShieldNode {
+			return &value.String{Value: FALCO_SHIELD_SERVER_HOSTNAME}, nil
+		}
 		return &value.String{Value: FALCO_SERVER_HOSTNAME}, nil
 	case SERVER_REGION:
 		if v := lookupOverride(v.ctx, name); v != nil {
@@ -633,7 +696,7 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		}
 		return &value.String{Value: "US"}, nil
 	case STALE_EXISTS:
-		return v.ctx.StaleContents, nil
+		return v.ctx.StaleExists, nil
 	case TIME_ELAPSED_MSEC:
 		return &value.String{
 			Value: fmt.Sprint(time.Since(v.ctx.RequestStartTime).Milliseconds()),
@@ -714,23 +777,7 @@ func (v *AllScopeVariables) getFromRegex(name string) value.Value {
 
 	// Ratecounter variable matching
 	if match := rateCounterRegex.FindStringSubmatch(name); match != nil {
-		var val float64
-		// all ratecounter variable value returns 1.0 fixed value
-		switch match[1] {
-		case "rate_10s",
-			"rate_1s",
-			"rate.60s",
-			"bucket.10s",
-			"bucket.20s",
-			"bucket.30s",
-			"bucket.40s",
-			"bucket.50s",
-			"bucket.60s":
-			val = 1.0
-		}
-		return &value.Float{
-			Value: val,
-		}
+		return v.getRatecounterValue(match[1], match[2])
 	}
 
 	if match := backendConnectionsOpenRegex.FindStringSubmatch(name); match != nil {
@@ -742,12 +789,123 @@ func (v *AllScopeVariables) getFromRegex(name string) value.Value {
 	}
 
 	if match := backendHealthyRegex.FindStringSubmatch(name); match != nil {
-		return &value.Boolean{Value: true}
+		if b, ok := v.ctx.Backends[match[1]]; ok {
+			return &value.Boolean{Value: b.IsHealthy()}
+		}
+		return &value.Boolean{Value: false}
 	}
 
 	return nil
 }
 
+// getGeoIPValue resolves client.geo.* and client.as.* variables from GeoIP database.
+// Returns nil when database is not configured or the value is unknown
+func (v *AllScopeVariables) getGeoIPValue(name string) value.Value {
+	if v.ctx.GeoIP == nil {
+		return nil
+	}
+	addr := v.ctx.ClientGeoIpOverride.Value
+	if addr == "" {
+		addr = v.ctx.Request.RemoteAddr
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+	}
+	record, err := v.ctx.GeoIP.Lookup(net.ParseIP(addr))
+	if err != nil || record == nil {
+		return nil
+	}
+
+	str := func(s string) value.Value {
+		if s == "" {
+			return nil
+		}
+		return &value.String{Value: s}
+	}
+	integer := func(i *int64) value.Value {
+		if i == nil {
+			return nil
+		}
+		return &value.Integer{Value: *i}
+	}
+	float := func(f *float64) value.Value {
+		if f == nil {
+			return nil
+		}
+		return &value.Float{Value: *f}
+	}
+
+	switch name {
+	case CLIENT_GEO_CITY, CLIENT_GEO_CITY_ASCII, CLIENT_GEO_CITY_LATIN1, CLIENT_GEO_CITY_UTF8:
+		return str(record.City)
+	case CLIENT_GEO_CONN_SPEED:
+		return str(record.ConnSpeed)
+	case CLIENT_GEO_CONN_TYPE:
+		return str(record.ConnType)
+	case CLIENT_GEO_CONTINENT_CODE:
+		return str(record.ContinentCode)
+	case CLIENT_GEO_COUNTRY_CODE:
+		return str(record.CountryCode)
+	case CLIENT_GEO_COUNTRY_CODE3:
+		return str(record.CountryCode3)
+	case CLIENT_GEO_COUNTRY_NAME,
+		CLIENT_GEO_COUNTRY_NAME_ASCII,
+		CLIENT_GEO_COUNTRY_NAME_LATIN1,
+		CLIENT_GEO_COUNTRY_NAME_UTF8:
+		return str(record.CountryName)
+	case CLIENT_GEO_POSTAL_CODE:
+		return str(record.PostalCode)
+	case CLIENT_GEO_PROXY_DESCRIPTION:
+		return str(record.ProxyDescription)
+	case CLIENT_GEO_PROXY_TYPE:
+		return str(record.ProxyType)
+	case CLIENT_GEO_REGION, CLIENT_GEO_REGION_ASCII, CLIENT_GEO_REGION_LATIN1, CLIENT_GEO_REGION_UTF8:
+		return str(record.Region)
+	case CLIENT_GEO_LATITUDE:
+		return float(record.Latitude)
+	case CLIENT_GEO_LONGITUDE:
+		return float(record.Longitude)
+	case CLIENT_GEO_AREA_CODE:
+		return integer(record.AreaCode)
+	case CLIENT_GEO_METRO_CODE:
+		return integer(record.MetroCode)
+	case CLIENT_GEO_UTC_OFFSET:
+		return integer(record.UTCOffset)
+	case CLIENT_AS_NUMBER:
+		return integer(record.ASNumber)
+	case CLIENT_AS_NAME:
+		return str(record.ASName)
+	}
+	return nil
+}
+
+// Ratecounter variables reflect the entry which is most recently incremented in the request
+// see: https://developer.fastly.com/reference/vcl/variables/rate-limiting/
+func (v *AllScopeVariables) getRatecounterValue(name, field string) value.Value {
+	var window time.Duration
+	var isBucket bool
+	switch field {
+	case "bucket.10s", "bucket.20s", "bucket.30s", "bucket.40s", "bucket.50s", "bucket.60s":
+		window, _ = time.ParseDuration(strings.TrimPrefix(field, "bucket.")) // nolint: errcheck
+		isBucket = true
+	case "rate.1s", "rate.10s", "rate.60s":
+		window, _ = time.ParseDuration(strings.TrimPrefix(field, "rate.")) // nolint: errcheck
+	default:
+		return nil
+	}
+
+	var count int64
+	if rc, ok := v.ctx.Ratecounters[name]; ok {
+		if entry, ok := v.ctx.RatecounterEntries[name]; ok {
+			count = rc.Count(entry, window, v.ctx.Now())
+		}
+	}
+	if isBucket {
+		return &value.Integer{Value: count}
+	}
+	return &value.Float{Value: float64(count) / window.Seconds()}
+}
+
 func (v *AllScopeVariables) Set(s context.Scope, name, operator string, val value.Value) error {
 	switch strings.ToLower(name) {
 	case CLIENT_IDENTITY:
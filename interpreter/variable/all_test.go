@@ -3,6 +3,7 @@ package variable
 import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/geoip"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"net/http"
 	"net/url"
@@ -88,3 +89,54 @@ func TestReqUrl(t *testing.T) {
 		}
 	}
 }
+
+func TestGeoIPVariables(t *testing.T) {
+	db, err := geoip.Open("../geoip/testdata/geoip.csv", "../geoip/testdata/asn.csv")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	vars := &AllScopeVariables{
+		ctx: &context.Context{
+			Request:             &http.Request{RemoteAddr: "192.0.2.1:12345"},
+			ClientGeoIpOverride: &value.String{},
+			GeoIP:               db,
+		},
+	}
+
+	tests := []struct {
+		name   string
+		expect value.Value
+	}{
+		{name: "client.geo.country_code", expect: &value.String{Value: "JP"}},
+		{name: "client.geo.country_code3", expect: &value.String{Value: "JPN"}},
+		{name: "client.geo.city.ascii", expect: &value.String{Value: "Tokyo"}},
+		{name: "client.geo.latitude", expect: &value.Float{Value: 35.6895}},
+		{name: "client.geo.gmt_offset", expect: &value.Integer{Value: 900}},
+		{name: "client.as.number", expect: &value.Integer{Value: 64496}},
+		{name: "client.as.name", expect: &value.String{Value: "Example AS"}},
+		// Unknown value in the database returns default value
+		{name: "client.geo.postal_code", expect: &value.String{Value: "unknown"}},
+	}
+	for _, tt := range tests {
+		v, err := vars.Get(context.RecvScope, tt.name)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.expect, v); diff != "" {
+			t.Errorf("%s: value mismatch, diff=%s", tt.name, diff)
+		}
+	}
+
+	// client.geo.ip_override changes lookup address
+	vars.ctx.ClientGeoIpOverride = &value.String{Value: "2001:db8::1"}
+	v, err := vars.Get(context.RecvScope, "client.geo.country_code")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if diff := cmp.Diff(&value.String{Value: "DE"}, v); diff != "" {
+		t.Errorf("client.geo.country_code mismatch with ip_override, diff=%s", diff)
+	}
+}
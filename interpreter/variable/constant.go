@@ -11,6 +11,10 @@ const (
 	FALCO_VIRTUAL_SERVICE_ID = "falco-virtual-service-id"
 	FALCO_SERVER_HOSTNAME    = "cache-localsimulator"
 	FALCO_DATACENTER         = "FALCO"
+
+	// Identity of the shield node in multi-node simulation
+	FALCO_SHIELD_SERVER_HOSTNAME = "cache-shieldsimulator"
+	FALCO_SHIELD_DATACENTER      = "FALCO-SHIELD"
 )
 
 // Mapping from tls package ciphersuite name (IANA) to OpenSSL name
@@ -110,14 +110,18 @@ func (v *DeliverScopeVariables) Get(s context.Scope, name string) (value.Value,
 		return v.ctx.ObjectGrace, nil
 	case OBJ_HITS:
 		if v.ctx.CacheHitItem != nil {
-			return &value.Integer{Value: int64(v.ctx.CacheHitItem.Hits)}, nil
+			hits, _ := v.ctx.CacheHitItem.HitStats()
+			return &value.Integer{Value: int64(hits)}, nil
 		}
 		return &value.Integer{Value: 0}, nil
 	case OBJ_IS_PCI:
 		return &value.Boolean{Value: false}, nil // fixed value
+	case OBJ_IS_STALE:
+		return v.ctx.ObjectIsStale, nil
 	case OBJ_LASTUSE:
 		if v.ctx.CacheHitItem != nil {
-			return &value.RTime{Value: v.ctx.CacheHitItem.LastUsed}, nil
+			_, lastUsed := v.ctx.CacheHitItem.HitStats()
+			return &value.RTime{Value: lastUsed}, nil
 		}
 		return &value.RTime{Value: 0}, nil
 
@@ -0,0 +1,43 @@
+package variable
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+)
+
+// FastlyFF returns Fastly-FF header value which indicates the request passes through the node.
+// Shield node has different datacenter and hostname from the edge node.
+// see: https://www.fastly.com/documentation/reference/http/http-headers/Fastly-FF/#format
+func FastlyFF(ctx *context.Context) string {
+	datacenter, hostname := FALCO_DATACENTER, FALCO_SERVER_HOSTNAME
+	if ctx.IsShieldNode {
+		datacenter, hostname = FALCO_SHIELD_DATACENTER, FALCO_SHIELD_SERVER_HOSTNAME
+	}
+	return fmt.Sprintf("%s!%s!%s", fastlyServiceHash(), datacenter, hostname)
+}
+
+func fastlyServiceHash() string {
+	mac := hmac.New(sha256.New, []byte("falco"))
+	mac.Write([]byte(FALCO_VIRTUAL_SERVICE_ID))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// countServiceVisits counts Fastly nodes of this service which the request has passed through
+func countServiceVisits(req *http.Request) int {
+	hash := fastlyServiceHash() + "!"
+	var count int
+	for _, h := range req.Header.Values("Fastly-FF") {
+		for _, v := range strings.Split(h, ",") {
+			if strings.HasPrefix(strings.TrimSpace(v), hash) {
+				count++
+			}
+		}
+	}
+	return count
+}
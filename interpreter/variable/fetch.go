@@ -212,9 +212,9 @@ func (v *FetchScopeVariables) Get(s context.Scope, name string) (value.Value, er
 	case FASTLY_INFO_IS_CLUSTER_SHIELD:
 		return &value.Boolean{Value: false}, nil
 
-	// Always true because simulator could not simulate origin-shielding
+	// Backend is not an origin when the request is forwarded to the shield POP
 	case REQ_BACKEND_IS_ORIGIN:
-		return &value.Boolean{Value: true}, nil
+		return &value.Boolean{Value: v.ctx.Backend == nil || !v.ctx.Backend.IsShield()}, nil
 	// Digest ratio will return fixed value
 	case REQ_DIGEST_RATIO:
 		return &value.Float{Value: 0.4}, nil
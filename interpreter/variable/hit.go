@@ -45,14 +45,18 @@ func (v *HitScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		return v.ctx.ObjectGrace, nil
 	case OBJ_HITS:
 		if v.ctx.CacheHitItem != nil {
-			return &value.Integer{Value: int64(v.ctx.CacheHitItem.Hits)}, nil
+			hits, _ := v.ctx.CacheHitItem.HitStats()
+			return &value.Integer{Value: int64(hits)}, nil
 		}
 		return &value.Integer{Value: 0}, nil
 	case OBJ_IS_PCI:
 		return &value.Boolean{Value: false}, nil // fixed value
+	case OBJ_IS_STALE:
+		return v.ctx.ObjectIsStale, nil
 	case OBJ_LASTUSE:
 		if v.ctx.CacheHitItem != nil {
-			return &value.RTime{Value: v.ctx.CacheHitItem.LastUsed}, nil
+			_, lastUsed := v.ctx.CacheHitItem.HitStats()
+			return &value.RTime{Value: lastUsed}, nil
 		}
 		return &value.RTime{Value: 0}, nil
 	case OBJ_PROTO:
@@ -66,8 +70,7 @@ func (v *HitScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		// Return fixed value because we don't support SWR yet
-		return &value.RTime{Value: 60 * time.Second}, nil
+		return v.ctx.ObjectStaleWhileRevalidate, nil
 	case OBJ_STATUS:
 		return &value.Integer{Value: int64(v.ctx.Object.StatusCode)}, nil
 	case OBJ_TTL:
@@ -86,12 +86,12 @@ func (v *MissScopeVariables) Get(s context.Scope, name string) (value.Value, err
 		}
 		return &value.Boolean{Value: false}, nil
 
-	// We simulate request is always pass to the origin, not consider shielding
+	// Backend is not an origin when the request is forwarded to the shield POP
 	case REQ_BACKEND_IS_ORIGIN:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Boolean{Value: true}, nil
+		return &value.Boolean{Value: v.ctx.Backend == nil || !v.ctx.Backend.IsShield()}, nil
 	// Digest ratio will return fixed value
 	case REQ_DIGEST_RATIO:
 		if v := lookupOverride(v.ctx, name); v != nil {
@@ -246,6 +246,7 @@ const (
 	OBJ_GRACE                                  = "obj.grace"
 	OBJ_HITS                                   = "obj.hits"
 	OBJ_IS_PCI                                 = "obj.is_pci"
+	OBJ_IS_STALE                               = "obj.is_stale"
 	OBJ_LASTUSE                                = "obj.lastuse"
 	OBJ_PROTO                                  = "obj.proto"
 	OBJ_RESPONSE                               = "obj.response"
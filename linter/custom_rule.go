@@ -0,0 +1,233 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/context"
+)
+
+// CustomRule is Go-native linter rule which is registered by RegisterRule.
+// Unlike "falco-*" exec plugins which receive a single annotated statement,
+// custom rule is called once after builtin rules and could inspect whole VCL including included modules.
+type CustomRule interface {
+	// Rule name like "myteam/backend-prefix", it is used for severity configuration and ignore comments
+	Name() Rule
+	// Reference URL of the rule, it could be empty
+	Reference() string
+	// Lint whole VCL and report errors via RuleContext
+	Lint(rc *RuleContext)
+}
+
+var (
+	customRulesMu sync.RWMutex
+	customRules   = make(map[Rule]CustomRule)
+)
+
+// RegisterRule registers custom rules to all linters. Typically this is called in init() function
+// of the package which is linked into custom falco binary.
+// It panics if the rule name is empty, or conflicts with builtin or already registered rules
+// in the same manner as database/sql.Register.
+func RegisterRule(rs ...CustomRule) {
+	customRulesMu.Lock()
+	defer customRulesMu.Unlock()
+
+	for _, r := range rs {
+		name := r.Name()
+		if name == "" {
+			panic("linter: custom rule name must not be empty")
+		}
+		for _, v := range rules {
+			if v == name {
+				panic(fmt.Sprintf("linter: custom rule %s conflicts with builtin rule", name))
+			}
+		}
+		if _, ok := customRules[name]; ok {
+			panic(fmt.Sprintf("linter: custom rule %s is registered twice", name))
+		}
+		customRules[name] = r
+	}
+}
+
+// registeredRules returns registered custom rules sorted by name
+func registeredRules() []CustomRule {
+	customRulesMu.RLock()
+	defer customRulesMu.RUnlock()
+
+	rs := make([]CustomRule, 0, len(customRules))
+	for _, r := range customRules {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Name() < rs[j].Name()
+	})
+	return rs
+}
+
+// allRules returns builtin rules and registered custom rule names
+func allRules() []Rule {
+	names := make([]Rule, len(rules))
+	copy(names, rules)
+	for _, r := range registeredRules() {
+		names = append(names, r.Name())
+	}
+	return names
+}
+
+// RuleContext is passed to CustomRule.Lint with linted VCL and linter context
+type RuleContext struct {
+	// Root VCL which include statements are resolved to the module statements
+	VCL *ast.VCL
+	// Linter context which holds declarations like backends, tables, subroutines, etc
+	Context *context.Context
+
+	l    *Linter
+	rule CustomRule
+}
+
+// Subroutines returns all subroutine declarations including functional subroutines
+func (rc *RuleContext) Subroutines() []*ast.SubroutineDeclaration {
+	return rc.l.subroutines
+}
+
+// Report reports lint error with the rule name
+func (rc *RuleContext) Report(le *LintError) {
+	le.Rule = rc.rule.Name()
+	le.Reference = rc.rule.Reference()
+	rc.l.Error(le)
+}
+
+// Visitor is called for each node on RuleContext.Walk.
+// Returning false skips walking child nodes
+type Visitor func(node ast.Node) bool
+
+// Walk traverses all declarations, statements and expressions in the VCL with depth-first order
+func (rc *RuleContext) Walk(v Visitor) {
+	for _, stmt := range rc.VCL.Statements {
+		walkNode(stmt, v)
+	}
+}
+
+func walkNode(node ast.Node, v Visitor) {
+	if node == nil || !v(node) {
+		return
+	}
+
+	switch t := node.(type) {
+	case *ast.AclDeclaration:
+		walkNode(t.Name, v)
+	case *ast.BackendDeclaration:
+		walkNode(t.Name, v)
+	case *ast.DirectorDeclaration:
+		walkNode(t.Name, v)
+	case *ast.TableDeclaration:
+		walkNode(t.Name, v)
+	case *ast.PenaltyboxDeclaration:
+		walkNode(t.Name, v)
+	case *ast.RatecounterDeclaration:
+		walkNode(t.Name, v)
+	case *ast.SubroutineDeclaration:
+		walkNode(t.Name, v)
+		walkNode(t.Block, v)
+	case *ast.BlockStatement:
+		for _, stmt := range t.Statements {
+			walkNode(stmt, v)
+		}
+	case *ast.IfStatement:
+		walkNode(t.Condition, v)
+		walkNode(t.Consequence, v)
+		for _, another := range t.Another {
+			walkNode(another, v)
+		}
+		if t.Alternative != nil {
+			walkNode(t.Alternative, v)
+		}
+	case *ast.ElseStatement:
+		walkNode(t.Consequence, v)
+	case *ast.SwitchStatement:
+		walkNode(t.Control, v)
+		for _, c := range t.Cases {
+			walkNode(c, v)
+		}
+	case *ast.SwitchControl:
+		walkNode(t.Expression, v)
+	case *ast.CaseStatement:
+		if t.Test != nil {
+			walkNode(t.Test, v)
+		}
+		for _, stmt := range t.Statements {
+			walkNode(stmt, v)
+		}
+	case *ast.DeclareStatement:
+		walkNode(t.Name, v)
+	case *ast.SetStatement:
+		walkNode(t.Ident, v)
+		walkNode(t.Value, v)
+	case *ast.AddStatement:
+		walkNode(t.Ident, v)
+		walkNode(t.Value, v)
+	case *ast.UnsetStatement:
+		walkNode(t.Ident, v)
+	case *ast.RemoveStatement:
+		walkNode(t.Ident, v)
+	case *ast.CallStatement:
+		walkNode(t.Subroutine, v)
+	case *ast.FunctionCallStatement:
+		walkNode(t.Function, v)
+		for _, arg := range t.Arguments {
+			walkNode(arg, v)
+		}
+	case *ast.ErrorStatement:
+		if t.Code != nil {
+			walkNode(t.Code, v)
+		}
+		if t.Argument != nil {
+			walkNode(t.Argument, v)
+		}
+	case *ast.LogStatement:
+		walkNode(t.Value, v)
+	case *ast.SyntheticStatement:
+		walkNode(t.Value, v)
+	case *ast.SyntheticBase64Statement:
+		walkNode(t.Value, v)
+	case *ast.ReturnStatement:
+		if t.ReturnExpression != nil {
+			walkNode(t.ReturnExpression, v)
+		}
+	case *ast.GroupedExpression:
+		walkNode(t.Right, v)
+	case *ast.PrefixExpression:
+		walkNode(t.Right, v)
+	case *ast.PostfixExpression:
+		walkNode(t.Left, v)
+	case *ast.InfixExpression:
+		walkNode(t.Left, v)
+		walkNode(t.Right, v)
+	case *ast.IfExpression:
+		walkNode(t.Condition, v)
+		walkNode(t.Consequence, v)
+		walkNode(t.Alternative, v)
+	case *ast.FunctionCallExpression:
+		walkNode(t.Function, v)
+		for _, arg := range t.Arguments {
+			walkNode(arg, v)
+		}
+	}
+}
+
+// lintCustomRules runs registered Go-native custom rules against the whole VCL
+func (l *Linter) lintCustomRules(ctx *context.Context) {
+	if l.vcl == nil {
+		return
+	}
+	for _, r := range registeredRules() {
+		r.Lint(&RuleContext{
+			VCL:     l.vcl,
+			Context: ctx,
+			l:       l,
+			rule:    r,
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package linter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+type backendPrefixRule struct{}
+
+func (r *backendPrefixRule) Name() Rule        { return "test/backend-prefix" }
+func (r *backendPrefixRule) Reference() string { return "" }
+func (r *backendPrefixRule) Lint(rc *RuleContext) {
+	rc.Walk(func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.BackendDeclaration:
+			if !strings.HasPrefix(t.Name.Value, "F_") {
+				rc.Report(&LintError{
+					Severity: WARNING,
+					Token:    t.GetMeta().Token,
+					Message:  "Backend name must start with F_",
+				})
+			}
+			return false
+		case *ast.SubroutineDeclaration:
+			// Backend declarations are never placed in the subroutine
+			return false
+		}
+		return true
+	})
+}
+
+func registerTestRule(t *testing.T, r CustomRule) {
+	RegisterRule(r)
+	t.Cleanup(func() {
+		customRulesMu.Lock()
+		delete(customRules, r.Name())
+		customRulesMu.Unlock()
+	})
+}
+
+func TestCustomRule(t *testing.T) {
+	registerTestRule(t, &backendPrefixRule{})
+
+	input := `
+backend F_origin {
+	.host = "example.com";
+}
+backend origin {
+	.host = "example.com";
+}
+sub vcl_recv {
+	#FASTLY recv
+	if (req.http.Foo) {
+		set req.backend = F_origin;
+	} else {
+		set req.backend = origin;
+	}
+}`
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		return
+	}
+
+	l := New(&config.LinterConfig{})
+	l.Lint(vcl, context.New())
+
+	var errs []*LintError
+	for _, e := range l.Errors {
+		if e.Rule == "test/backend-prefix" {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) != 1 {
+		t.Errorf("Expect 1 error but got %d: %v", len(errs), errs)
+		return
+	}
+	if errs[0].Token.Line != 5 {
+		t.Errorf("Expect error on line 5 but got %d", errs[0].Token.Line)
+	}
+}
+
+func TestCustomRuleIgnoreComment(t *testing.T) {
+	registerTestRule(t, &backendPrefixRule{})
+
+	input := `
+# falco-ignore-next-line test/backend-prefix
+backend origin {
+	.host = "example.com";
+}
+backend other {
+	.host = "example.com";
+} # falco-ignore
+# falco-ignore-start test/backend-prefix
+backend another {
+	.host = "example.com";
+}
+# falco-ignore-end test/backend-prefix
+sub vcl_recv {
+	#FASTLY recv
+	if (req.http.Foo) {
+		set req.backend = origin;
+	} else if (req.http.Bar) {
+		set req.backend = other;
+	} else {
+		set req.backend = another;
+	}
+}`
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		return
+	}
+
+	l := New(&config.LinterConfig{})
+	l.Lint(vcl, context.New())
+
+	for _, e := range l.Errors {
+		if e.Rule == "test/backend-prefix" {
+			t.Errorf("Expect error to be ignored but reported on line %d", e.Token.Line)
+		}
+	}
+}
+
+func TestCustomRuleSeverityOverrides(t *testing.T) {
+	registerTestRule(t, &backendPrefixRule{})
+
+	s, err := NewSeverityOverrides(&config.LinterConfig{
+		Rules: map[string]string{"test/*": "error"},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	le := (&LintError{Severity: WARNING}).Match("test/backend-prefix")
+	if got := s.Severity(le, "main.vcl"); got != ERROR {
+		t.Errorf("Unexpected severity, expect=%s, got=%s", ERROR, got)
+	}
+}
+
+func TestRegisterRuleConflict(t *testing.T) {
+	registerTestRule(t, &backendPrefixRule{})
+
+	tests := []struct {
+		name string
+		rule CustomRule
+	}{
+		{name: "registered twice", rule: &backendPrefixRule{}},
+		{name: "conflicts with builtin rule", rule: &builtinConflictRule{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic but not")
+				}
+			}()
+			RegisterRule(tt.rule)
+		})
+	}
+}
+
+type builtinConflictRule struct{}
+
+func (r *builtinConflictRule) Name() Rule           { return UNUSED_DECLARATION }
+func (r *builtinConflictRule) Reference() string    { return "" }
+func (r *builtinConflictRule) Lint(rc *RuleContext) {}
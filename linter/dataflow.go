@@ -0,0 +1,531 @@
+package linter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/context"
+)
+
+// Execution order of Fastly state-machine subroutines in a request.
+// Subroutines which have the same order never run in the same request without restart.
+// vcl_pass is ordered after vcl_hit and vcl_miss because return(pass) in them moves to vcl_pass
+// ref: https://developer.fastly.com/learning/vcl/using/#the-vcl-request-lifecycle
+var subroutinePhases = map[string]int{
+	"vcl_recv":    0,
+	"vcl_hash":    1,
+	"vcl_hit":     2,
+	"vcl_miss":    2,
+	"vcl_pass":    3,
+	"vcl_fetch":   4,
+	"vcl_error":   5,
+	"vcl_deliver": 6,
+	"vcl_log":     7,
+}
+
+// Header prefixes which are tracked by the data-flow analysis
+var flowHeaderPrefixes = []string{
+	"req.http.",
+	"bereq.http.",
+	"beresp.http.",
+	"resp.http.",
+	"obj.http.",
+}
+
+type flowEventKind int
+
+const (
+	flowRead flowEventKind = iota
+	flowSet
+	flowUnset
+	flowExit
+)
+
+// flowEvent represents a single access to the variable in the subroutine flow.
+// depth is the nest level of conditional blocks, zero means the event always happens when the flow reaches it.
+type flowEvent struct {
+	kind  flowEventKind
+	key   string
+	ident *ast.Ident
+	depth int
+}
+
+// flow is the ordered events of the state-machine subroutine
+// that the called subroutines are expanded into.
+type flow struct {
+	name   string
+	phase  int
+	events []flowEvent
+}
+
+type flowBuilder struct {
+	subroutines map[string]*ast.SubroutineDeclaration
+	visiting    map[string]struct{}
+	events      []flowEvent
+	hasRestart  bool
+}
+
+func newFlowBuilder(subroutines map[string]*ast.SubroutineDeclaration) *flowBuilder {
+	return &flowBuilder{
+		subroutines: subroutines,
+		visiting:    make(map[string]struct{}),
+	}
+}
+
+// flowKey normalizes variable name for comparison.
+// HTTP header names are case-insensitive and subfield access is treated as header access.
+func flowKey(name string) string {
+	for _, prefix := range flowHeaderPrefixes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if idx := strings.Index(name[len(prefix):], ":"); idx != -1 {
+			name = name[:len(prefix)+idx]
+		}
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+func isFlowHeader(key string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *flowBuilder) add(kind flowEventKind, ident *ast.Ident, depth int) {
+	e := flowEvent{kind: kind, ident: ident, depth: depth}
+	if ident != nil {
+		e.key = flowKey(ident.Value)
+	}
+	b.events = append(b.events, e)
+}
+
+func (b *flowBuilder) subroutine(decl *ast.SubroutineDeclaration, depth int) {
+	if _, ok := b.visiting[decl.Name.Value]; ok {
+		return
+	}
+	b.visiting[decl.Name.Value] = struct{}{}
+	defer delete(b.visiting, decl.Name.Value)
+
+	b.statements(decl.Block.Statements, depth)
+}
+
+func (b *flowBuilder) statements(stmts []ast.Statement, depth int) {
+	for _, stmt := range stmts {
+		b.statement(stmt, depth)
+	}
+}
+
+// nolint: gocognit
+func (b *flowBuilder) statement(stmt ast.Statement, depth int) {
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		b.statements(t.Statements, depth)
+	case *ast.SetStatement:
+		b.expression(t.Value, depth)
+		// Compound assignment like "+=" reads the current value
+		if t.Operator.Operator != "=" {
+			b.add(flowRead, t.Ident, depth)
+		}
+		b.add(flowSet, t.Ident, depth)
+	case *ast.AddStatement:
+		b.expression(t.Value, depth)
+		b.add(flowSet, t.Ident, depth)
+	case *ast.UnsetStatement:
+		b.add(flowUnset, t.Ident, depth)
+	case *ast.RemoveStatement:
+		b.add(flowUnset, t.Ident, depth)
+	case *ast.IfStatement:
+		b.expression(t.Condition, depth)
+		b.statements(t.Consequence.Statements, depth+1)
+		for _, another := range t.Another {
+			b.expression(another.Condition, depth+1)
+			b.statements(another.Consequence.Statements, depth+1)
+		}
+		if t.Alternative != nil {
+			b.statements(t.Alternative.Consequence.Statements, depth+1)
+		}
+	case *ast.SwitchStatement:
+		b.expression(t.Control.Expression, depth)
+		for _, c := range t.Cases {
+			b.statements(c.Statements, depth+1)
+		}
+	case *ast.CallStatement:
+		if decl, ok := b.subroutines[t.Subroutine.Value]; ok {
+			b.subroutine(decl, depth)
+		}
+	case *ast.FunctionCallStatement:
+		for _, arg := range t.Arguments {
+			b.expression(arg, depth)
+		}
+	case *ast.LogStatement:
+		b.expression(t.Value, depth)
+	case *ast.SyntheticStatement:
+		b.expression(t.Value, depth)
+	case *ast.SyntheticBase64Statement:
+		b.expression(t.Value, depth)
+	case *ast.ReturnStatement:
+		if t.ReturnExpression != nil {
+			b.expression(t.ReturnExpression, depth)
+		}
+		b.add(flowExit, nil, depth)
+	case *ast.ErrorStatement:
+		if t.Code != nil {
+			b.expression(t.Code, depth)
+		}
+		if t.Argument != nil {
+			b.expression(t.Argument, depth)
+		}
+		b.add(flowExit, nil, depth)
+	case *ast.RestartStatement:
+		b.hasRestart = true
+		b.add(flowExit, nil, depth)
+	case *ast.GotoStatement:
+		b.add(flowExit, nil, depth)
+	}
+}
+
+func (b *flowBuilder) expression(expr ast.Expression, depth int) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		b.add(flowRead, t, depth)
+	case *ast.GroupedExpression:
+		b.expression(t.Right, depth)
+	case *ast.PrefixExpression:
+		b.expression(t.Right, depth)
+	case *ast.PostfixExpression:
+		b.expression(t.Left, depth)
+	case *ast.InfixExpression:
+		b.expression(t.Left, depth)
+		b.expression(t.Right, depth)
+	case *ast.IfExpression:
+		b.expression(t.Condition, depth)
+		b.expression(t.Consequence, depth+1)
+		b.expression(t.Alternative, depth+1)
+	case *ast.FunctionCallExpression:
+		for _, arg := range t.Arguments {
+			b.expression(arg, depth)
+		}
+		// Functional subroutine is expanded as well as call statement
+		if decl, ok := b.subroutines[t.Function.Value]; ok {
+			b.subroutine(decl, depth)
+		}
+	}
+}
+
+// lintDataFlow analyzes variable accesses across subroutines.
+// Called subroutines are expanded into each state-machine subroutine and then
+// the flows are compared in the Fastly execution order.
+func (l *Linter) lintDataFlow(ctx *context.Context) {
+	subroutines := make(map[string]*ast.SubroutineDeclaration)
+	var names []string
+	for _, decl := range l.subroutines {
+		name := decl.Name.Value
+		exists, ok := subroutines[name]
+		if !ok {
+			subroutines[name] = decl
+			names = append(names, name)
+			continue
+		}
+		// Duplicated Fastly subroutines are concatenated in the declared order as Fastly does
+		// ref: https://developer.fastly.com/reference/vcl/subroutines/#concatenation
+		if context.IsFastlySubroutine(name) {
+			subroutines[name] = concatSubroutine(exists, decl)
+		}
+	}
+
+	var flows []*flow
+	var hasRestart bool
+	for name := range ctx.Subroutines {
+		phase, ok := subroutinePhases[name]
+		if !ok {
+			continue
+		}
+		decl, ok := subroutines[name]
+		if !ok {
+			continue
+		}
+		b := newFlowBuilder(subroutines)
+		b.subroutine(decl, 0)
+		flows = append(flows, &flow{name: name, phase: phase, events: b.events})
+		if b.hasRestart {
+			hasRestart = true
+		}
+	}
+	// Sort by execution order to report errors in stable order
+	sort.Slice(flows, func(i, j int) bool {
+		if flows[i].phase != flows[j].phase {
+			return flows[i].phase < flows[j].phase
+		}
+		return flows[i].name < flows[j].name
+	})
+
+	l.lintHeaderReadBeforeSet(flows, hasRestart)
+	l.lintRemovedHeaderSet(flows)
+
+	// Dead assignment check is done in each subroutine because local variable does not live over the subroutine
+	for _, name := range names {
+		l.lintDeadAssignment(subroutines[name].Block.Statements)
+	}
+}
+
+// concatSubroutine returns new subroutine which has the statements of both subroutines.
+// Declarations are not modified because they are also used by other lint rules
+func concatSubroutine(a, b *ast.SubroutineDeclaration) *ast.SubroutineDeclaration {
+	sub := *a
+	sub.Block = &ast.BlockStatement{
+		Meta:       a.Block.Meta,
+		Statements: append(append([]ast.Statement{}, a.Block.Statements...), b.Block.Statements...),
+	}
+	return &sub
+}
+
+func (l *Linter) lintHeaderReadBeforeSet(flows []*flow, hasRestart bool) {
+	// Collect all set headers and phases
+	setPhases := make(map[string][]int)
+	setNames := make(map[string]string)
+	for _, f := range flows {
+		for _, e := range f.events {
+			if e.kind != flowSet || !isFlowHeader(e.key, "req.http.") {
+				continue
+			}
+			setPhases[e.key] = append(setPhases[e.key], f.phase)
+			if _, ok := setNames[e.key]; !ok {
+				setNames[e.key] = e.ident.Value
+			}
+		}
+	}
+
+	reported := make(map[*ast.Ident]struct{})
+	for _, f := range flows {
+		set := make(map[string]struct{})
+		for _, e := range f.events {
+			if e.kind == flowSet {
+				set[e.key] = struct{}{}
+			}
+			if e.kind != flowRead || !isFlowHeader(e.key, "req.http.") {
+				continue
+			}
+			if _, ok := reported[e.ident]; ok {
+				continue
+			}
+			if _, ok := set[e.key]; ok {
+				continue
+			}
+
+			phases, ok := setPhases[e.key]
+			if !ok {
+				// Header is never set in VCL so it may come from the client.
+				// But if the similar name is set, it seems to be a typo
+				if similar := findSimilarHeader(e.key, setNames); similar != "" {
+					reported[e.ident] = struct{}{}
+					l.Error(MisspelledHeader(e.ident.GetMeta(), e.ident.Value, similar).Match(DATAFLOW_MISSPELLED_HEADER))
+				}
+				continue
+			}
+			// Header could be set on the previous request flow before restart
+			if hasRestart {
+				continue
+			}
+			upstream := false
+			for _, p := range phases {
+				if p < f.phase {
+					upstream = true
+					break
+				}
+			}
+			// Header may be set later in the same subroutine, typically initialize if not present
+			if upstream || isSetAfter(f.events, e) {
+				continue
+			}
+			reported[e.ident] = struct{}{}
+			l.Error(HeaderReadBeforeSet(e.ident.GetMeta(), e.ident.Value).Match(DATAFLOW_READ_BEFORE_SET))
+		}
+	}
+}
+
+func isSetAfter(events []flowEvent, read flowEvent) bool {
+	found := false
+	for _, e := range events {
+		if e.ident == read.ident && e.kind == flowRead {
+			found = true
+			continue
+		}
+		if found && e.kind == flowSet && e.key == read.key {
+			return true
+		}
+	}
+	return false
+}
+
+func findSimilarHeader(key string, names map[string]string) string {
+	name := strings.TrimPrefix(key, "req.http.")
+	if len(name) < 5 {
+		return ""
+	}
+
+	var candidates []string
+	for k := range names {
+		n := strings.TrimPrefix(k, "req.http.")
+		if len(n) < 5 {
+			continue
+		}
+		if d := editDistance(name, n); d > 0 && d <= 2 {
+			candidates = append(candidates, k)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Strings(candidates)
+	return names[candidates[0]]
+}
+
+// editDistance returns levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func (l *Linter) lintRemovedHeaderSet(flows []*flow) {
+	reported := make(map[*ast.Ident]struct{})
+	for _, f := range flows {
+		for i, e := range f.events {
+			if e.kind != flowSet || !isFlowHeader(e.key, "bereq.http.", "beresp.http.") {
+				continue
+			}
+			if _, ok := reported[e.ident]; ok {
+				continue
+			}
+		SCAN:
+			for _, next := range f.events[i+1:] {
+				switch next.kind {
+				case flowExit:
+					break SCAN
+				case flowRead:
+					if next.key == e.key {
+						break SCAN
+					}
+				case flowUnset:
+					if next.key != e.key {
+						continue
+					}
+					// Conditional unset does not always remove the header
+					if next.depth > 0 {
+						break SCAN
+					}
+					reported[e.ident] = struct{}{}
+					l.Error(RemovedHeaderSet(e.ident.GetMeta(), e.ident.Value, next.ident).Match(DATAFLOW_REMOVED_HEADER_SET))
+					break SCAN
+				}
+			}
+		}
+	}
+}
+
+// lintDeadAssignment finds local variable assignment which is overwritten before it is read.
+// Nested blocks are checked separately because they may not be executed.
+func (l *Linter) lintDeadAssignment(stmts []ast.Statement) {
+	assigned := make(map[string]*ast.SetStatement)
+
+	for _, stmt := range stmts {
+		// Statement reads or jumps make the previous assignments alive
+		b := newFlowBuilder(nil)
+		b.statement(stmt, 0)
+		for _, e := range b.events {
+			if e.kind == flowRead {
+				delete(assigned, e.key)
+			}
+		}
+
+		switch t := stmt.(type) {
+		case *ast.SetStatement:
+			if !strings.HasPrefix(t.Ident.Value, "var.") {
+				continue
+			}
+			// Compound assignment already read the previous value above
+			if prev, ok := assigned[t.Ident.Value]; ok && t.Operator.Operator == "=" {
+				l.Error(DeadAssignment(prev.GetMeta(), prev.Ident.Value).Match(DATAFLOW_DEAD_ASSIGNMENT))
+			}
+			assigned[t.Ident.Value] = t
+		case *ast.GotoStatement, *ast.GotoDestinationStatement:
+			assigned = make(map[string]*ast.SetStatement)
+		case *ast.BlockStatement:
+			l.lintDeadAssignment(t.Statements)
+		case *ast.IfStatement:
+			if hasGotoStatement(t) {
+				assigned = make(map[string]*ast.SetStatement)
+			}
+			l.lintDeadAssignment(t.Consequence.Statements)
+			for _, another := range t.Another {
+				l.lintDeadAssignment(another.Consequence.Statements)
+			}
+			if t.Alternative != nil {
+				l.lintDeadAssignment(t.Alternative.Consequence.Statements)
+			}
+		case *ast.SwitchStatement:
+			if hasGotoStatement(t) {
+				assigned = make(map[string]*ast.SetStatement)
+			}
+			for _, c := range t.Cases {
+				l.lintDeadAssignment(c.Statements)
+			}
+		}
+	}
+}
+
+func hasGotoStatement(stmt ast.Statement) bool {
+	var walk func(stmts []ast.Statement) bool
+	walk = func(stmts []ast.Statement) bool {
+		for _, s := range stmts {
+			switch t := s.(type) {
+			case *ast.GotoStatement:
+				return true
+			case *ast.BlockStatement:
+				if walk(t.Statements) {
+					return true
+				}
+			case *ast.IfStatement:
+				if walk(t.Consequence.Statements) {
+					return true
+				}
+				for _, another := range t.Another {
+					if walk(another.Consequence.Statements) {
+						return true
+					}
+				}
+				if t.Alternative != nil && walk(t.Alternative.Consequence.Statements) {
+					return true
+				}
+			case *ast.SwitchStatement:
+				for _, c := range t.Cases {
+					if walk(c.Statements) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	return walk([]ast.Statement{stmt})
+}
@@ -0,0 +1,348 @@
+package linter
+
+import (
+	"testing"
+)
+
+func TestLintHeaderReadBeforeSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect int
+	}{
+		{
+			name: "set in upstream subroutine",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	set req.http.X-Device = "mobile";
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	set resp.http.X-Device = req.http.X-Device;
+}`,
+			expect: 0,
+		},
+		{
+			name: "set in hit subroutine before pass",
+			input: `
+sub vcl_hit {
+	#FASTLY hit
+	set req.http.X-Hit-Pass = "1";
+	return(pass);
+}
+sub vcl_pass {
+	#FASTLY pass
+	set bereq.http.X-Hit-Pass = req.http.X-Hit-Pass;
+}`,
+			expect: 0,
+		},
+		{
+			name: "set only in sibling subroutine",
+			input: `
+sub vcl_hit {
+	#FASTLY hit
+	set req.http.X-Hit = "1";
+}
+sub vcl_miss {
+	#FASTLY miss
+	set bereq.http.X-Hit = req.http.X-Hit;
+}`,
+			expect: 1,
+		},
+		{
+			name: "set in called subroutine",
+			input: `
+sub set_device_recv {
+	set req.http.X-Device = "mobile";
+}
+sub vcl_recv {
+	#FASTLY recv
+	call set_device_recv;
+	if (req.http.x-device == "mobile") {
+		esi;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "set only in downstream subroutine",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	if (req.http.X-Device == "mobile") {
+		esi;
+	}
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	set req.http.X-Device = "mobile";
+}`,
+			expect: 1,
+		},
+		{
+			name: "set in downstream subroutine with restart",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	if (req.http.X-Device == "mobile") {
+		esi;
+	}
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	if (resp.status == 404) {
+		set req.http.X-Device = "mobile";
+		restart;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "read in the first of concatenated subroutines",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	if (req.http.X-Device == "mobile") {
+		esi;
+	}
+}
+sub vcl_recv {
+	set req.http.X-Recv = "1";
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	set req.http.X-Device = "mobile";
+}`,
+			expect: 1,
+		},
+		{
+			name: "ignored by comment",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	# falco-ignore-next-line dataflow/read-before-set
+	if (req.http.X-Device == "mobile") {
+		esi;
+	}
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	set req.http.X-Device = "mobile";
+}`,
+			expect: 0,
+		},
+		{
+			name: "initialized if not present",
+			input: `
+sub vcl_fetch {
+	#FASTLY fetch
+	if (!req.http.X-Device) {
+		set req.http.X-Device = "desktop";
+	}
+}`,
+			expect: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := lintRuleErrors(t, testConfig, tt.input, DATAFLOW_READ_BEFORE_SET)
+			if len(errs) != tt.expect {
+				t.Errorf("Expect %d errors but got %d: %v", tt.expect, len(errs), errs)
+			}
+		})
+	}
+}
+
+func TestLintMisspelledHeader(t *testing.T) {
+	t.Run("raise misspelled error", func(t *testing.T) {
+		input := `
+sub vcl_recv {
+	#FASTLY recv
+	set req.http.X-Country-Code = client.geo.country_code;
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	set resp.http.X-Country = req.http.X-Contry-Code;
+}`
+		errs := lintRuleErrors(t, testConfig, input, DATAFLOW_MISSPELLED_HEADER)
+		if len(errs) != 1 {
+			t.Errorf("Expect one error but got %d: %v", len(errs), errs)
+			t.FailNow()
+		}
+		if errs[0].Token.Line != 8 {
+			t.Errorf("Unexpected error line, expect=8, got=%d", errs[0].Token.Line)
+		}
+	})
+
+	t.Run("pass client header", func(t *testing.T) {
+		input := `
+sub vcl_recv {
+	#FASTLY recv
+	set req.http.X-Country-Code = client.geo.country_code;
+	if (req.http.User-Agent ~ "bot") {
+		esi;
+	}
+}`
+		errs := lintRuleErrors(t, testConfig, input, DATAFLOW_MISSPELLED_HEADER)
+		if len(errs) != 0 {
+			t.Errorf("Expect no errors but got %d: %v", len(errs), errs)
+		}
+	})
+}
+
+func TestLintDeadAssignment(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect int
+	}{
+		{
+			name: "overwritten before used",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	declare local var.S STRING;
+	set var.S = "foo";
+	set var.S = "bar";
+	set req.http.X-Value = var.S;
+}`,
+			expect: 1,
+		},
+		{
+			name: "read between assignments",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	declare local var.S STRING;
+	set var.S = "foo";
+	set req.http.X-Value = var.S;
+	set var.S = "bar";
+	set req.http.X-Value = var.S;
+}`,
+			expect: 0,
+		},
+		{
+			name: "compound assignment reads the value",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	declare local var.S STRING;
+	set var.S = "foo";
+	set var.S += "bar";
+	set var.S = "baz";
+	set req.http.X-Value = var.S;
+}`,
+			expect: 1,
+		},
+		{
+			name: "conditional assignment",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	declare local var.S STRING;
+	set var.S = "foo";
+	if (req.http.Foo) {
+		set var.S = "bar";
+	}
+	set req.http.X-Value = var.S;
+}`,
+			expect: 0,
+		},
+		{
+			name: "goto skips assignment",
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	declare local var.S STRING;
+	set var.S = "foo";
+	if (req.http.Foo) {
+		goto done;
+	}
+	set var.S = "bar";
+	done:
+	set req.http.X-Value = var.S;
+}`,
+			expect: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := lintRuleErrors(t, testConfig, tt.input, DATAFLOW_DEAD_ASSIGNMENT)
+			if len(errs) != tt.expect {
+				t.Errorf("Expect %d errors but got %d: %v", tt.expect, len(errs), errs)
+			}
+		})
+	}
+}
+
+func TestLintRemovedHeaderSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect int
+	}{
+		{
+			name: "removed in called subroutine",
+			input: `
+sub cleanup_fetch {
+	unset beresp.http.X-Debug;
+}
+sub vcl_fetch {
+	#FASTLY fetch
+	set beresp.http.X-Debug = "1";
+	call cleanup_fetch;
+}`,
+			expect: 1,
+		},
+		{
+			name: "read before removed",
+			input: `
+sub vcl_fetch {
+	#FASTLY fetch
+	set beresp.http.X-Debug = "1";
+	if (beresp.http.X-Debug) {
+		set beresp.ttl = 0s;
+	}
+	unset beresp.http.X-Debug;
+}`,
+			expect: 0,
+		},
+		{
+			name: "conditionally removed",
+			input: `
+sub vcl_miss {
+	#FASTLY miss
+	set bereq.http.X-Debug = "1";
+	if (req.http.Foo) {
+		unset bereq.http.X-Debug;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "return before removed",
+			input: `
+sub vcl_miss {
+	#FASTLY miss
+	set bereq.http.X-Debug = "1";
+	if (req.http.Foo) {
+		return(fetch);
+	}
+	unset bereq.http.X-Debug;
+}`,
+			expect: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := lintRuleErrors(t, testConfig, tt.input, DATAFLOW_REMOVED_HEADER_SET)
+			if len(errs) != tt.expect {
+				t.Errorf("Expect %d errors but got %d: %v", tt.expect, len(errs), errs)
+			}
+		})
+	}
+}
@@ -379,6 +379,7 @@ func (l *Linter) lintSubRoutineDeclaration(decl *ast.SubroutineDeclaration, ctx
 	}()
 
 	l.lint(decl.Block, cc)
+	l.subroutines = append(l.subroutines, decl)
 
 	// We are done linting inside the previous scope so
 	// we dont need the return type anymore
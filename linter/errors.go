@@ -26,6 +26,7 @@ type LintError struct {
 	Message   string
 	Reference string
 	Rule      Rule
+	Fix       Fix `json:"-"`
 }
 
 func (l *LintError) Match(r Rule) *LintError {
@@ -39,6 +40,11 @@ func (e *LintError) Ref(url string) *LintError {
 	return e
 }
 
+func (e *LintError) WithFix(f Fix) *LintError {
+	e.Fix = f
+	return e
+}
+
 func (e *LintError) Error() string {
 	var rule, ref, file string
 
@@ -326,6 +332,93 @@ func UnusedVariable(m *ast.Meta, name string) *LintError {
 	}
 }
 
+func HeaderReadBeforeSet(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Header "%s" is read before it is set in the request flow`, name),
+	}
+}
+
+func MisspelledHeader(m *ast.Meta, name, similar string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Header "%s" is read but never set, did you mean "%s"?`, name, similar),
+	}
+}
+
+func DeadAssignment(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Assigned value to "%s" is overwritten before it is used`, name),
+	}
+}
+
+func RemovedHeaderSet(m *ast.Meta, name string, removed *ast.Ident) *LintError {
+	line := removed.GetMeta().Token.Line
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Header "%s" is set but always removed at line %d before it is used`, name, line),
+	}
+}
+
+func URLRegexBypass(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message: fmt.Sprintf(
+			`Regex matching against raw "%s" can be bypassed by percent-encoding or case variation, match against normalized URL like std.tolower(urldecode(%s))`,
+			name, name,
+		),
+	}
+}
+
+func HostNotNormalized(m *ast.Meta) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  "Host header is compared without normalization, set lowercased value by std.tolower() before routing",
+	}
+}
+
+func MissingShieldCheck(m *ast.Meta, name, client string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message: fmt.Sprintf(
+			`"%s" is set from "%s" without checking req.http.Fastly-FF, the value is overwritten by the edge POP information on the shield POP`,
+			name, client,
+		),
+	}
+}
+
+func DebugHeaderLeak(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Debug header "%s" is exposed to any client, restrict it by client.ip ACL matching`, name),
+	}
+}
+
+func UnsafeSynthetic(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: ERROR,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Synthetic response contains unescaped user input "%s", escape it by json.escape(), xml_escape() or urlencode()`, name),
+	}
+}
+
+func UncheckedBackendURL(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: ERROR,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Backend URL is built from user input "%s" which is not validated by regex matching`, name),
+	}
+}
+
 func NonEmptyPenaltyboxBlock(m *ast.Meta, name string) *LintError {
 	return &LintError{
 		Severity: ERROR,
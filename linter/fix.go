@@ -0,0 +1,147 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/token"
+)
+
+// Fix is a machine-applicable fix which is attached to LintError.
+// Linter mutates AST while linting (e.g. embedding snippets), so fix must not keep AST node pointers.
+// Instead, fix finds the target node by token position from the freshly parsed VCL of the file
+type Fix interface {
+	// Apply fix to the VCL, return true if the VCL is modified
+	Apply(vcl *ast.VCL) bool
+	String() string
+}
+
+// removeStatementFix removes the statement which is placed at the token position.
+// This fix is used for unused declarations and unused local variables
+type removeStatementFix struct {
+	token token.Token
+	name  string
+}
+
+func (f *removeStatementFix) String() string {
+	return "Remove unused " + f.name
+}
+
+func (f *removeStatementFix) Apply(vcl *ast.VCL) bool {
+	var removed bool
+	vcl.Statements, _, removed = removeStatement(vcl.Statements, f.token)
+	return removed
+}
+
+// Remove the statement from the statements recursively.
+// Fastly boilerplate macros in leading comments of the removed statement must be kept,
+// so they are moved to the next statement, or returned when the removed statement is the last one
+func removeStatement(statements []ast.Statement, t token.Token) ([]ast.Statement, ast.Comments, bool) {
+	for i, stmt := range statements {
+		if isSamePosition(stmt.GetMeta().Token, t) {
+			macros := boilerplateMacros(stmt.GetMeta().Leading)
+			if i+1 < len(statements) {
+				// Next statement takes over the spacing of removed statement
+				next := statements[i+1].GetMeta()
+				next.PreviousEmptyLines = stmt.GetMeta().PreviousEmptyLines
+				next.Leading = append(macros, next.Leading...)
+				macros = nil
+			}
+			return append(statements[:i:i], statements[i+1:]...), macros, true
+		}
+
+		var removed bool
+		switch s := stmt.(type) {
+		case *ast.SubroutineDeclaration:
+			removed = removeStatementInBlock(s.Block, t)
+		case *ast.BlockStatement:
+			removed = removeStatementInBlock(s, t)
+		case *ast.IfStatement:
+			removed = removeStatementInIf(s, t)
+		case *ast.SwitchStatement:
+			for _, c := range s.Cases {
+				if c.Statements, _, removed = removeStatement(c.Statements, t); removed {
+					break
+				}
+			}
+		}
+		if removed {
+			return statements, nil, true
+		}
+	}
+	return statements, nil, false
+}
+
+func removeStatementInBlock(block *ast.BlockStatement, t token.Token) bool {
+	var macros ast.Comments
+	var removed bool
+	block.Statements, macros, removed = removeStatement(block.Statements, t)
+	if len(macros) > 0 {
+		block.Infix = append(macros, block.Infix...)
+	}
+	return removed
+}
+
+func removeStatementInIf(stmt *ast.IfStatement, t token.Token) bool {
+	if removeStatementInBlock(stmt.Consequence, t) {
+		return true
+	}
+	for _, another := range stmt.Another {
+		if removeStatementInIf(another, t) {
+			return true
+		}
+	}
+	if stmt.Alternative != nil {
+		return removeStatementInBlock(stmt.Alternative.Consequence, t)
+	}
+	return false
+}
+
+func boilerplateMacros(comments ast.Comments) ast.Comments {
+	var macros ast.Comments
+	for _, c := range comments {
+		if strings.HasPrefix(c.String(), "#FASTLY ") {
+			macros = append(macros, c)
+		}
+	}
+	return macros
+}
+
+// boilerplateMacroFix adds Fastly boilerplate macro comment like "#FASTLY RECV"
+// at the beginning of the subroutine which is placed at the token position
+type boilerplateMacroFix struct {
+	token token.Token
+	scope string
+}
+
+func (f *boilerplateMacroFix) String() string {
+	return fmt.Sprintf(`Add "#FASTLY %s" boilerplate macro`, strings.ToUpper(f.scope))
+}
+
+func (f *boilerplateMacroFix) Apply(vcl *ast.VCL) bool {
+	for _, stmt := range vcl.Statements {
+		sub, ok := stmt.(*ast.SubroutineDeclaration)
+		if !ok || !isSamePosition(sub.GetMeta().Token, f.token) {
+			continue
+		}
+		macro := &ast.Comment{
+			Token:            token.Token{Type: token.COMMENT},
+			Value:            "#FASTLY " + strings.ToUpper(f.scope),
+			PrefixedLineFeed: true,
+		}
+		if len(sub.Block.Statements) == 0 {
+			// Block infix comments are placed at the end of block
+			sub.Block.Infix = append(sub.Block.Infix, macro)
+		} else {
+			first := sub.Block.Statements[0].GetMeta()
+			first.Leading = append(ast.Comments{macro}, first.Leading...)
+		}
+		return true
+	}
+	return false
+}
+
+func isSamePosition(a, b token.Token) bool {
+	return a.Line == b.Line && a.Position == b.Position
+}
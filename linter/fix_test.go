@@ -0,0 +1,145 @@
+package linter
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/formatter"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+func assertFixed(t *testing.T, input, expect string) {
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		t.FailNow()
+	}
+	l := New(testConfig)
+	l.Lint(vcl, context.New())
+
+	// Fixes must be applied to the freshly parsed VCL
+	vcl, err = parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		t.FailNow()
+	}
+	for _, le := range l.Errors {
+		if le.Fix == nil {
+			continue
+		}
+		if !le.Fix.Apply(vcl) {
+			t.Errorf("Fix %s could not be applied", le.Fix)
+		}
+	}
+
+	formatted, err := io.ReadAll(formatter.New(&config.FormatConfig{
+		IndentWidth:                2,
+		IndentStyle:                "space",
+		LineWidth:                  120,
+		ReturnStatementParenthesis: true,
+	}).Format(vcl))
+	if err != nil {
+		t.Errorf("unexpected format error: %s", err)
+		t.FailNow()
+	}
+	if diff := cmp.Diff(expect, string(formatted)); diff != "" {
+		t.Errorf("Fixed result mismatch, diff=%s", diff)
+	}
+}
+
+func TestFixUnusedDeclaration(t *testing.T) {
+	input := `
+table unused_table {
+  "foo": "bar",
+}
+
+acl unused_acl {
+  "127.0.0.1";
+}
+
+sub unused_recv {
+  set req.http.Foo = "bar";
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  return(lookup);
+}
+`
+	expect := `sub vcl_recv {
+#FASTLY RECV
+  return(lookup);
+}
+`
+	assertFixed(t, input, expect)
+}
+
+func TestFixUnusedVariable(t *testing.T) {
+	t.Run("remove unused variable", func(t *testing.T) {
+		input := `
+sub vcl_recv {
+  #FASTLY RECV
+  declare local var.used STRING;
+  declare local var.unused STRING;
+  set var.used = "foo";
+  if (req.http.Foo) {
+    declare local var.nested INTEGER;
+    set req.http.Bar = var.used;
+  }
+}
+`
+		expect := `sub vcl_recv {
+#FASTLY RECV
+  declare local var.used STRING;
+  set var.used = "foo";
+  if (req.http.Foo) {
+    set req.http.Bar = var.used;
+  }
+}
+`
+		assertFixed(t, input, expect)
+	})
+
+	t.Run("boilerplate macro is kept", func(t *testing.T) {
+		input := `
+sub vcl_recv {
+  # comment for variable
+  #FASTLY RECV
+  declare local var.unused STRING;
+  set req.http.Foo = "bar";
+}
+`
+		expect := `sub vcl_recv {
+#FASTLY RECV
+  set req.http.Foo = "bar";
+}
+`
+		assertFixed(t, input, expect)
+	})
+}
+
+func TestFixBoilerplateMacro(t *testing.T) {
+	input := `
+sub vcl_recv {
+  set req.http.Foo = "bar";
+}
+
+sub vcl_deliver {
+}
+`
+	expect := `sub vcl_recv {
+#FASTLY RECV
+  set req.http.Foo = "bar";
+}
+
+
+sub vcl_deliver {
+#FASTLY DELIVER
+}
+`
+	assertFixed(t, input, expect)
+}
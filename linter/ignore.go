@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/token"
 )
 
 // ignore signatures
@@ -25,6 +26,11 @@ type ignore struct {
 	ignoreNextLine ignoredRules
 	ignoreThisLine ignoredRules
 	ignoreRange    ignoredRules
+
+	// Ignored rules of each statement line, key is filename and line number.
+	// Some rules like data-flow analysis report errors after walking AST,
+	// then the errors are ignored by the recorded state of the reported line
+	lines map[string]map[int]*ignoredRules
 }
 
 type ignoredRules struct {
@@ -180,3 +186,43 @@ func (i *ignore) IsEnable(rule Rule) bool {
 		i.ignoreThisLine.rules[rule] ||
 		i.ignoreRange.rules[rule]
 }
+
+// Record current ignore state for the statement line.
+// When multiple statements are placed on the same line, ignored rules are merged
+func (i *ignore) Record(tok token.Token) {
+	if i.lines == nil {
+		i.lines = make(map[string]map[int]*ignoredRules)
+	}
+	if _, ok := i.lines[tok.File]; !ok {
+		i.lines[tok.File] = make(map[int]*ignoredRules)
+	}
+	r, ok := i.lines[tok.File][tok.Line]
+	if !ok {
+		r = &ignoredRules{rules: make(map[Rule]bool)}
+		i.lines[tok.File][tok.Line] = r
+	}
+	for _, v := range []ignoredRules{i.ignoreNextLine, i.ignoreThisLine, i.ignoreRange} {
+		r.all = r.all || v.all
+		for rule, ok := range v.rules {
+			if ok {
+				r.rules[rule] = true
+			}
+		}
+	}
+}
+
+// IsEnableAt returns true if the rule is ignored at the recorded statement line.
+// The token may be placed in the middle of multi-line statement
+// so the nearest statement line which is not after the token is used
+func (i *ignore) IsEnableAt(rule Rule, tok token.Token) bool {
+	lines, ok := i.lines[tok.File]
+	if !ok {
+		return false
+	}
+	for line := tok.Line; line > 0; line-- {
+		if r, ok := lines[line]; ok {
+			return r.all || r.rules[rule]
+		}
+	}
+	return false
+}
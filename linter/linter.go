@@ -21,6 +21,11 @@ type Linter struct {
 	lexers     map[string]*lexer.Lexer
 	ignore     *ignore
 	conf       *config.LinterConfig
+
+	// Linted subroutine declarations which are used for the data-flow analysis
+	subroutines []*ast.SubroutineDeclaration
+	// Root VCL which include statements are resolved, used for custom rules
+	vcl *ast.VCL
 }
 
 func New(c *config.LinterConfig, opts ...optionFunc) *Linter {
@@ -39,9 +44,14 @@ func (l *Linter) Lexers() map[string]*lexer.Lexer {
 	return l.lexers
 }
 
+// Subroutines returns all linted subroutine declarations including ones in the included modules
+func (l *Linter) Subroutines() []*ast.SubroutineDeclaration {
+	return l.subroutines
+}
+
 func (l *Linter) Error(err error) {
 	if le, ok := err.(*LintError); ok {
-		if !l.ignore.IsEnable(le.Rule) {
+		if !l.ignore.IsEnable(le.Rule) && !l.ignore.IsEnableAt(le.Rule, le.Token) {
 			l.Errors = append(l.Errors, le)
 		}
 	} else {
@@ -71,6 +81,13 @@ func (l *Linter) Lint(node ast.Node, ctx *context.Context) types.Type {
 	l.lintUnusedPenaltyboxes(ctx)
 	l.lintUnusedRatecounters(ctx)
 
+	// Then analyze data-flow across subroutines
+	l.lintDataFlow(ctx)
+	l.lintSecurity()
+
+	// Finally, run Go-native custom rules which are registered by RegisterRule
+	l.lintCustomRules(ctx)
+
 	return types.NeverType
 }
 
@@ -82,7 +99,9 @@ func (l *Linter) lintUnusedTables(ctx *context.Context) {
 		if t.Decl == nil {
 			l.Error(UnusedExternalDeclaration(key, "table").Match(UNUSED_DECLARATION))
 		} else {
-			l.Error(UnusedDeclaration(t.Decl.GetMeta(), t.Name, "table").Match(UNUSED_DECLARATION))
+			l.Error(UnusedDeclaration(t.Decl.GetMeta(), t.Name, "table").Match(UNUSED_DECLARATION).WithFix(
+				&removeStatementFix{token: t.Decl.GetMeta().Token, name: "table " + t.Name},
+			))
 		}
 	}
 }
@@ -95,7 +114,9 @@ func (l *Linter) lintUnusedAcls(ctx *context.Context) {
 		if a.Decl == nil {
 			l.Error(UnusedExternalDeclaration(key, "acl").Match(UNUSED_DECLARATION))
 		} else {
-			l.Error(UnusedDeclaration(a.Decl.GetMeta(), a.Decl.Name.Value, "acl").Match(UNUSED_DECLARATION))
+			l.Error(UnusedDeclaration(a.Decl.GetMeta(), a.Decl.Name.Value, "acl").Match(UNUSED_DECLARATION).WithFix(
+				&removeStatementFix{token: a.Decl.GetMeta().Token, name: "acl " + a.Decl.Name.Value},
+			))
 		}
 	}
 }
@@ -108,14 +129,18 @@ func (l *Linter) lintUnusedBackends(ctx *context.Context) {
 		if b.DirectorDecl != nil {
 			// Check director is used
 			if !ctx.Directors[b.DirectorDecl.Name.Value].IsUsed {
-				l.Error(UnusedDeclaration(b.DirectorDecl.GetMeta(), b.DirectorDecl.Name.Value, "director").Match(UNUSED_DECLARATION))
+				l.Error(UnusedDeclaration(b.DirectorDecl.GetMeta(), b.DirectorDecl.Name.Value, "director").Match(UNUSED_DECLARATION).WithFix(
+					&removeStatementFix{token: b.DirectorDecl.GetMeta().Token, name: "director " + b.DirectorDecl.Name.Value},
+				))
 			}
 			continue
 		}
 		if b.BackendDecl == nil {
 			l.Error(UnusedExternalDeclaration(key, "backend").Match(UNUSED_DECLARATION))
 		} else {
-			l.Error(UnusedDeclaration(b.BackendDecl.GetMeta(), b.BackendDecl.Name.Value, "backend").Match(UNUSED_DECLARATION))
+			l.Error(UnusedDeclaration(b.BackendDecl.GetMeta(), b.BackendDecl.Name.Value, "backend").Match(UNUSED_DECLARATION).WithFix(
+				&removeStatementFix{token: b.BackendDecl.GetMeta().Token, name: "backend " + b.BackendDecl.Name.Value},
+			))
 		}
 	}
 }
@@ -133,7 +158,9 @@ func (l *Linter) lintUnusedSubroutines(ctx *context.Context) {
 		if isIgnoredSubroutineInConfig(l.conf.IgnoreSubroutines, s.Decl.Name.Value) {
 			continue
 		}
-		l.Error(UnusedDeclaration(s.Decl.GetMeta(), s.Decl.Name.Value, "subroutine").Match(UNUSED_DECLARATION))
+		l.Error(UnusedDeclaration(s.Decl.GetMeta(), s.Decl.Name.Value, "subroutine").Match(UNUSED_DECLARATION).WithFix(
+			&removeStatementFix{token: s.Decl.GetMeta().Token, name: "subroutine " + s.Decl.Name.Value},
+		))
 	}
 }
 
@@ -142,7 +169,9 @@ func (l *Linter) lintUnusedPenaltyboxes(ctx *context.Context) {
 		if p.IsUsed {
 			continue
 		}
-		l.Error(UnusedDeclaration(p.Decl.GetMeta(), p.Decl.Name.Value, "penaltybox").Match(UNUSED_DECLARATION))
+		l.Error(UnusedDeclaration(p.Decl.GetMeta(), p.Decl.Name.Value, "penaltybox").Match(UNUSED_DECLARATION).WithFix(
+			&removeStatementFix{token: p.Decl.GetMeta().Token, name: "penaltybox " + p.Decl.Name.Value},
+		))
 	}
 }
 
@@ -151,7 +180,9 @@ func (l *Linter) lintUnusedRatecounters(ctx *context.Context) {
 		if rc.IsUsed {
 			continue
 		}
-		l.Error(UnusedDeclaration(rc.Decl.GetMeta(), rc.Decl.Name.Value, "ratecounter").Match(UNUSED_DECLARATION))
+		l.Error(UnusedDeclaration(rc.Decl.GetMeta(), rc.Decl.Name.Value, "ratecounter").Match(UNUSED_DECLARATION).WithFix(
+			&removeStatementFix{token: rc.Decl.GetMeta().Token, name: "ratecounter " + rc.Decl.Name.Value},
+		))
 	}
 }
 
@@ -165,7 +196,9 @@ func (l *Linter) lintUnusedVariables(ctx *context.Context) {
 		if o.IsUsed {
 			continue
 		}
-		l.Error(UnusedVariable(o.Meta, k).Match(UNUSED_VARIABLE))
+		l.Error(UnusedVariable(o.Meta, k).Match(UNUSED_VARIABLE).WithFix(
+			&removeStatementFix{token: o.Meta.Token, name: "variable var." + k},
+		))
 	}
 }
 
@@ -301,6 +334,7 @@ func (l *Linter) lint(node ast.Node, ctx *context.Context) types.Type {
 func (l *Linter) lintVCL(vcl *ast.VCL, ctx *context.Context) types.Type {
 	// Resolve module, snippet inclusion
 	statements := l.resolveIncludeStatements(vcl.Statements, ctx, true)
+	l.vcl = &ast.VCL{Statements: statements}
 
 	// https://github.com/ysugimoto/falco/issues/50
 	// To support subroutine hoisting, add root statements to context firstly and lint each statements after that.
@@ -318,6 +352,7 @@ func (l *Linter) lintStatement(s ast.Statement, ctx *context.Context) {
 	// Any statements may have ignoring comments so we do setup and teardown
 	l.ignore.SetupStatement(s.GetMeta())
 	defer l.ignore.TeardownStatement(s.GetMeta())
+	l.ignore.Record(s.GetMeta().Token)
 	l.lint(s, ctx)
 }
 
@@ -609,5 +644,7 @@ func (l *Linter) lintFastlyBoilerPlateMacro(sub *ast.SubroutineDeclaration, ctx
 			`Subroutine "%s" is missing Fastly boilerplate comment "#FASTLY %s" inside definition`, sub.Name.Value, strings.ToUpper(scope),
 		),
 	}
-	l.Error(err.Match(SUBROUTINE_BOILERPLATE_MACRO))
+	l.Error(err.Match(SUBROUTINE_BOILERPLATE_MACRO).WithFix(
+		&boilerplateMacroFix{token: sub.GetMeta().Token, scope: scope},
+	))
 }
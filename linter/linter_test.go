@@ -3,6 +3,8 @@ package linter
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/ysugimoto/falco/ast"
@@ -87,6 +89,27 @@ func assertErrorWithSeverity(t *testing.T, input string, severity Severity, opts
 	}
 }
 
+// lintRuleErrors lints whole VCL including the rules which run after walking AST,
+// and returns the errors of the rule
+func lintRuleErrors(t *testing.T, conf *config.LinterConfig, input string, rule Rule) []*LintError {
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		t.FailNow()
+	}
+
+	l := New(conf)
+	l.Lint(vcl, context.New())
+
+	var errs []*LintError
+	for _, e := range l.Errors {
+		if e.Rule == rule {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
 func TestLintStuff(t *testing.T) {
 
 	tests := []struct {
@@ -877,3 +900,20 @@ sub vcl_pipe {}
 `
 	assertError(t, input)
 }
+
+func TestRuleDocuments(t *testing.T) {
+	doc, err := os.ReadFile("../docs/rules.md")
+	if err != nil {
+		t.Errorf("Failed to read rules document: %s", err)
+		return
+	}
+	for _, r := range rules {
+		if r.Description() == "" {
+			t.Errorf("Rule %s does not have description", r)
+		}
+		// Rules which do not have reference are linked to the section of rules document
+		if r.Reference() == "" && !strings.Contains(string(doc), "\n## "+string(r)+"\n") {
+			t.Errorf("Rule %s is not described in rules document", r)
+		}
+	}
+}
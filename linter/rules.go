@@ -1,5 +1,7 @@
 package linter
 
+import "strings"
+
 type Rule string
 
 func (r Rule) Reference() string {
@@ -9,6 +11,24 @@ func (r Rule) Reference() string {
 	return ""
 }
 
+// Description returns short description of the builtin rule, empty for unknown rules
+func (r Rule) Description() string {
+	if v, ok := descriptions[r]; ok {
+		return v
+	}
+	return ""
+}
+
+// DocumentURL returns the URL of the builtin rule section in falco rules document, empty for unknown rules
+func (r Rule) DocumentURL() string {
+	if _, ok := descriptions[r]; !ok {
+		return ""
+	}
+	return rulesDocumentURL + "#" + strings.ReplaceAll(string(r), "/", "")
+}
+
+const rulesDocumentURL = "https://github.com/ysugimoto/falco/blob/main/docs/rules.md"
+
 const (
 	ACL_SYNTAX                           = "acl/syntax"
 	ACL_DUPLICATED                       = "acl/duplicated"
@@ -72,8 +92,94 @@ const (
 	FORBIDDEN_BACKWARD_JUMP              = "goto/forbidden-backward-jump"
 	TIME_CALCULATION                     = "operator/time-calculation"
 	DEPRECATED                           = "deprecated"
+	DATAFLOW_READ_BEFORE_SET             = "dataflow/read-before-set"
+	DATAFLOW_MISSPELLED_HEADER           = "dataflow/misspelled-header"
+	DATAFLOW_DEAD_ASSIGNMENT             = "dataflow/dead-assignment"
+	DATAFLOW_REMOVED_HEADER_SET          = "dataflow/removed-header-set"
+	SECURITY_URL_REGEX_BYPASS            = "security/url-regex-bypass"
+	SECURITY_HOST_NORMALIZATION          = "security/host-normalization"
+	SECURITY_SHIELD_FASTLY_FF            = "security/shield-fastly-ff"
+	SECURITY_DEBUG_HEADER_LEAK           = "security/debug-header-leak"
+	SECURITY_SYNTHETIC_INJECTION         = "security/synthetic-injection"
+	SECURITY_UNCHECKED_BEREQ_URL         = "security/unchecked-bereq-url"
 )
 
+// All known rules, used for validating rule names in the configuration
+var rules = []Rule{
+	ACL_SYNTAX,
+	ACL_DUPLICATED,
+	BACKEND_SYNTAX,
+	BACKEND_DUPLICATED,
+	BACKEND_NOTFOUND,
+	BACKEND_PROBER_CONFIGURATION,
+	DIRECTOR_SYNTAX,
+	DIRECTOR_DUPLICATED,
+	DIRECTOR_PROPS_RANDOM,
+	DIRECTOR_PROPS_FALLBACK,
+	DIRECTOR_PROPS_HASH,
+	DIRECTOR_PROPS_CLIENT,
+	DIRECTOR_PROPS_CHASH,
+	DIRECTOR_BACKEND_REQUIRED,
+	TABLE_SYNTAX,
+	TABLE_TYPE_VARIATION,
+	TABLE_ITEM_LIMITATION,
+	TABLE_DUPLICATED,
+	SUBROUTINE_SYNTAX,
+	SUBROUTINE_BOILERPLATE_MACRO,
+	SUBROUTINE_DUPLICATED,
+	SUBROUTINE_INVALID_RETURN_TYPE,
+	UNRECOGNIZE_CALL_SCOPE,
+	FORBID_VCL_PIPE,
+	PENALTYBOX_SYNTAX,
+	PENALTYBOX_DUPLICATED,
+	PENALTYBOX_NONEMPTY_BLOCK,
+	RATECOUNTER_SYNTAX,
+	RATECOUNTER_DUPLICATED,
+	RATECOUNTER_NONEMPTY_BLOCK,
+	DECLARE_STATEMENT_SYNTAX,
+	DECLARE_STATEMENT_INVALID_TYPE,
+	DECLARE_STATEMENT_DUPLICATED,
+	SET_STATEMENT_SYNTAX,
+	OPERATOR_ASSIGNMENT,
+	UNSET_STATEMENT_SYNTAX,
+	REMOVE_STATEMENT_SYNTAX,
+	OPERATOR_CONDITIONAL,
+	RESTART_STATEMENT_SCOPE,
+	ADD_STATEMENT_SYNTAX,
+	CALL_STATEMENT_SYNTAX,
+	CALL_STATEMENT_SUBROUTINE_NOTFOUND,
+	ERROR_STATEMENT_SCOPE,
+	ERROR_STATEMENT_CODE,
+	SYNTHETIC_STATEMENT_SCOPE,
+	SYNTHETIC_BASE64_STATEMENT_SCOPE,
+	GOTO_DUPLICATED,
+	GOTO_SYNTAX,
+	CONDITION_LITERAL,
+	VALID_IP,
+	FUNCTION_ARGUMENTS,
+	FUNCTION_ARGUMENT_TYPE,
+	INCLUDE_STATEMENT_MODULE_NOT_FOUND,
+	INCLUDE_STATEMENT_MODULE_LOAD_FAILED,
+	REGEX_MATCHED_VALUE_MAY_OVERRIDE,
+	UNUSED_DECLARATION,
+	UNUSED_VARIABLE,
+	UNUSED_GOTO,
+	DISALLOW_EMPTY_RETURN,
+	FORBIDDEN_BACKWARD_JUMP,
+	TIME_CALCULATION,
+	DEPRECATED,
+	DATAFLOW_READ_BEFORE_SET,
+	DATAFLOW_MISSPELLED_HEADER,
+	DATAFLOW_DEAD_ASSIGNMENT,
+	DATAFLOW_REMOVED_HEADER_SET,
+	SECURITY_URL_REGEX_BYPASS,
+	SECURITY_HOST_NORMALIZATION,
+	SECURITY_SHIELD_FASTLY_FF,
+	SECURITY_DEBUG_HEADER_LEAK,
+	SECURITY_SYNTHETIC_INJECTION,
+	SECURITY_UNCHECKED_BEREQ_URL,
+}
+
 var references = map[Rule]string{
 	ACL_SYNTAX:                       "https://developer.fastly.com/reference/vcl/declarations/acl/",
 	BACKEND_SYNTAX:                   "https://developer.fastly.com/reference/vcl/declarations/backend/",
@@ -110,3 +216,78 @@ var references = map[Rule]string{
 	UNRECOGNIZE_CALL_SCOPE:           "https://github.com/ysugimoto/falco/blob/main/docs/linter.md#user-defined-subroutine",
 	FORBIDDEN_BACKWARD_JUMP:          "https://fiddle.fastly.dev/fiddle/4814c144",
 }
+
+var descriptions = map[Rule]string{
+	ACL_SYNTAX:                           "Syntax error on ACL declaration",
+	ACL_DUPLICATED:                       "ACL is declared more than once",
+	BACKEND_SYNTAX:                       "Syntax error on backend declaration",
+	BACKEND_DUPLICATED:                   "Backend is declared more than once",
+	BACKEND_NOTFOUND:                     "Backend is not found in director or req.backend",
+	BACKEND_PROBER_CONFIGURATION:         "Backend probe .initial property should be less than .threshold property",
+	DIRECTOR_SYNTAX:                      "Syntax error on director declaration",
+	DIRECTOR_DUPLICATED:                  "Director is declared more than once",
+	DIRECTOR_PROPS_RANDOM:                "Required property is not declared on random director",
+	DIRECTOR_PROPS_FALLBACK:              "Required property is not declared on fallback director",
+	DIRECTOR_PROPS_HASH:                  "Required property is not declared on hash director",
+	DIRECTOR_PROPS_CLIENT:                "Required property is not declared on client director",
+	DIRECTOR_PROPS_CHASH:                 "Required property is not declared on chash director",
+	DIRECTOR_BACKEND_REQUIRED:            "Director must have at least one backend",
+	TABLE_SYNTAX:                         "Syntax error on table declaration",
+	TABLE_TYPE_VARIATION:                 "Table value type is not supported",
+	TABLE_ITEM_LIMITATION:                "Table has more items than Fastly allows",
+	TABLE_DUPLICATED:                     "Table is declared more than once",
+	SUBROUTINE_SYNTAX:                    "Syntax error on subroutine declaration",
+	SUBROUTINE_BOILERPLATE_MACRO:         "Fastly boilerplate macro is missing in reserved subroutine",
+	SUBROUTINE_DUPLICATED:                "Subroutine is declared more than once",
+	SUBROUTINE_INVALID_RETURN_TYPE:       "Subroutine has invalid return type",
+	UNRECOGNIZE_CALL_SCOPE:               "Scope of user-defined subroutine could not be recognized",
+	FORBID_VCL_PIPE:                      "User-defined vcl_pipe subroutine may cause unexpected behavior on Fastly",
+	PENALTYBOX_SYNTAX:                    "Syntax error on penaltybox declaration",
+	PENALTYBOX_DUPLICATED:                "Penaltybox is declared more than once",
+	PENALTYBOX_NONEMPTY_BLOCK:            "Penaltybox declaration block must be empty",
+	RATECOUNTER_SYNTAX:                   "Syntax error on ratecounter declaration",
+	RATECOUNTER_DUPLICATED:               "Ratecounter is declared more than once",
+	RATECOUNTER_NONEMPTY_BLOCK:           "Ratecounter declaration block must be empty",
+	DECLARE_STATEMENT_SYNTAX:             "Syntax error on declare statement",
+	DECLARE_STATEMENT_INVALID_TYPE:       "Local variable type is invalid",
+	DECLARE_STATEMENT_DUPLICATED:         "Local variable is declared more than once",
+	SET_STATEMENT_SYNTAX:                 "Syntax error on set statement",
+	OPERATOR_ASSIGNMENT:                  "Operator could not be used in assignment expression",
+	UNSET_STATEMENT_SYNTAX:               "Syntax error on unset statement",
+	REMOVE_STATEMENT_SYNTAX:              "Syntax error on remove statement",
+	OPERATOR_CONDITIONAL:                 "Conditional operator is used for unexpected type",
+	RESTART_STATEMENT_SCOPE:              "Restart statement is used in invalid scope",
+	ADD_STATEMENT_SYNTAX:                 "Syntax error on add statement",
+	CALL_STATEMENT_SYNTAX:                "Syntax error on call statement",
+	CALL_STATEMENT_SUBROUTINE_NOTFOUND:   "Called subroutine is not defined before the statement",
+	ERROR_STATEMENT_SCOPE:                "Error statement is used in invalid scope",
+	ERROR_STATEMENT_CODE:                 "Error statement code should be in range of 600-699",
+	SYNTHETIC_STATEMENT_SCOPE:            "Synthetic statement is used out of vcl_error",
+	SYNTHETIC_BASE64_STATEMENT_SCOPE:     "Synthetic.base64 statement is used out of vcl_error",
+	GOTO_DUPLICATED:                      "Goto destination is declared more than once",
+	GOTO_SYNTAX:                          "Goto destination name is invalid",
+	CONDITION_LITERAL:                    "Literal is used as if condition",
+	VALID_IP:                             "IP address string is invalid",
+	FUNCTION_ARGUMENTS:                   "Function arguments count mismatch",
+	FUNCTION_ARGUMENT_TYPE:               "Function argument type mismatch",
+	INCLUDE_STATEMENT_MODULE_NOT_FOUND:   "Included module is not found",
+	INCLUDE_STATEMENT_MODULE_LOAD_FAILED: "Included module could not be loaded",
+	REGEX_MATCHED_VALUE_MAY_OVERRIDE:     "Regex matched value re.group.N may be overridden",
+	UNUSED_DECLARATION:                   "Declaration is never used",
+	UNUSED_VARIABLE:                      "Local variable is never used",
+	UNUSED_GOTO:                          "Goto destination is never jumped to",
+	DISALLOW_EMPTY_RETURN:                "Return statement in state-machine subroutine must have the next state",
+	FORBIDDEN_BACKWARD_JUMP:              "Goto statement jumps backward",
+	TIME_CALCULATION:                     "Time calculation should be precalculated",
+	DEPRECATED:                           "Deprecated variable is used",
+	DATAFLOW_READ_BEFORE_SET:             "Request header is read before it is set in the request flow",
+	DATAFLOW_MISSPELLED_HEADER:           "Request header is never set but a similar name header is set",
+	DATAFLOW_DEAD_ASSIGNMENT:             "Local variable is overwritten before the assigned value is used",
+	DATAFLOW_REMOVED_HEADER_SET:          "Backend header is set but always removed before it is used",
+	SECURITY_URL_REGEX_BYPASS:            "Regex matching against raw URL could be bypassed",
+	SECURITY_HOST_NORMALIZATION:          "Host header is compared without normalization",
+	SECURITY_SHIELD_FASTLY_FF:            "Header from client information is set without shielding guard",
+	SECURITY_DEBUG_HEADER_LEAK:           "Debug response headers are exposed to any client",
+	SECURITY_SYNTHETIC_INJECTION:         "User input is concatenated into synthetic response without escaping",
+	SECURITY_UNCHECKED_BEREQ_URL:         "Backend request URL is built from unvalidated user input",
+}
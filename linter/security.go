@@ -0,0 +1,475 @@
+package linter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/context"
+)
+
+// Variables which contain raw request URL, regex matching against them could be bypassed by encoding
+var rawURLVariables = map[string]struct{}{
+	"req.url":        {},
+	"req.url.path":   {},
+	"bereq.url":      {},
+	"bereq.url.path": {},
+}
+
+// Variables which indicate the request comes from another Fastly POP on shielding
+var shieldCheckVariables = map[string]struct{}{
+	"req.http.fastly-ff":            {},
+	"fastly.ff.visits_this_service": {},
+	"req.backend.is_shield":         {},
+	"fastly_info.is_cluster_edge":   {},
+	"fastly_info.is_cluster_shield": {},
+}
+
+// Functions which escape user input safely to embed in the response body
+var escapeFunctions = map[string]struct{}{
+	"json.escape": {},
+	"xml_escape":  {},
+	"urlencode":   {},
+}
+
+// Regex pattern which contains literal path segment like "^/admin"
+var literalPathPattern = regexp.MustCompile(`/[a-zA-Z0-9_-]`)
+
+// guard is the condition which is known to be true on the statement,
+// or known to be false when negated is true like else branch or after early exit.
+type guard struct {
+	expr    ast.Expression
+	negated bool
+}
+
+// securityWalker walks subroutine statements with enclosing if conditions
+// in order to know the statement is guarded by some checks.
+type securityWalker struct {
+	l              *Linter
+	scope          int
+	hostNormalized bool
+}
+
+// lintSecurity runs opt-in security rules which are enabled by configuration.
+func (l *Linter) lintSecurity() {
+	if l.conf == nil || !l.conf.EnableSecurityRules {
+		return
+	}
+
+	// Host header is treated as normalized when it is lowercased somewhere
+	var hostNormalized bool
+	for _, decl := range l.subroutines {
+		if isHostNormalized(decl.Block.Statements) {
+			hostNormalized = true
+		}
+	}
+
+	for _, decl := range l.subroutines {
+		w := &securityWalker{
+			l:              l,
+			scope:          getSubroutineCallScope(decl),
+			hostNormalized: hostNormalized,
+		}
+		w.statements(decl.Block.Statements, nil)
+	}
+}
+
+// isHostNormalized finds set statement for Host header which value is lowercased
+func isHostNormalized(stmts []ast.Statement) bool {
+	for _, stmt := range stmts {
+		switch t := stmt.(type) {
+		case *ast.SetStatement:
+			if strings.EqualFold(t.Ident.Value, "req.http.Host") && callsFunction(t.Value, "std.tolower") {
+				return true
+			}
+		case *ast.BlockStatement:
+			if isHostNormalized(t.Statements) {
+				return true
+			}
+		case *ast.IfStatement:
+			if isHostNormalized(t.Consequence.Statements) {
+				return true
+			}
+			for _, another := range t.Another {
+				if isHostNormalized(another.Consequence.Statements) {
+					return true
+				}
+			}
+			if t.Alternative != nil && isHostNormalized(t.Alternative.Consequence.Statements) {
+				return true
+			}
+		case *ast.SwitchStatement:
+			for _, c := range t.Cases {
+				if isHostNormalized(c.Statements) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (w *securityWalker) inScope(scope int) bool {
+	return w.scope > 0 && w.scope&scope > 0
+}
+
+func (w *securityWalker) statements(stmts []ast.Statement, conds []guard) {
+	for _, stmt := range stmts {
+		w.statement(stmt, conds)
+		// Early exit like "if (!req.http.Fastly-FF) { return(pass); }" guards the following statements
+		// with the failed condition
+		if t, ok := stmt.(*ast.IfStatement); ok && endsWithExit(t.Consequence.Statements) {
+			conds = append(append([]guard{}, conds...), guard{expr: t.Condition, negated: true})
+		}
+	}
+}
+
+func endsWithExit(stmts []ast.Statement) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	switch stmts[len(stmts)-1].(type) {
+	case *ast.ReturnStatement, *ast.ErrorStatement, *ast.RestartStatement:
+		return true
+	}
+	return false
+}
+
+func (w *securityWalker) statement(stmt ast.Statement, conds []guard) {
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		w.statements(t.Statements, conds)
+	case *ast.IfStatement:
+		// Each branch is guarded by its own condition and the failed conditions of the previous branches
+		failed := append([]guard{}, conds...)
+		w.expression(t.Condition)
+		w.statements(t.Consequence.Statements, append(append([]guard{}, failed...), guard{expr: t.Condition}))
+		failed = append(failed, guard{expr: t.Condition, negated: true})
+		for _, another := range t.Another {
+			w.expression(another.Condition)
+			w.statements(another.Consequence.Statements, append(append([]guard{}, failed...), guard{expr: another.Condition}))
+			failed = append(failed, guard{expr: another.Condition, negated: true})
+		}
+		if t.Alternative != nil {
+			w.statements(t.Alternative.Consequence.Statements, failed)
+		}
+	case *ast.SwitchStatement:
+		nested := append(append([]guard{}, conds...), guard{expr: t.Control.Expression})
+		w.expression(t.Control.Expression)
+		for _, c := range t.Cases {
+			w.statements(c.Statements, nested)
+		}
+	case *ast.SetStatement:
+		w.expression(t.Value)
+		w.lintSetStatement(t.Ident, t.Value, conds)
+	case *ast.AddStatement:
+		w.expression(t.Value)
+		w.lintSetStatement(t.Ident, t.Value, conds)
+	case *ast.SyntheticStatement:
+		w.expression(t.Value)
+		w.lintSynthetic(t.Value)
+	case *ast.SyntheticBase64Statement:
+		w.expression(t.Value)
+		w.lintSynthetic(t.Value)
+	case *ast.FunctionCallStatement:
+		for _, arg := range t.Arguments {
+			w.expression(arg)
+		}
+		w.lintHostLookup(t.Function, t.Arguments)
+	case *ast.LogStatement:
+		w.expression(t.Value)
+	case *ast.ReturnStatement:
+		if t.ReturnExpression != nil {
+			w.expression(t.ReturnExpression)
+		}
+	}
+}
+
+func (w *securityWalker) expression(expr ast.Expression) {
+	switch t := expr.(type) {
+	case *ast.GroupedExpression:
+		w.expression(t.Right)
+	case *ast.PrefixExpression:
+		w.expression(t.Right)
+	case *ast.PostfixExpression:
+		w.expression(t.Left)
+	case *ast.IfExpression:
+		w.expression(t.Condition)
+		w.expression(t.Consequence)
+		w.expression(t.Alternative)
+	case *ast.FunctionCallExpression:
+		for _, arg := range t.Arguments {
+			w.expression(arg)
+		}
+		w.lintHostLookup(t.Function, t.Arguments)
+	case *ast.InfixExpression:
+		w.expression(t.Left)
+		w.expression(t.Right)
+		w.lintInfixExpression(t)
+	}
+}
+
+func (w *securityWalker) lintInfixExpression(expr *ast.InfixExpression) {
+	ident, ok := expr.Left.(*ast.Ident)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(ident.Value)
+	pattern, isString := expr.Right.(*ast.String)
+
+	switch expr.Operator {
+	case "~", "!~":
+		if !isString {
+			return
+		}
+		if _, ok := rawURLVariables[name]; ok && literalPathPattern.MatchString(pattern.Value) {
+			w.l.Error(URLRegexBypass(ident.GetMeta(), ident.Value).Match(SECURITY_URL_REGEX_BYPASS))
+			return
+		}
+		if name == "req.http.host" && !w.hostNormalized && !strings.HasPrefix(pattern.Value, "(?i)") {
+			w.l.Error(HostNotNormalized(ident.GetMeta()).Match(SECURITY_HOST_NORMALIZATION))
+		}
+	case "==", "!=":
+		if name == "req.http.host" && !w.hostNormalized && isString {
+			w.l.Error(HostNotNormalized(ident.GetMeta()).Match(SECURITY_HOST_NORMALIZATION))
+		}
+	}
+}
+
+// Routing by table lookup with Host header, e.g table.lookup(hosts, req.http.Host)
+func (w *securityWalker) lintHostLookup(fn *ast.Ident, args []ast.Expression) {
+	if w.hostNormalized || !strings.HasPrefix(fn.Value, "table.") {
+		return
+	}
+	for _, arg := range args {
+		if ident, ok := arg.(*ast.Ident); ok && strings.EqualFold(ident.Value, "req.http.Host") {
+			w.l.Error(HostNotNormalized(ident.GetMeta()).Match(SECURITY_HOST_NORMALIZATION))
+		}
+	}
+}
+
+func (w *securityWalker) lintSetStatement(ident *ast.Ident, value ast.Expression, conds []guard) {
+	name := strings.ToLower(ident.Value)
+
+	switch {
+	// Client information is overwritten by the edge POP information on the shield POP
+	case strings.HasPrefix(name, "req.http.") && w.inScope(context.RECV):
+		if conditionsRead(conds, func(v string) bool {
+			_, ok := shieldCheckVariables[strings.ToLower(v)]
+			return ok
+		}) {
+			return
+		}
+		if client := findIdent(value, isClientVariable); client != nil {
+			w.l.Error(MissingShieldCheck(ident.GetMeta(), ident.Value, client.Value).Match(SECURITY_SHIELD_FASTLY_FF))
+		}
+
+	// Debug headers must be restricted by ACL
+	case strings.HasPrefix(name, "resp.http.") && w.inScope(context.DELIVER):
+		isDebug := strings.HasPrefix(name, "resp.http.fastly-debug") ||
+			conditionsRead(conds, func(v string) bool {
+				return strings.HasPrefix(strings.ToLower(v), "req.http.fastly-debug")
+			})
+		if isDebug && !conditionsHaveACL(conds) {
+			w.l.Error(DebugHeaderLeak(ident.GetMeta(), ident.Value).Match(SECURITY_DEBUG_HEADER_LEAK))
+		}
+
+	// Backend URL must not be built from user input without validation
+	case name == "bereq.url" || name == "bereq.url.path":
+		for _, input := range untrustedInputs(value, true) {
+			if !conditionsValidate(conds, input.Value) {
+				w.l.Error(UncheckedBackendURL(ident.GetMeta(), input.Value).Match(SECURITY_UNCHECKED_BEREQ_URL))
+				return
+			}
+		}
+	}
+}
+
+func (w *securityWalker) lintSynthetic(value ast.Expression) {
+	inputs := untrustedInputs(value, false)
+	if len(inputs) == 0 {
+		return
+	}
+	w.l.Error(UnsafeSynthetic(inputs[0].GetMeta(), inputs[0].Value).Match(SECURITY_SYNTHETIC_INJECTION))
+}
+
+func isClientVariable(name string) bool {
+	return name == "client.ip" ||
+		strings.HasPrefix(name, "client.geo.") ||
+		strings.HasPrefix(name, "client.as.")
+}
+
+// isUntrustedVariable returns true if the variable could be controlled by the client.
+// When allowURL is true, whole request URL is allowed like "set bereq.url = req.url;"
+func isUntrustedVariable(name string, allowURL bool) bool {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(name, "req.http."):
+		return true
+	case strings.HasPrefix(name, "req.body"):
+		return true
+	case name == "req.url.qs":
+		return true
+	case name == "req.url" || strings.HasPrefix(name, "req.url."):
+		return !allowURL
+	}
+	return false
+}
+
+// untrustedInputs collects variables which come from user input and are not escaped
+func untrustedInputs(expr ast.Expression, allowURL bool) []*ast.Ident {
+	var idents []*ast.Ident
+	var walk func(expr ast.Expression)
+	walk = func(expr ast.Expression) {
+		switch t := expr.(type) {
+		case *ast.Ident:
+			if isUntrustedVariable(t.Value, allowURL) {
+				idents = append(idents, t)
+			}
+		case *ast.GroupedExpression:
+			walk(t.Right)
+		case *ast.PrefixExpression:
+			walk(t.Right)
+		case *ast.PostfixExpression:
+			walk(t.Left)
+		case *ast.InfixExpression:
+			walk(t.Left)
+			walk(t.Right)
+		case *ast.IfExpression:
+			walk(t.Consequence)
+			walk(t.Alternative)
+		case *ast.FunctionCallExpression:
+			if _, ok := escapeFunctions[t.Function.Value]; ok {
+				return
+			}
+			// Query parameter value is user input even if whole URL is allowed
+			if strings.HasPrefix(t.Function.Value, "querystring.get") {
+				idents = append(idents, t.Function)
+				return
+			}
+			for _, arg := range t.Arguments {
+				walk(arg)
+			}
+		}
+	}
+	walk(expr)
+	return idents
+}
+
+func findIdent(expr ast.Expression, match func(name string) bool) *ast.Ident {
+	b := newFlowBuilder(nil)
+	b.expression(expr, 0)
+	for _, e := range b.events {
+		if e.kind == flowRead && match(e.ident.Value) {
+			return e.ident
+		}
+	}
+	return nil
+}
+
+// conditionsRead returns true if the conditions read the variable regardless of the result
+func conditionsRead(conds []guard, match func(name string) bool) bool {
+	for _, c := range conds {
+		if findIdent(c.expr, match) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionsHaveACL returns true if the conditions ensure client IP matching like "client.ip ~ internal"
+func conditionsHaveACL(conds []guard) bool {
+	for _, c := range conds {
+		if hasInfix(c.expr, c.negated, func(i *ast.InfixExpression, negated bool) bool {
+			ident, ok := i.Left.(*ast.Ident)
+			if !ok || ident.Value != "client.ip" {
+				return false
+			}
+			return (i.Operator == "~" && !negated) || (i.Operator == "!~" && negated)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionsValidate returns true if the conditions ensure regex or exact matching for the variable
+func conditionsValidate(conds []guard, name string) bool {
+	for _, c := range conds {
+		if hasInfix(c.expr, c.negated, func(i *ast.InfixExpression, negated bool) bool {
+			switch i.Operator {
+			case "~", "==":
+				if negated {
+					return false
+				}
+			case "!~", "!=":
+				if !negated {
+					return false
+				}
+			default:
+				return false
+			}
+			switch left := i.Left.(type) {
+			case *ast.Ident:
+				return strings.EqualFold(left.Value, name)
+			case *ast.FunctionCallExpression:
+				return left.Function.Value == name
+			}
+			return false
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasInfix returns true if the infix expression which matches is ensured when the expression is true,
+// or false when negated is true. Negation by "!" flips the result of the following expression,
+// and only the operands which are always evaluated to the same result are inspected for logical operators
+func hasInfix(expr ast.Expression, negated bool, match func(i *ast.InfixExpression, negated bool) bool) bool {
+	switch t := expr.(type) {
+	case *ast.GroupedExpression:
+		return hasInfix(t.Right, negated, match)
+	case *ast.PrefixExpression:
+		if t.Operator == "!" {
+			return hasInfix(t.Right, !negated, match)
+		}
+		return false
+	case *ast.InfixExpression:
+		switch t.Operator {
+		case "&&":
+			// Only "a && b" ensures both operands are true
+			if negated {
+				return false
+			}
+			return hasInfix(t.Left, negated, match) || hasInfix(t.Right, negated, match)
+		case "||":
+			// Only "!(a || b)" ensures both operands are false
+			if !negated {
+				return false
+			}
+			return hasInfix(t.Left, negated, match) || hasInfix(t.Right, negated, match)
+		}
+		return match(t, negated)
+	}
+	return false
+}
+
+func callsFunction(expr ast.Expression, name string) bool {
+	switch t := expr.(type) {
+	case *ast.FunctionCallExpression:
+		if t.Function.Value == name {
+			return true
+		}
+		for _, arg := range t.Arguments {
+			if callsFunction(arg, name) {
+				return true
+			}
+		}
+	case *ast.GroupedExpression:
+		return callsFunction(t.Right, name)
+	case *ast.InfixExpression:
+		return callsFunction(t.Left, name) || callsFunction(t.Right, name)
+	}
+	return false
+}
@@ -0,0 +1,307 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/ysugimoto/falco/config"
+)
+
+var securityConfig = &config.LinterConfig{EnableSecurityRules: true}
+
+func TestSecurityRulesAreOptIn(t *testing.T) {
+	input := `
+sub vcl_recv {
+	#FASTLY recv
+	if (req.url ~ "^/admin") {
+		error 403;
+	}
+}`
+	errs := lintRuleErrors(t, testConfig, input, SECURITY_URL_REGEX_BYPASS)
+	if len(errs) != 0 {
+		t.Errorf("Expect no errors without enabling security rules but got %d", len(errs))
+	}
+}
+
+func TestSecurityRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		input  string
+		expect int
+	}{
+		{
+			name: "regex on raw url",
+			rule: SECURITY_URL_REGEX_BYPASS,
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	if (req.url ~ "^/admin") {
+		error 403;
+	}
+}`,
+			expect: 1,
+		},
+		{
+			name: "regex on normalized url",
+			rule: SECURITY_URL_REGEX_BYPASS,
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	if (std.tolower(urldecode(req.url.path)) ~ "^/admin") {
+		error 403;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "host routing without normalization",
+			rule: SECURITY_HOST_NORMALIZATION,
+			input: `
+backend F_api {
+	.host = "api.example.com";
+}
+sub vcl_recv {
+	#FASTLY recv
+	if (req.http.Host == "api.example.com") {
+		set req.backend = F_api;
+	}
+}`,
+			expect: 1,
+		},
+		{
+			name: "host routing with normalization",
+			rule: SECURITY_HOST_NORMALIZATION,
+			input: `
+backend F_api {
+	.host = "api.example.com";
+}
+sub vcl_recv {
+	#FASTLY recv
+	set req.http.Host = std.tolower(req.http.Host);
+	if (req.http.Host == "api.example.com") {
+		set req.backend = F_api;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "client information without shield check",
+			rule: SECURITY_SHIELD_FASTLY_FF,
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	set req.http.X-Client-IP = client.ip;
+}`,
+			expect: 1,
+		},
+		{
+			name: "client information with shield check",
+			rule: SECURITY_SHIELD_FASTLY_FF,
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	if (!req.http.Fastly-FF) {
+		set req.http.X-Client-IP = client.ip;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "client information after early return on shield",
+			rule: SECURITY_SHIELD_FASTLY_FF,
+			input: `
+sub vcl_recv {
+	#FASTLY recv
+	if (fastly.ff.visits_this_service > 0) {
+		return(lookup);
+	}
+	set req.http.X-Country = client.geo.country_code;
+}`,
+			expect: 0,
+		},
+		{
+			name: "debug header exposed",
+			rule: SECURITY_DEBUG_HEADER_LEAK,
+			input: `
+sub vcl_deliver {
+	#FASTLY deliver
+	if (req.http.Fastly-Debug) {
+		set resp.http.X-Backend = req.backend;
+	}
+}`,
+			expect: 1,
+		},
+		{
+			name: "debug header restricted by acl",
+			rule: SECURITY_DEBUG_HEADER_LEAK,
+			input: `
+acl internal {
+	"192.0.2.0"/24;
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	if (req.http.Fastly-Debug && client.ip ~ internal) {
+		set resp.http.X-Backend = req.backend;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "debug header in else branch of acl check",
+			rule: SECURITY_DEBUG_HEADER_LEAK,
+			input: `
+acl internal {
+	"192.0.2.0"/24;
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	if (client.ip ~ internal) {
+		set resp.http.X-Internal = "1";
+	} else {
+		set resp.http.Fastly-Debug = req.backend;
+	}
+}`,
+			expect: 1,
+		},
+		{
+			name: "debug header in else branch of negated acl check",
+			rule: SECURITY_DEBUG_HEADER_LEAK,
+			input: `
+acl internal {
+	"192.0.2.0"/24;
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	if (!(client.ip ~ internal)) {
+		set resp.http.X-Internal = "0";
+	} else {
+		set resp.http.Fastly-Debug = req.backend;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "debug header guarded by negated acl check",
+			rule: SECURITY_DEBUG_HEADER_LEAK,
+			input: `
+acl internal {
+	"192.0.2.0"/24;
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	if (!(client.ip ~ internal)) {
+		set resp.http.Fastly-Debug = req.backend;
+	}
+}`,
+			expect: 1,
+		},
+		{
+			name: "debug header after early exit by acl check",
+			rule: SECURITY_DEBUG_HEADER_LEAK,
+			input: `
+acl internal {
+	"192.0.2.0"/24;
+}
+sub vcl_deliver {
+	#FASTLY deliver
+	if (client.ip !~ internal) {
+		return(deliver);
+	}
+	set resp.http.Fastly-Debug = req.backend;
+}`,
+			expect: 0,
+		},
+		{
+			name: "unescaped input in synthetic",
+			rule: SECURITY_SYNTHETIC_INJECTION,
+			input: `
+sub vcl_error {
+	#FASTLY error
+	synthetic "<p>Not found: " + req.url + "</p>";
+	return(deliver);
+}`,
+			expect: 1,
+		},
+		{
+			name: "escaped input in synthetic",
+			rule: SECURITY_SYNTHETIC_INJECTION,
+			input: `
+sub vcl_error {
+	#FASTLY error
+	synthetic "<p>Not found: " + xml_escape(req.url) + "</p>";
+	return(deliver);
+}`,
+			expect: 0,
+		},
+		{
+			name: "backend url from header",
+			rule: SECURITY_UNCHECKED_BEREQ_URL,
+			input: `
+sub vcl_miss {
+	#FASTLY miss
+	set bereq.url = "/api/" + req.http.X-Version + req.url;
+}`,
+			expect: 1,
+		},
+		{
+			name: "backend url from validated header",
+			rule: SECURITY_UNCHECKED_BEREQ_URL,
+			input: `
+sub vcl_miss {
+	#FASTLY miss
+	if (req.http.X-Version ~ "^v[0-9]+$") {
+		set bereq.url = "/api/" + req.http.X-Version + req.url;
+	}
+}`,
+			expect: 0,
+		},
+		{
+			name: "backend url in else branch of validation",
+			rule: SECURITY_UNCHECKED_BEREQ_URL,
+			input: `
+sub vcl_miss {
+	#FASTLY miss
+	if (req.http.X-Version ~ "^[a-z]+$") {
+		set bereq.url = "/api/" + req.http.X-Version;
+	} else {
+		set bereq.url = "/api/" + req.http.X-Version;
+	}
+}`,
+			expect: 1,
+		},
+		{
+			name: "backend url from header with negated validation",
+			rule: SECURITY_UNCHECKED_BEREQ_URL,
+			input: `
+sub vcl_miss {
+	#FASTLY miss
+	if (!(req.http.X-Version ~ "^ok$")) {
+		set bereq.url = "/api/" + req.http.X-Version;
+	}
+}`,
+			expect: 1,
+		},
+		{
+			name: "backend url after early exit by validation",
+			rule: SECURITY_UNCHECKED_BEREQ_URL,
+			input: `
+sub vcl_miss {
+	#FASTLY miss
+	if (req.http.X-Version !~ "^v[0-9]+$") {
+		error 400;
+	}
+	set bereq.url = "/api/" + req.http.X-Version + req.url;
+}`,
+			expect: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := lintRuleErrors(t, securityConfig, tt.input, tt.rule)
+			if len(errs) != tt.expect {
+				t.Errorf("Expect %d errors but got %d: %v", tt.expect, len(errs), errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package linter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/config"
+)
+
+// ruleSeverity is a single "rule: severity" entry in the configuration.
+// pattern may be an exact rule name or glob pattern like "unused/*".
+type ruleSeverity struct {
+	pattern  string
+	severity Severity
+}
+
+// ruleSeverities is a set of rule severities which is sorted by precedence.
+// Exact rule name takes precedence over glob pattern, and more specific pattern wins.
+type ruleSeverities []ruleSeverity
+
+func (r ruleSeverities) lookup(rule Rule) (Severity, bool) {
+	for _, v := range r {
+		if matched, _ := path.Match(v.pattern, string(rule)); matched {
+			return v.severity, true
+		}
+	}
+	return "", false
+}
+
+type pathOverride struct {
+	files []string
+	rules ruleSeverities
+}
+
+// SeverityOverrides resolves lint error severity which is overridden by the configuration.
+// Rules in "overrides" section apply to the matched files and take precedence over global "rules",
+// and the later override wins when multiple overrides match the same file.
+type SeverityOverrides struct {
+	rules     ruleSeverities
+	overrides []pathOverride
+	cwd       string
+}
+
+func NewSeverityOverrides(c *config.LinterConfig) (*SeverityOverrides, error) {
+	s := &SeverityOverrides{}
+	if cwd, err := os.Getwd(); err == nil {
+		s.cwd = cwd
+	}
+	if c == nil {
+		return s, nil
+	}
+
+	rules, err := parseRuleSeverities(c.Rules)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s.rules = rules
+
+	for i, o := range c.Overrides {
+		if o == nil {
+			continue
+		}
+		if len(o.Files) == 0 {
+			return nil, errors.Errorf("overrides[%d]: files must be specified", i)
+		}
+		for _, f := range o.Files {
+			if err := validateGlob(f); err != nil {
+				return nil, errors.Errorf("overrides[%d]: invalid file pattern %q", i, f)
+			}
+		}
+		rules, err := parseRuleSeverities(o.Rules)
+		if err != nil {
+			return nil, errors.Wrapf(err, "overrides[%d]", i)
+		}
+		s.overrides = append(s.overrides, pathOverride{files: o.Files, rules: rules})
+	}
+
+	return s, nil
+}
+
+func parseRuleSeverities(rules map[string]string) (ruleSeverities, error) {
+	var ret ruleSeverities
+	for key, value := range rules {
+		if err := validateRule(key); err != nil {
+			return nil, err
+		}
+		severity, err := parseSeverity(value)
+		if err != nil {
+			return nil, errors.Errorf("Level for rule %s has invalid value %s", key, value)
+		}
+		ret = append(ret, ruleSeverity{pattern: key, severity: severity})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		a, b := ret[i].pattern, ret[j].pattern
+		if isGlob(a) != isGlob(b) {
+			return !isGlob(a)
+		}
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return ret, nil
+}
+
+func parseSeverity(v string) (Severity, error) {
+	switch strings.ToUpper(v) {
+	case "ERROR":
+		return ERROR, nil
+	case "WARNING":
+		return WARNING, nil
+	case "INFO":
+		return INFO, nil
+	case "IGNORE":
+		return IGNORE, nil
+	}
+	return "", fmt.Errorf("invalid severity %s", v)
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// validateRule checks rule name is builtin or registered custom rule, or glob pattern matches at least one rule
+func validateRule(pattern string) error {
+	rules := allRules()
+	if !isGlob(pattern) {
+		for _, r := range rules {
+			if string(r) == pattern {
+				return nil
+			}
+		}
+		return errors.Errorf("Unknown rule %q is specified", pattern)
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.Errorf("Invalid rule pattern %q", pattern)
+	}
+	for _, r := range rules {
+		if matched, _ := path.Match(pattern, string(r)); matched {
+			return nil
+		}
+	}
+	return errors.Errorf("Rule pattern %q does not match any rules", pattern)
+}
+
+// Severity returns overridden severity for the lint error which is reported in the file.
+// If any rules are not matched, original severity is returned.
+func (s *SeverityOverrides) Severity(le *LintError, file string) Severity {
+	if s == nil || le.Rule == "" {
+		return le.Severity
+	}
+
+	for i := len(s.overrides) - 1; i >= 0; i-- {
+		o := s.overrides[i]
+		if !s.matchFile(o.files, file) {
+			continue
+		}
+		if v, ok := o.rules.lookup(le.Rule); ok {
+			return v
+		}
+	}
+	if v, ok := s.rules.lookup(le.Rule); ok {
+		return v
+	}
+	return le.Severity
+}
+
+func (s *SeverityOverrides) matchFile(patterns []string, file string) bool {
+	if file == "" {
+		return false
+	}
+	if filepath.IsAbs(file) && s.cwd != "" {
+		if rel, err := filepath.Rel(s.cwd, file); err == nil {
+			file = rel
+		}
+	}
+	file = filepath.ToSlash(filepath.Clean(file))
+
+	for _, p := range patterns {
+		// Pattern without slash matches to the file name in any directories
+		if !strings.Contains(p, "/") {
+			p = "**/" + p
+		}
+		if matchGlob(strings.Split(p, "/"), strings.Split(file, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateGlob(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchGlob matches path segments with pattern segments.
+// "**" segment matches zero or more directories, and other segments are matched by path.Match.
+func matchGlob(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(patterns[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(patterns[0], segments[0]); !matched {
+		return false
+	}
+	return matchGlob(patterns[1:], segments[1:])
+}
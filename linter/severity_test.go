@@ -0,0 +1,101 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/ysugimoto/falco/config"
+)
+
+func TestSeverityOverrides(t *testing.T) {
+	c := &config.LinterConfig{
+		Rules: map[string]string{
+			"unused/*":           "error",
+			"unused/declaration": "info",
+		},
+		Overrides: []*config.RuleOverride{
+			{
+				Files: []string{"vendor/**"},
+				Rules: map[string]string{
+					"unused/*": "ignore",
+				},
+			},
+			{
+				Files: []string{"*.generated.vcl"},
+				Rules: map[string]string{
+					"subroutine/boilerplate-macro": "ignore",
+				},
+			},
+		},
+	}
+	s, err := NewSeverityOverrides(c)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		file   string
+		expect Severity
+	}{
+		{name: "exact rule takes precedence over glob", rule: UNUSED_DECLARATION, file: "main.vcl", expect: INFO},
+		{name: "glob rule", rule: UNUSED_VARIABLE, file: "main.vcl", expect: ERROR},
+		{name: "path override takes precedence over global rules", rule: UNUSED_DECLARATION, file: "vendor/lib/mod.vcl", expect: IGNORE},
+		{name: "file name pattern matches in any directories", rule: SUBROUTINE_BOILERPLATE_MACRO, file: "src/main.generated.vcl", expect: IGNORE},
+		{name: "not overridden", rule: SUBROUTINE_BOILERPLATE_MACRO, file: "src/main.vcl", expect: WARNING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			le := (&LintError{Severity: WARNING}).Match(tt.rule)
+			if got := s.Severity(le, tt.file); got != tt.expect {
+				t.Errorf("Unexpected severity, expect=%s, got=%s", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestSeverityOverridesValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		conf *config.LinterConfig
+	}{
+		{
+			name: "unknown rule",
+			conf: &config.LinterConfig{Rules: map[string]string{"unused/unknown": "error"}},
+		},
+		{
+			name: "glob does not match any rules",
+			conf: &config.LinterConfig{Rules: map[string]string{"foo/*": "error"}},
+		},
+		{
+			name: "invalid severity",
+			conf: &config.LinterConfig{Rules: map[string]string{"unused/variable": "fatal"}},
+		},
+		{
+			name: "unknown rule in overrides",
+			conf: &config.LinterConfig{
+				Overrides: []*config.RuleOverride{
+					{Files: []string{"vendor/**"}, Rules: map[string]string{"unused/unknown": "ignore"}},
+				},
+			},
+		},
+		{
+			name: "overrides without files",
+			conf: &config.LinterConfig{
+				Overrides: []*config.RuleOverride{
+					{Rules: map[string]string{"unused/variable": "ignore"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSeverityOverrides(tt.conf); err == nil {
+				t.Errorf("Expected error but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package lsp
+
+import (
+	"strings"
+
+	"github.com/ysugimoto/falco/context"
+)
+
+var subroutineScopes = map[string]int{
+	"vcl_recv":    context.RECV,
+	"vcl_hash":    context.HASH,
+	"vcl_hit":     context.HIT,
+	"vcl_miss":    context.MISS,
+	"vcl_pass":    context.PASS,
+	"vcl_fetch":   context.FETCH,
+	"vcl_error":   context.ERROR,
+	"vcl_deliver": context.DELIVER,
+	"vcl_log":     context.LOG,
+}
+
+// Complete predefined variables, builtin functions and user defined symbols.
+// Variables and functions which are not accessible in the enclosing Fastly subroutine are excluded
+func (s *Server) completion(params TextDocumentPositionParams) (*CompletionList, error) {
+	doc, ok := s.workspace.document(params.TextDocument.URI)
+	if !ok {
+		return &CompletionList{Items: []CompletionItem{}}, nil
+	}
+	prefix, rng := prefixAt(doc.text, params.Position)
+
+	// Predefined variables and builtin functions are available even if the VCL could not be parsed
+	ctx := context.New()
+	a, analyzed := s.workspace.analysisOf(doc)
+	if analyzed {
+		ctx = a.ctx
+	}
+	scope, scoped := subroutineScopes[enclosingSubroutine(doc.text, params.Position.Line)]
+
+	items := []CompletionItem{}
+	add := func(label string, kind int, detail string) {
+		if !strings.HasPrefix(label, prefix) {
+			return
+		}
+		items = append(items, CompletionItem{
+			Label:    label,
+			Kind:     kind,
+			Detail:   detail,
+			TextEdit: &TextEdit{Range: rng, NewText: label},
+		})
+	}
+
+	for _, v := range flattenVariables(ctx) {
+		if scoped && v.accessor.Scopes&scope == 0 {
+			continue
+		}
+		add(v.name, completionKindVariable, v.accessor.Get.String())
+	}
+	for _, f := range flattenFunctions(ctx) {
+		if scoped && f.function.Scopes&scope == 0 {
+			continue
+		}
+		add(f.name, completionKindFunction, functionSignatures(f.name, f.function)[0])
+	}
+	if analyzed {
+		for _, sym := range declaredSymbols(ctx) {
+			kind := completionKindReference
+			if sym.kind == "subroutine" {
+				kind = completionKindMethod
+			}
+			add(sym.name, kind, sym.kind)
+		}
+	}
+
+	return &CompletionList{Items: items}, nil
+}
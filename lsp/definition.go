@@ -0,0 +1,27 @@
+package lsp
+
+// Find declaration of subroutine, table, backend, director, acl, penaltybox and ratecounter.
+// Symbols which are declared in included files are also found because linted context has all of them
+func (s *Server) definition(params TextDocumentPositionParams) (any, error) {
+	doc, ok := s.workspace.document(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	a, ok := s.workspace.analysisOf(doc)
+	if !ok {
+		return nil, nil
+	}
+	word, _ := wordAt(doc.text, params.Position)
+	sym, ok := findSymbol(a.ctx, word)
+	if !ok {
+		return nil, nil
+	}
+	file := sym.token.File
+	if file == "" {
+		file = a.main
+	}
+	return &Location{
+		URI:   pathToURI(file),
+		Range: a.tokenRange(sym.token, file),
+	}, nil
+}
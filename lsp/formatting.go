@@ -0,0 +1,39 @@
+package lsp
+
+import (
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/formatter"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+// Format whole document, the result is returned as a single text edit which replaces entire document
+func (s *Server) formatting(params DocumentFormattingParams) (any, error) {
+	doc, ok := s.workspace.document(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	vcl, err := parser.New(lexer.NewFromString(doc.text, lexer.WithFile(doc.path))).ParseVCL()
+	if err != nil {
+		// Could not format invalid VCL, the parse error is reported as diagnostics
+		return nil, nil
+	}
+	buf, err := io.ReadAll(formatter.New(s.config.Format).Format(vcl))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	lines := strings.Split(doc.text, "\n")
+	return []TextEdit{
+		{
+			Range: Range{
+				Start: Position{Line: 0, Character: 0},
+				End:   Position{Line: len(lines), Character: 0},
+			},
+			NewText: string(buf),
+		},
+	}, nil
+}
@@ -0,0 +1,74 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ysugimoto/falco/context"
+)
+
+// Show type and scope information of the word under the cursor
+func (s *Server) hover(params TextDocumentPositionParams) (any, error) {
+	doc, ok := s.workspace.document(params.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	word, rng := wordAt(doc.text, params.Position)
+	if word == "" {
+		return nil, nil
+	}
+
+	ctx := context.New()
+	a, analyzed := s.workspace.analysisOf(doc)
+	if analyzed {
+		ctx = a.ctx
+	}
+
+	var contents string
+	if v, ok := findVariable(ctx, word); ok {
+		contents = variableHover(word, v)
+	} else if fn, ok := findFunction(ctx, word); ok {
+		contents = functionHover(word, fn)
+	} else if sym, ok := findSymbol(ctx, word); ok && analyzed {
+		contents = fmt.Sprintf("```vcl\n%s %s\n```\nDeclared at %s:%d", sym.kind, sym.name, sym.token.File, sym.token.Line)
+	}
+	if contents == "" {
+		return nil, nil
+	}
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: contents},
+		Range:    &rng,
+	}, nil
+}
+
+func variableHover(name string, v *context.Accessor) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("```vcl\n%s %s\n```\n", v.Get, name))
+	if v.Set != 0 {
+		sb.WriteString(fmt.Sprintf("- Settable: %s\n", v.Set))
+	} else {
+		sb.WriteString("- Read only\n")
+	}
+	if v.Unset {
+		sb.WriteString("- Unsettable\n")
+	}
+	sb.WriteString(fmt.Sprintf("- Scopes: %s\n", strings.TrimSpace(context.ScopesString(v.Scopes))))
+	if v.Deprecated {
+		sb.WriteString("- Deprecated\n")
+	}
+	if v.Reference != "" {
+		sb.WriteString(fmt.Sprintf("\n%s", v.Reference))
+	}
+	return sb.String()
+}
+
+func functionHover(name string, fn *context.BuiltinFunction) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("```vcl\n%s\n```\n", strings.Join(functionSignatures(name, fn), "\n")))
+	sb.WriteString(fmt.Sprintf("- Scopes: %s\n", strings.TrimSpace(context.ScopesString(fn.Scopes))))
+	if fn.Reference != "" {
+		sb.WriteString(fmt.Sprintf("\n%s", fn.Reference))
+	}
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const jsonrpcVersion = "2.0"
+
+// JSON-RPC error codes
+// see: https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#errorCodes
+const (
+	codeParseError           = -32700
+	codeInvalidRequest       = -32600
+	codeMethodNotFound       = -32601
+	codeInvalidParams        = -32602
+	codeInternalError        = -32603
+	codeServerNotInitialized = -32002
+)
+
+// message represents JSON-RPC request, notification and response.
+// Request has both ID and Method, notification has only Method, and response has only ID
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  any              `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+func (m *message) isRequest() bool {
+	return m.ID != nil && m.Method != ""
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("%s (code: %d)", e.Message, e.Code)
+}
+
+// Read a message which is framed with Content-Length header
+func readMessage(r *bufio.Reader) (*message, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(headers.Get("Content-Length")))
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid Content-Length header")
+	}
+	if length < 0 {
+		return nil, errors.Errorf("Invalid Content-Length header: %d", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	msg := &message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, &responseError{Code: codeParseError, Message: err.Error()}
+	}
+	return msg, nil
+}
+
+// Write a message with Content-Length header
+func writeMessage(w io.Writer, msg *message) error {
+	msg.JSONRPC = jsonrpcVersion
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
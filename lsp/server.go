@@ -0,0 +1,247 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/linter"
+)
+
+// Server is a Language Server Protocol server for VCL.
+// Server communicates with the editor through stdio and handles messages sequentially
+type Server struct {
+	config    *config.Config
+	workspace *workspace
+
+	mu          sync.Mutex
+	w           io.Writer
+	initialized bool
+	shutdown    bool
+
+	// Files which diagnostics have been published, used for clearing diagnostics
+	published map[string]struct{}
+}
+
+func New(c *config.Config, main string) (*Server, error) {
+	severities, err := linter.NewSeverityOverrides(c.Linter)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid linter rules configuration")
+	}
+	return &Server{
+		config:    c,
+		workspace: newWorkspace(c, main, severities),
+		published: make(map[string]struct{}),
+	}, nil
+}
+
+func (s *Server) Run() error {
+	log.SetOutput(io.Discard)
+	return s.Serve(os.Stdin, os.Stdout)
+}
+
+// Serve reads messages from r and writes responses to w until exit notification is received
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = w
+	reader := bufio.NewReader(r)
+
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if re, ok := err.(*responseError); ok {
+				s.reply(nil, nil, re) // nolint:errcheck
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) error {
+	result, err := s.dispatch(msg)
+	if !msg.isRequest() {
+		// Notification could not be responded
+		return nil
+	}
+	if err != nil {
+		re, ok := err.(*responseError)
+		if !ok {
+			re = &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		return s.reply(msg.ID, nil, re)
+	}
+	return s.reply(msg.ID, result, nil)
+}
+
+// nolint: gocyclo
+func (s *Server) dispatch(msg *message) (any, error) {
+	if !s.initialized && msg.Method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "Server is not initialized"}
+	}
+	if s.shutdown && msg.Method != "exit" {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "Server has been shut down"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		s.initialized = true
+		return s.initialize(), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc := s.workspace.open(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.workspace.document(params.TextDocument.URI)
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// Server declares full text synchronization so the last change has whole content
+		doc.text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		if doc, ok := s.workspace.document(params.TextDocument.URI); ok {
+			return nil, s.publishDiagnostics(doc)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		s.workspace.close(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params)
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params)
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params)
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.formatting(params)
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: "Method not found: " + msg.Method}
+	}
+}
+
+func (s *Server) initialize() *InitializeResult {
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			PositionEncoding: positionEncodingUTF16,
+			TextDocumentSync: TextDocumentSyncOptions{
+				OpenClose: true,
+				Change:    syncKindFull,
+				Save:      true,
+			},
+			CompletionProvider: CompletionOptions{
+				TriggerCharacters: []string{"."},
+			},
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{
+			Name: "falco",
+		},
+	}
+}
+
+// Analyze the document and publish diagnostics for all files which are related to the document.
+// Diagnostics for the files which have no problem anymore are cleared
+func (s *Server) publishDiagnostics(doc *document) error {
+	a := s.workspace.analyze(doc)
+
+	for file := range s.published {
+		if _, ok := a.diagnostics[file]; !ok {
+			a.diagnostics[file] = []Diagnostic{}
+		}
+	}
+	for file, diagnostics := range a.diagnostics {
+		if err := s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         pathToURI(file),
+			Diagnostics: diagnostics,
+		}); err != nil {
+			return err
+		}
+		if len(diagnostics) > 0 {
+			s.published[file] = struct{}{}
+		} else {
+			delete(s.published, file)
+		}
+	}
+	return nil
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, re *responseError) error {
+	if re == nil && result == nil {
+		// Result must be present as null on success
+		result = json.RawMessage("null")
+	}
+	return s.write(&message{ID: id, Result: result, Error: re})
+}
+
+func (s *Server) notify(method string, params any) error {
+	buf, err := json.Marshal(params)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.write(&message{Method: method, Params: buf})
+}
+
+func (s *Server) write(msg *message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeMessage(s.w, msg)
+}
+
+func decodeParams(msg *message, v any) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
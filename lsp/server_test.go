@@ -0,0 +1,338 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ysugimoto/falco/config"
+)
+
+type testClient struct {
+	buf bytes.Buffer
+	id  int
+}
+
+func (c *testClient) request(method string, params any) int {
+	c.id++
+	id := json.RawMessage(fmt.Sprint(c.id))
+	c.send(&message{ID: &id, Method: method, Params: mustMarshal(params)})
+	return c.id
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.send(&message{Method: method, Params: mustMarshal(params)})
+}
+
+func (c *testClient) send(msg *message) {
+	writeMessage(&c.buf, msg)
+}
+
+func mustMarshal(v any) json.RawMessage {
+	buf, _ := json.Marshal(v)
+	return buf
+}
+
+type testResult struct {
+	responses     map[string]*message
+	notifications []*message
+}
+
+func (r *testResult) response(t *testing.T, id int, v any) {
+	t.Helper()
+	msg, ok := r.responses[fmt.Sprint(id)]
+	if !ok {
+		t.Fatalf("Response for id %d not found", id)
+	}
+	if msg.Error != nil {
+		t.Fatalf("Unexpected error response: %s", msg.Error)
+	}
+	buf, _ := json.Marshal(msg.Result)
+	if err := json.Unmarshal(buf, v); err != nil {
+		t.Fatalf("Failed to decode response: %s", err)
+	}
+}
+
+func serve(t *testing.T, c *testClient) *testResult {
+	t.Helper()
+	c.notify("exit", nil)
+
+	conf, err := config.New([]string{})
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %s", err)
+	}
+	main, _ := filepath.Abs("testdata/main.vcl")
+	var out bytes.Buffer
+	server, err := New(conf, main)
+	if err != nil {
+		t.Fatalf("Failed to create server: %s", err)
+	}
+	if err := server.Serve(&c.buf, &out); err != nil {
+		t.Fatalf("Unexpected serve error: %s", err)
+	}
+
+	result := &testResult{responses: make(map[string]*message)}
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		if msg.ID != nil {
+			result.responses[string(*msg.ID)] = msg
+		} else {
+			result.notifications = append(result.notifications, msg)
+		}
+	}
+	return result
+}
+
+func openMain(t *testing.T, c *testClient) string {
+	t.Helper()
+	path, _ := filepath.Abs("testdata/main.vcl")
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read testdata: %s", err)
+	}
+	uri := pathToURI(path)
+	c.request("initialize", map[string]any{})
+	c.notify("initialized", map[string]any{})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "vcl", Text: string(buf)},
+	})
+	return uri
+}
+
+func TestServerNotInitialized(t *testing.T) {
+	c := &testClient{}
+	id := c.request("textDocument/hover", TextDocumentPositionParams{})
+	r := serve(t, c)
+
+	msg := r.responses[fmt.Sprint(id)]
+	if msg == nil || msg.Error == nil || msg.Error.Code != codeServerNotInitialized {
+		t.Errorf("Expected not initialized error, got %+v", msg)
+	}
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	c := &testClient{}
+	uri := openMain(t, c)
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Text: "sub vcl_recv {\n  #FASTLY RECV\n  set req.http.Foo = undefined_var;\n}\n"},
+		},
+	})
+	r := serve(t, c)
+
+	var diagnostics []PublishDiagnosticsParams
+	for _, n := range r.notifications {
+		if n.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var p PublishDiagnosticsParams
+		json.Unmarshal(n.Params, &p)
+		if p.URI == uri {
+			diagnostics = append(diagnostics, p)
+		}
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected diagnostics are published twice, got %d", len(diagnostics))
+	}
+	if len(diagnostics[0].Diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics for valid VCL, got %+v", diagnostics[0].Diagnostics)
+	}
+	changed := diagnostics[1].Diagnostics
+	if len(changed) == 0 {
+		t.Fatalf("Expected diagnostics for invalid VCL")
+	}
+	if changed[0].Severity != severityError || changed[0].Range.Start.Line != 2 {
+		t.Errorf("Unexpected diagnostic: %+v", changed[0])
+	}
+}
+
+func TestServerCompletion(t *testing.T) {
+	c := &testClient{}
+	uri := openMain(t, c)
+	// "  set req.backend = origin;" in vcl_recv
+	variable := c.request("textDocument/completion", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 8, Character: 13},
+	})
+	// "  call set_host_recv;" in vcl_recv
+	subroutine := c.request("textDocument/completion", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 10, Character: 10},
+	})
+	// Edit "  set req.backend" to "  set beresp." in vcl_recv
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Text: "sub vcl_recv {\n  #FASTLY RECV\n  set beresp.\n}\n"},
+		},
+	})
+	scoped := c.request("textDocument/completion", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 2, Character: 13},
+	})
+	r := serve(t, c)
+
+	var list CompletionList
+	r.response(t, variable, &list)
+	labels := map[string]bool{}
+	for _, item := range list.Items {
+		if !strings.HasPrefix(item.Label, "req.b") {
+			t.Errorf("Unexpected completion item %s for prefix req.b", item.Label)
+		}
+		labels[item.Label] = true
+	}
+	if !labels["req.backend"] {
+		t.Errorf("Expected completion item req.backend is not found")
+	}
+
+	r.response(t, subroutine, &list)
+	var found *CompletionItem
+	for i := range list.Items {
+		if list.Items[i].Label == "set_host_recv" {
+			found = &list.Items[i]
+		}
+	}
+	if found == nil || found.Kind != completionKindMethod {
+		t.Fatalf("Expected subroutine completion item is not found: %+v", list.Items)
+	}
+	if found.TextEdit == nil || found.TextEdit.Range.Start.Character != 7 || found.TextEdit.Range.End.Character != 10 {
+		t.Errorf("Unexpected completion text edit: %+v", found.TextEdit)
+	}
+
+	r.response(t, scoped, &list)
+	for _, item := range list.Items {
+		if item.Label == "beresp.status" {
+			t.Errorf("Expected beresp.status is not completed in vcl_recv")
+		}
+	}
+}
+
+func TestServerHover(t *testing.T) {
+	c := &testClient{}
+	uri := openMain(t, c)
+	variable := c.request("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 9, Character: 8},
+	})
+	function := c.request("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 9, Character: 30},
+	})
+	r := serve(t, c)
+
+	var hover Hover
+	r.response(t, variable, &hover)
+	if !strings.Contains(hover.Contents.Value, "STRING req.http.Redirect") {
+		t.Errorf("Unexpected variable hover: %s", hover.Contents.Value)
+	}
+	r.response(t, function, &hover)
+	if !strings.Contains(hover.Contents.Value, "STRING table.lookup(TABLE, STRING)") {
+		t.Errorf("Unexpected function hover: %s", hover.Contents.Value)
+	}
+}
+
+func TestServerDefinition(t *testing.T) {
+	c := &testClient{}
+	uri := openMain(t, c)
+	backend := c.request("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 8, Character: 22},
+	})
+	subroutine := c.request("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 10, Character: 9},
+	})
+	r := serve(t, c)
+
+	var loc Location
+	r.response(t, backend, &loc)
+	if !strings.HasSuffix(loc.URI, "/testdata/backends.vcl") || loc.Range.Start.Line != 0 || loc.Range.Start.Character != 8 {
+		t.Errorf("Unexpected backend definition: %+v", loc)
+	}
+	r.response(t, subroutine, &loc)
+	if loc.URI != uri || loc.Range.Start.Line != 14 || loc.Range.Start.Character != 4 {
+		t.Errorf("Unexpected subroutine definition: %+v", loc)
+	}
+}
+
+func TestServerFormatting(t *testing.T) {
+	c := &testClient{}
+	uri := openMain(t, c)
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Text: "sub vcl_recv {\nset req.http.Foo = \"bar\";\n}\n"},
+		},
+	})
+	id := c.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+	})
+	r := serve(t, c)
+
+	var edits []TextEdit
+	r.response(t, id, &edits)
+	expect := "sub vcl_recv {\n  set req.http.Foo = \"bar\";\n}\n"
+	if len(edits) != 1 || edits[0].NewText != expect {
+		t.Errorf("Unexpected formatting result: %+v", edits)
+	}
+}
+
+func TestServerUTF16Position(t *testing.T) {
+	c := &testClient{}
+	uri := openMain(t, c)
+	// Emoji is encoded as surrogate pair which is counted as two UTF-16 code units
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Text: "sub vcl_recv {\n  #FASTLY RECV\n  set req.http.Foo = \"😀\" undefined_var;\n  set req.http.Bar = \"😀\" req.http.Foo;\n}\n"},
+		},
+	})
+	hover := c.request("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 3, Character: 26},
+	})
+	r := serve(t, c)
+
+	var diagnostics []Diagnostic
+	for _, n := range r.notifications {
+		if n.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var p PublishDiagnosticsParams
+		json.Unmarshal(n.Params, &p)
+		if p.URI == uri {
+			diagnostics = p.Diagnostics
+		}
+	}
+	if len(diagnostics) == 0 {
+		t.Fatalf("Expected diagnostics for invalid VCL")
+	}
+	if start := diagnostics[0].Range.Start; start.Line != 2 || start.Character != 26 {
+		t.Errorf("Unexpected diagnostic position: %+v", start)
+	}
+
+	var h Hover
+	r.response(t, hover, &h)
+	if h.Range == nil || h.Range.Start.Character != 26 || h.Range.End.Character != 38 {
+		t.Errorf("Unexpected hover range: %+v", h.Range)
+	}
+}
+
+func TestReadMessageInvalidContentLength(t *testing.T) {
+	for _, v := range []string{"", "foo", "-1"} {
+		r := bufio.NewReader(strings.NewReader("Content-Length: " + v + "\r\n\r\n{}"))
+		if _, err := readMessage(r); err == nil {
+			t.Errorf("Expected error for Content-Length %q but got nil", v)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/token"
+	"github.com/ysugimoto/falco/types"
+)
+
+const anyKey = "%any%"
+
+// User defined symbol in VCL like subroutine, table, backend, etc
+type symbol struct {
+	name  string
+	kind  string
+	token token.Token
+}
+
+// Collect user defined symbols from linted context, sorted by name
+func declaredSymbols(ctx *context.Context) []symbol {
+	var symbols []symbol
+	for name, v := range ctx.Subroutines {
+		symbols = append(symbols, symbol{name: name, kind: "subroutine", token: nameToken(v.Decl.Name, v.Decl.Token)})
+	}
+	for name, v := range ctx.Tables {
+		symbols = append(symbols, symbol{name: name, kind: "table", token: nameToken(v.Decl.Name, v.Decl.Token)})
+	}
+	for name, v := range ctx.Backends {
+		// Director is also registered as backend
+		if v.BackendDecl == nil {
+			continue
+		}
+		symbols = append(symbols, symbol{name: name, kind: "backend", token: nameToken(v.BackendDecl.Name, v.BackendDecl.Token)})
+	}
+	for name, v := range ctx.Directors {
+		symbols = append(symbols, symbol{name: name, kind: "director", token: nameToken(v.Decl.Name, v.Decl.Token)})
+	}
+	for name, v := range ctx.Acls {
+		symbols = append(symbols, symbol{name: name, kind: "acl", token: nameToken(v.Decl.Name, v.Decl.Token)})
+	}
+	for name, v := range ctx.Penaltyboxes {
+		symbols = append(symbols, symbol{name: name, kind: "penaltybox", token: nameToken(v.Decl.Name, v.Decl.Token)})
+	}
+	for name, v := range ctx.Ratecounters {
+		symbols = append(symbols, symbol{name: name, kind: "ratecounter", token: nameToken(v.Decl.Name, v.Decl.Token)})
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].name < symbols[j].name
+	})
+	return symbols
+}
+
+func findSymbol(ctx *context.Context, name string) (symbol, bool) {
+	for _, s := range declaredSymbols(ctx) {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return symbol{}, false
+}
+
+// Declaration name token points to the exact position of symbol name.
+// Fallback to declaration token if the name is not present
+func nameToken(name *ast.Ident, fallback token.Token) token.Token {
+	if name == nil || name.Meta == nil {
+		return fallback
+	}
+	return name.Token
+}
+
+// Find predefined variable accessor by dot-separated name like "req.http.Host".
+// Wildcard field like HTTP header name is matched via "%any%" key
+func findVariable(ctx *context.Context, name string) (*context.Accessor, bool) {
+	// Strip subfield access like req.http.Cookie:name
+	if idx := strings.Index(name, ":"); idx != -1 {
+		name = name[:idx]
+	}
+	parts := strings.Split(name, ".")
+	obj, ok := ctx.Variables[parts[0]]
+	if !ok {
+		return nil, false
+	}
+	for _, p := range parts[1:] {
+		next, ok := obj.Items[p]
+		if !ok {
+			if next, ok = obj.Items[anyKey]; !ok {
+				return nil, false
+			}
+		}
+		obj = next
+	}
+	if obj.Value == nil {
+		return nil, false
+	}
+	return obj.Value, true
+}
+
+type variableEntry struct {
+	name     string
+	accessor *context.Accessor
+}
+
+// Flatten predefined variables to dot-separated names, sorted by name
+func flattenVariables(ctx *context.Context) []variableEntry {
+	var entries []variableEntry
+	var walk func(prefix string, items map[string]*context.Object)
+	walk = func(prefix string, items map[string]*context.Object) {
+		for key, obj := range items {
+			if key == anyKey {
+				continue
+			}
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			if obj.Value != nil {
+				entries = append(entries, variableEntry{name: name, accessor: obj.Value})
+			}
+			walk(name, obj.Items)
+		}
+	}
+	walk("", ctx.Variables)
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return entries
+}
+
+type functionEntry struct {
+	name     string
+	function *context.BuiltinFunction
+}
+
+// Flatten builtin functions to dot-separated names, sorted by name
+func flattenFunctions(ctx *context.Context) []functionEntry {
+	var entries []functionEntry
+	var walk func(prefix string, items map[string]*context.FunctionSpec)
+	walk = func(prefix string, items map[string]*context.FunctionSpec) {
+		for key, spec := range items {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			if spec.Value != nil && !spec.Value.IsUserDefinedFunction {
+				entries = append(entries, functionEntry{name: name, function: spec.Value})
+			}
+			walk(name, spec.Items)
+		}
+	}
+	walk("", ctx.Functions())
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return entries
+}
+
+func findFunction(ctx *context.Context, name string) (*context.BuiltinFunction, bool) {
+	for _, e := range flattenFunctions(ctx) {
+		if e.name == name {
+			return e.function, true
+		}
+	}
+	return nil, false
+}
+
+// Format function signature like "STRING std.tolower(STRING)"
+func functionSignatures(name string, fn *context.BuiltinFunction) []string {
+	overloads := fn.Arguments
+	if len(overloads) == 0 {
+		overloads = [][]types.Type{{}}
+	}
+	signatures := make([]string, len(overloads))
+	for i, args := range overloads {
+		names := make([]string, len(args))
+		for j := range args {
+			names[j] = args[j].String()
+		}
+		signatures[i] = fn.Return.String() + " " + name + "(" + strings.Join(names, ", ") + ")"
+	}
+	return signatures
+}
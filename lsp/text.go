@@ -0,0 +1,105 @@
+package lsp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Characters which could compose VCL identifier, variable and function name
+func isWordChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '_', r == '.', r == ':', r == '-':
+		return true
+	}
+	return false
+}
+
+func lineAt(text string, line int) []rune {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return nil
+	}
+	return []rune(strings.TrimRight(lines[line], "\r"))
+}
+
+// LSP position counts characters in UTF-16 code units but falco counts them in runes.
+// Convert rune index of the line to UTF-16 offset, runes over the line are counted as one unit
+func utf16Offset(line []rune, index int) int {
+	var offset int
+	for i := 0; i < index; i++ {
+		if i < len(line) {
+			offset += utf16Len(line[i])
+		} else {
+			offset++
+		}
+	}
+	return offset
+}
+
+// Characters out of the basic multilingual plane are encoded as surrogate pair
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// Convert UTF-16 offset of the line to rune index, the offset is clamped to the line length
+func runeIndex(line []rune, offset int) int {
+	var units int
+	for i, r := range line {
+		if units >= offset {
+			return i
+		}
+		units += utf16Len(r)
+	}
+	return len(line)
+}
+
+// Get word before the cursor position, used for completion prefix
+func prefixAt(text string, pos Position) (string, Range) {
+	line := lineAt(text, pos.Line)
+	end := runeIndex(line, pos.Character)
+	start := end
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+	return string(line[start:end]), Range{
+		Start: Position{Line: pos.Line, Character: utf16Offset(line, start)},
+		End:   Position{Line: pos.Line, Character: utf16Offset(line, end)},
+	}
+}
+
+// Get whole word under the cursor position, used for hover and definition
+func wordAt(text string, pos Position) (string, Range) {
+	line := lineAt(text, pos.Line)
+	start := runeIndex(line, pos.Character)
+	end := start
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isWordChar(line[end]) {
+		end++
+	}
+	// Trim trailing dot or colon which are not a part of the name
+	word := strings.TrimRight(string(line[start:end]), ".:")
+	return word, Range{
+		Start: Position{Line: pos.Line, Character: utf16Offset(line, start)},
+		End:   Position{Line: pos.Line, Character: utf16Offset(line, start+len([]rune(word)))},
+	}
+}
+
+var subroutineStartRegex = regexp.MustCompile(`^\s*sub\s+([A-Za-z0-9_]+)`)
+
+// Find subroutine name which encloses the line by looking back subroutine declaration
+func enclosingSubroutine(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	for i := min(line, len(lines)-1); i >= 0; i-- {
+		if m := subroutineStartRegex.FindStringSubmatch(lines[i]); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,278 @@
+package lsp
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/parser"
+	"github.com/ysugimoto/falco/resolver"
+	"github.com/ysugimoto/falco/token"
+)
+
+// Opened text document in the editor
+type document struct {
+	uri  string
+	path string
+	text string
+}
+
+// Result of analyzing main VCL, lexers, parsed AST and linted context are kept
+// in order to answer completion, hover and definition requests.
+// Source texts of the analyzed files are kept to convert token positions to LSP positions
+type analysis struct {
+	main        string
+	vcl         *ast.VCL
+	ctx         *context.Context
+	lexers      map[string]*lexer.Lexer
+	sources     map[string]string
+	diagnostics map[string][]Diagnostic
+}
+
+// workspace manages opened documents and analysis results.
+// If main VCL is specified, all documents are analyzed as a part of the main VCL,
+// otherwise each document is analyzed as main VCL
+type workspace struct {
+	config     *config.Config
+	main       string
+	documents  map[string]*document
+	analyses   map[string]*analysis
+	severities *linter.SeverityOverrides
+}
+
+func newWorkspace(c *config.Config, main string, severities *linter.SeverityOverrides) *workspace {
+	w := &workspace{
+		config:     c,
+		severities: severities,
+		documents:  make(map[string]*document),
+		analyses:   make(map[string]*analysis),
+	}
+	if main != "" {
+		if abs, err := filepath.Abs(main); err == nil {
+			w.main = abs
+		}
+	}
+	return w
+}
+
+func (w *workspace) open(uri, text string) *document {
+	doc := &document{
+		uri:  uri,
+		path: uriToPath(uri),
+		text: text,
+	}
+	w.documents[doc.path] = doc
+	return doc
+}
+
+func (w *workspace) close(uri string) {
+	path := uriToPath(uri)
+	delete(w.documents, path)
+	delete(w.analyses, path)
+}
+
+func (w *workspace) document(uri string) (*document, bool) {
+	doc, ok := w.documents[uriToPath(uri)]
+	return doc, ok
+}
+
+// Find main VCL path to analyze for the document
+func (w *workspace) mainOf(doc *document) string {
+	if w.main != "" {
+		return w.main
+	}
+	return doc.path
+}
+
+// Get latest analysis for the document
+func (w *workspace) analysisOf(doc *document) (*analysis, bool) {
+	a, ok := w.analyses[w.mainOf(doc)]
+	return a, ok
+}
+
+// Analyze VCL which the document belongs to.
+// Parse and lint results are stored as diagnostics for each file,
+// and the analysis is kept only when the main VCL has been parsed successfully
+func (w *workspace) analyze(doc *document) *analysis {
+	main := w.mainOf(doc)
+	a := &analysis{
+		main:        main,
+		lexers:      make(map[string]*lexer.Lexer),
+		sources:     make(map[string]string),
+		diagnostics: make(map[string][]Diagnostic),
+	}
+	rslv := &overlayResolver{
+		main:         main,
+		includePaths: w.includePaths(main),
+		documents:    w.documents,
+		sources:      a.sources,
+	}
+	// Ensure diagnostics are cleared for the opened documents
+	a.diagnostics[doc.path] = []Diagnostic{}
+
+	vcl, err := rslv.MainVCL()
+	if err != nil {
+		return a
+	}
+	lx := lexer.NewFromString(vcl.Data, lexer.WithFile(vcl.Name))
+	tree, err := parser.New(lx).ParseVCL()
+	if err != nil {
+		a.addParseError(vcl.Name, err)
+		return a
+	}
+
+	ctx := context.New(context.WithResolver(rslv))
+	lt := linter.New(w.config.Linter)
+	lt.Lint(tree, ctx)
+	for k, v := range lt.Lexers() {
+		a.lexers[k] = v
+	}
+	a.lexers[vcl.Name] = lx
+
+	if lt.FatalError != nil {
+		file := vcl.Name
+		if pe, ok := lt.FatalError.Error.(*parser.ParseError); ok && pe.Token.File != "" {
+			file = pe.Token.File
+		}
+		a.addParseError(file, lt.FatalError.Error)
+	}
+	for _, le := range lt.Errors {
+		file := le.Token.File
+		if file == "" {
+			file = vcl.Name
+		}
+		severity := w.severities.Severity(le, file)
+		if severity == linter.IGNORE {
+			continue
+		}
+		a.diagnostics[file] = append(a.diagnostics[file], a.lintDiagnostic(le, file, severity))
+	}
+
+	a.vcl = tree
+	a.ctx = ctx
+	w.analyses[main] = a
+	return a
+}
+
+func (w *workspace) includePaths(main string) []string {
+	var paths []string
+	for _, p := range w.config.IncludePaths {
+		if abs, err := filepath.Abs(p); err == nil {
+			paths = append(paths, abs)
+		}
+	}
+	return append(paths, filepath.Dir(main))
+}
+
+func (a *analysis) addParseError(file string, err error) {
+	d := Diagnostic{
+		Severity: severityError,
+		Source:   "falco",
+		Message:  errors.Cause(err).Error(),
+	}
+	if pe, ok := errors.Cause(err).(*parser.ParseError); ok {
+		d.Message = pe.Message
+		d.Range = a.tokenRange(pe.Token, file)
+	}
+	a.diagnostics[file] = append(a.diagnostics[file], d)
+}
+
+func (a *analysis) lintDiagnostic(le *linter.LintError, file string, severity linter.Severity) Diagnostic {
+	d := Diagnostic{
+		Range:    a.tokenRange(le.Token, file),
+		Severity: severityError,
+		Source:   "falco",
+		Message:  le.Message,
+		Code:     string(le.Rule),
+	}
+	switch severity {
+	case linter.WARNING:
+		d.Severity = severityWarning
+	case linter.INFO:
+		d.Severity = severityInformation
+	}
+	if le.Reference != "" {
+		d.CodeDescription = &CodeDescription{Href: le.Reference}
+	}
+	return d
+}
+
+// Convert token position to LSP range.
+// Token has 1-based line and position in runes, LSP uses 0-based line and character in UTF-16 code units
+func (a *analysis) tokenRange(t token.Token, file string) Range {
+	line := max(t.Line-1, 0)
+	start := max(t.Position-1, 0)
+	length := len([]rune(t.Literal))
+	if t.Type == token.STRING {
+		// String literal does not include quotes
+		length += 2
+	}
+	text := lineAt(a.sources[file], line)
+	return Range{
+		Start: Position{Line: line, Character: utf16Offset(text, start)},
+		End:   Position{Line: line, Character: utf16Offset(text, start+max(length, 1))},
+	}
+}
+
+// overlayResolver resolves VCL files from opened documents first, and then from filesystem.
+// It is similar to resolver.FileResolver but editing content which is not saved yet is used.
+// Resolved contents are recorded to sources
+type overlayResolver struct {
+	main         string
+	includePaths []string
+	documents    map[string]*document
+	sources      map[string]string
+}
+
+func (r *overlayResolver) read(file string) (*resolver.VCL, error) {
+	if doc, ok := r.documents[file]; ok {
+		r.sources[file] = doc.text
+		return &resolver.VCL{Name: file, Data: doc.text}, nil
+	}
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r.sources[file] = string(buf)
+	return &resolver.VCL{Name: file, Data: string(buf)}, nil
+}
+
+func (r *overlayResolver) MainVCL() (*resolver.VCL, error) {
+	return r.read(r.main)
+}
+
+func (r *overlayResolver) Resolve(stmt *ast.IncludeStatement) (*resolver.VCL, error) {
+	module := stmt.Module.Value
+	if !strings.HasSuffix(module, ".vcl") {
+		module += ".vcl"
+	}
+	for _, p := range r.includePaths {
+		if vcl, err := r.read(filepath.Join(p, module)); err == nil {
+			return vcl, nil
+		}
+	}
+	return nil, errors.Errorf("Failed to resolve include file: %s", module)
+}
+
+func (r *overlayResolver) Name() string           { return "" }
+func (r *overlayResolver) IncludePaths() []string { return r.includePaths }
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
@@ -20,3 +20,7 @@ func (d *Debugger) Run(node ast.Node) interpreter.DebugState {
 func (d *Debugger) Message(msg string) {
 	d.stack = append(d.stack, msg)
 }
+
+func (d *Debugger) Logs() []string {
+	return d.stack
+}
@@ -2,7 +2,10 @@ package tester
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	"github.com/ysugimoto/falco/interpreter/function/errors"
 	"github.com/ysugimoto/falco/lexer"
 )
@@ -60,6 +63,7 @@ type TestFactory struct {
 	Results    []*TestResult
 	Statistics *TestCounter
 	Logs       []string
+	Coverage   *coverage.Collector // nil if coverage is not enabled
 }
 
 type TestCounter struct {
@@ -81,3 +85,63 @@ func (c *TestCounter) Fail() {
 	c.Asserts++
 	c.Fails++
 }
+
+// Merge adds statistics of another counter
+func (c *TestCounter) Merge(o *TestCounter) {
+	c.Asserts += o.Asserts
+	c.Passes += o.Passes
+	c.Fails += o.Fails
+}
+
+// Failure detail of the test case which is used for reporters
+type failureDetail struct {
+	Type     string
+	Message  string
+	Actual   string
+	File     string
+	Line     int
+	Position int
+}
+
+func newFailureDetail(r *TestResult, c *TestCase) *failureDetail {
+	d := &failureDetail{
+		Type:    "Error",
+		Message: c.Error.Error(),
+		File:    r.Filename,
+	}
+	switch e := c.Error.(type) {
+	case *errors.AssertionError:
+		d.Type = "AssertionError"
+		d.Message = e.Message
+		if e.Actual != nil {
+			d.Actual = e.Actual.String()
+		}
+		d.setLocation(e.Token.File, e.Token.Line, e.Token.Position)
+	case *errors.TestingError:
+		d.Type = "TestingError"
+		d.Message = e.Message
+		d.setLocation(e.Token.File, e.Token.Line, e.Token.Position)
+	}
+	return d
+}
+
+func (d *failureDetail) setLocation(file string, line, position int) {
+	if file != "" {
+		d.File = file
+	}
+	d.Line = line
+	d.Position = position
+}
+
+func (d *failureDetail) String() string {
+	var b strings.Builder
+	b.WriteString(d.Message + "\n")
+	if d.Actual != "" {
+		b.WriteString("Actual Value: " + d.Actual + "\n")
+	}
+	b.WriteString("at " + d.File)
+	if d.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", d.Line, d.Position)
+	}
+	return b.String()
+}
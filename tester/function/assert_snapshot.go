@@ -0,0 +1,73 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+	"github.com/ysugimoto/falco/tester/snapshot"
+)
+
+const Assert_snapshot_Name = "assert.snapshot"
+
+func Assert_snapshot_Validate(args []value.Value) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.ArgumentNotInRange(Assert_snapshot_Name, 1, 2, args)
+	}
+	for i := range args {
+		if args[i].Type() != value.StringType {
+			return errors.TypeMismatch(Assert_snapshot_Name, i+1, value.StringType, args[i].Type())
+		}
+	}
+	return nil
+}
+
+// Assert_snapshot compares the whole response with the recorded snapshot.
+// In ERROR scope, the snapshot is taken from obj.* because synthetic response is built on the object,
+// otherwise it is taken from resp.*
+// The snapshot which is not recorded yet fails unless snapshots are updated
+func Assert_snapshot(
+	ctx *context.Context,
+	store *snapshot.Store,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Assert_snapshot_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+	if store == nil {
+		return nil, errors.NewTestingError("%s is not available in this test", Assert_snapshot_Name)
+	}
+
+	resp := ctx.Response
+	if ctx.Scope == context.ErrorScope {
+		resp = ctx.Object
+	}
+	if resp == nil {
+		return nil, errors.NewTestingError("response does not exist to take a snapshot")
+	}
+	actual, err := snapshot.FromResponse(resp)
+	if err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	name := value.Unwrap[*value.String](args[0]).Value
+	diff, ok := store.Match(name, actual)
+	if ok {
+		return &value.Boolean{Value: true}, nil
+	}
+
+	message := "snapshot " + name + " does not match"
+	if !store.Recorded(name) {
+		message = "snapshot " + name + " is not recorded, run with --update-snapshots to record it"
+	}
+	if len(args) == 2 {
+		message = value.Unwrap[*value.String](args[1]).Value
+	}
+	// Actual value is omitted because the diff is included in the message
+	return &value.Boolean{}, errors.NewAssertionError(
+		nil,
+		"%s (-snapshot +actual):\n%s",
+		message,
+		diff,
+	)
+}
@@ -0,0 +1,117 @@
+package function
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+	"github.com/ysugimoto/falco/tester/snapshot"
+)
+
+func Test_Assert_snapshot(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "default.test.vcl")
+	store, err := snapshot.Load(testFile, false)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	// Snapshots are recorded only in update mode
+	updateStore, err := snapshot.Load(testFile, true)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	response := func(status int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"X-Foo": {"foo"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	t.Run("Missing snapshot fails", func(t *testing.T) {
+		ctx := &context.Context{Scope: context.DeliverScope, Response: response(200, "body")}
+		_, err := Assert_snapshot(ctx, store, &value.String{Value: "missing"})
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+			return
+		}
+		if !strings.Contains(err.Error(), "--update-snapshots") {
+			t.Errorf("Error should suggest updating snapshots, got=%s", err)
+		}
+	})
+
+	t.Run("Record and match resp snapshot", func(t *testing.T) {
+		ctx := &context.Context{Scope: context.DeliverScope, Response: response(200, "body")}
+		if _, err := Assert_snapshot(ctx, updateStore, &value.String{Value: "deliver"}); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if err := updateStore.Save(); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		store, err = snapshot.Load(testFile, false)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if _, err := Assert_snapshot(ctx, store, &value.String{Value: "deliver"}); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		ctx.Response.Header.Set("X-Foo", "bar")
+		_, err := Assert_snapshot(ctx, store, &value.String{Value: "deliver"})
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+			return
+		}
+		// Diff is reported in the message instead of the actual value
+		ae, ok := err.(*errors.AssertionError)
+		if !ok {
+			t.Errorf("Expected AssertionError but got %T", err)
+			return
+		}
+		if ae.Actual != nil {
+			t.Errorf("Actual value should be omitted, got=%s", ae.Actual)
+		}
+		if !strings.Contains(ae.Message, "X-Foo: bar") {
+			t.Errorf("Message should contain the diff, got=%s", ae.Message)
+		}
+	})
+
+	t.Run("Take snapshot from obj in ERROR scope", func(t *testing.T) {
+		ctx := &context.Context{
+			Scope:    context.ErrorScope,
+			Response: response(200, "body"),
+			Object:   response(404, "not found"),
+		}
+		if _, err := Assert_snapshot(ctx, updateStore, &value.String{Value: "error"}); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if err := updateStore.Save(); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		store, err = snapshot.Load(testFile, false)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		ctx.Object = response(404, "not found")
+		if _, err := Assert_snapshot(ctx, store, &value.String{Value: "error"}); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		ctx.Object.Body = io.NopCloser(strings.NewReader("changed"))
+		if _, err := Assert_snapshot(ctx, store, &value.String{Value: "error"}); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
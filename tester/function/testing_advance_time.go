@@ -0,0 +1,41 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_advance_time_Name = "testing.advance_time"
+
+var Testing_advance_time_ArgumentTypes = []value.Type{value.RTimeType}
+
+func Testing_advance_time_Validate(args []value.Value) error {
+	if len(args) != 1 {
+		return errors.ArgumentNotEnough(Testing_advance_time_Name, 1, args)
+	}
+	if args[0].Type() != Testing_advance_time_ArgumentTypes[0] {
+		return errors.TypeMismatch(Testing_advance_time_Name, 1, Testing_advance_time_ArgumentTypes[0], args[0].Type())
+	}
+	return nil
+}
+
+// Testing_advance_time moves the simulated clock forward by the duration.
+// If the time is not fixed yet, the clock is fixed at the current time before moving.
+func Testing_advance_time(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_advance_time_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	d := value.Unwrap[*value.RTime](args[0]).Value
+	if d < 0 {
+		return value.Null, errors.NewTestingError("%s could not move the time backward", Testing_advance_time_Name)
+	}
+	t := ctx.Now().Add(d)
+	ctx.FixedTime = &t
+	return value.Null, nil
+}
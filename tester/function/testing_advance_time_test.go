@@ -0,0 +1,48 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_advance_time(t *testing.T) {
+	t.Run("Advance fixed time", func(t *testing.T) {
+		fixed := time.Now().Add(-time.Hour)
+		c := &context.Context{FixedTime: &fixed}
+		_, err := Testing_advance_time(c, &value.RTime{Value: 30 * time.Second})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if !c.Now().Equal(fixed.Add(30 * time.Second)) {
+			t.Errorf("Time should be advanced, expect=%s, got=%s", fixed.Add(30*time.Second), c.Now())
+		}
+	})
+
+	t.Run("Advance current time", func(t *testing.T) {
+		before := time.Now()
+		c := &context.Context{}
+		_, err := Testing_advance_time(c, &value.RTime{Value: time.Hour})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if c.FixedTime == nil {
+			t.Errorf("Time should be fixed")
+			return
+		}
+		if c.Now().Before(before.Add(time.Hour)) {
+			t.Errorf("Time should be advanced by an hour, got=%s", c.Now())
+		}
+	})
+
+	t.Run("Negative duration is not allowed", func(t *testing.T) {
+		c := &context.Context{}
+		if _, err := Testing_advance_time(c, &value.RTime{Value: -time.Second}); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
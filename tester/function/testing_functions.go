@@ -7,6 +7,7 @@ import (
 	"github.com/ysugimoto/falco/interpreter/context"
 	ifn "github.com/ysugimoto/falco/interpreter/function"
 	"github.com/ysugimoto/falco/interpreter/value"
+	"github.com/ysugimoto/falco/tester/snapshot"
 )
 
 const allScope = context.AnyScope
@@ -25,12 +26,17 @@ type Definiions struct {
 
 type Functions map[string]*ifn.Function
 
-func TestingFunctions(i *interpreter.Interpreter, defs *Definiions, c Counter) Functions {
+func TestingFunctions(
+	i *interpreter.Interpreter,
+	defs *Definiions,
+	c Counter,
+	snapshots *snapshot.Store,
+) Functions {
 	functions := Functions{}
 	for key, val := range testingFunctions(i, defs) {
 		functions[key] = val
 	}
-	for key, val := range assertionFunctions(i, c) {
+	for key, val := range assertionFunctions(i, c, snapshots) {
 		functions[key] = val
 	}
 	return functions
@@ -149,11 +155,57 @@ func testingFunctions(i *interpreter.Interpreter, defs *Definiions) Functions {
 				return false
 			},
 		},
+		"testing.ratecounter_increment": {
+			Scope:            allScope,
+			Call:             Testing_ratecounter_increment,
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+		"testing.ratecounter_clear": {
+			Scope:            allScope,
+			Call:             Testing_ratecounter_clear,
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+		"testing.penaltybox_add": {
+			Scope:            allScope,
+			Call:             Testing_penaltybox_add,
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+		"testing.penaltybox_remove": {
+			Scope:            allScope,
+			Call:             Testing_penaltybox_remove,
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+		"testing.advance_time": {
+			Scope: allScope,
+			Call: func(ctx *context.Context, args ...value.Value) (value.Value, error) {
+				unwrapped, err := unwrapIdentArguments(i, args)
+				if err != nil {
+					return value.Null, errors.WithStack(err)
+				}
+				return Testing_advance_time(ctx, unwrapped...)
+			},
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
 	}
 }
 
 // nolint: funlen,gocognit
-func assertionFunctions(i *interpreter.Interpreter, c Counter) Functions {
+func assertionFunctions(i *interpreter.Interpreter, c Counter, snapshots *snapshot.Store) Functions {
 	return Functions{
 		"assert": {
 			Scope: allScope,
@@ -215,6 +267,26 @@ func assertionFunctions(i *interpreter.Interpreter, c Counter) Functions {
 				return false
 			},
 		},
+		"assert.snapshot": {
+			Scope: allScope,
+			Call: func(ctx *context.Context, args ...value.Value) (value.Value, error) {
+				unwrapped, err := unwrapIdentArguments(i, args)
+				if err != nil {
+					return value.Null, errors.WithStack(err)
+				}
+				v, err := Assert_snapshot(ctx, snapshots, unwrapped...)
+				if err != nil {
+					c.Fail()
+				} else {
+					c.Pass()
+				}
+				return v, err
+			},
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
 		"assert.restart": {
 			Scope: allScope,
 			Call: func(ctx *context.Context, args ...value.Value) (value.Value, error) {
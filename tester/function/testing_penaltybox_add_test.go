@@ -0,0 +1,52 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_penaltybox_add(t *testing.T) {
+	now := time.Now()
+	pb := ratelimit.NewPenaltybox("box")
+	c := &context.Context{
+		FixedTime: &now,
+		Penaltyboxes: map[string]*ratelimit.Penaltybox{
+			"box": pb,
+		},
+	}
+
+	t.Run("Add penaltybox entry", func(t *testing.T) {
+		_, err := Testing_penaltybox_add(
+			c,
+			&value.Ident{Value: "box"},
+			&value.String{Value: "client"},
+			&value.RTime{Value: 2 * time.Minute},
+		)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if !pb.Has("client", now.Add(time.Minute)) {
+			t.Errorf("Entry should be in the penaltybox")
+		}
+		if pb.Has("client", now.Add(2*time.Minute)) {
+			t.Errorf("Entry should be expired")
+		}
+	})
+
+	t.Run("Penaltybox not found", func(t *testing.T) {
+		_, err := Testing_penaltybox_add(
+			c,
+			&value.Ident{Value: "not_found"},
+			&value.String{Value: "client"},
+			&value.RTime{Value: 2 * time.Minute},
+		)
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_penaltybox_remove_Name = "testing.penaltybox_remove"
+
+var Testing_penaltybox_remove_ArgumentTypes = []value.Type{value.IdentType, value.StringType}
+
+func Testing_penaltybox_remove_Validate(args []value.Value) error {
+	if len(args) != 2 {
+		return errors.ArgumentNotEnough(Testing_penaltybox_remove_Name, 2, args)
+	}
+
+	for i := range Testing_penaltybox_remove_ArgumentTypes {
+		if args[i].Type() != Testing_penaltybox_remove_ArgumentTypes[i] {
+			return errors.TypeMismatch(
+				Testing_penaltybox_remove_Name,
+				i+1,
+				Testing_penaltybox_remove_ArgumentTypes[i],
+				args[i].Type(),
+			)
+		}
+	}
+	return nil
+}
+
+// Testing_penaltybox_remove removes the entry from the penaltybox
+func Testing_penaltybox_remove(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_penaltybox_remove_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	name := value.Unwrap[*value.Ident](args[0]).Value
+	pb, ok := ctx.Penaltyboxes[name]
+	if !ok {
+		return value.Null, errors.NewTestingError("penaltybox %s not found in VCL", name)
+	}
+
+	pb.Remove(value.Unwrap[*value.String](args[1]).Value)
+	return value.Null, nil
+}
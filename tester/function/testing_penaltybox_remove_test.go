@@ -0,0 +1,35 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_penaltybox_remove(t *testing.T) {
+	now := time.Now()
+	pb := ratelimit.NewPenaltybox("box")
+	pb.Add("client", 10*time.Minute, now)
+	c := &context.Context{
+		FixedTime: &now,
+		Penaltyboxes: map[string]*ratelimit.Penaltybox{
+			"box": pb,
+		},
+	}
+
+	_, err := Testing_penaltybox_remove(
+		c,
+		&value.Ident{Value: "box"},
+		&value.String{Value: "client"},
+	)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if pb.Has("client", now) {
+		t.Errorf("Entry should be removed from the penaltybox")
+	}
+}
@@ -0,0 +1,50 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_ratecounter_clear_Name = "testing.ratecounter_clear"
+
+var Testing_ratecounter_clear_ArgumentTypes = []value.Type{value.IdentType, value.StringType}
+
+func Testing_ratecounter_clear_Validate(args []value.Value) error {
+	if len(args) != 2 {
+		return errors.ArgumentNotEnough(Testing_ratecounter_clear_Name, 2, args)
+	}
+
+	for i := range Testing_ratecounter_clear_ArgumentTypes {
+		if args[i].Type() != Testing_ratecounter_clear_ArgumentTypes[i] {
+			return errors.TypeMismatch(
+				Testing_ratecounter_clear_Name,
+				i+1,
+				Testing_ratecounter_clear_ArgumentTypes[i],
+				args[i].Type(),
+			)
+		}
+	}
+	return nil
+}
+
+// Testing_ratecounter_clear drops all counts of the entry in the ratecounter.
+// Ratecounters are kept across the requests so tests could reset the state with this function.
+func Testing_ratecounter_clear(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_ratecounter_clear_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	name := value.Unwrap[*value.Ident](args[0]).Value
+	rc, ok := ctx.Ratecounters[name]
+	if !ok {
+		return value.Null, errors.NewTestingError("ratecounter %s not found in VCL", name)
+	}
+
+	rc.Clear(value.Unwrap[*value.String](args[1]).Value)
+	return value.Null, nil
+}
@@ -0,0 +1,35 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_ratecounter_clear(t *testing.T) {
+	now := time.Now()
+	rc := ratelimit.NewRatecounter("counter")
+	rc.Increment("client", 10, now)
+	c := &context.Context{
+		FixedTime: &now,
+		Ratecounters: map[string]*ratelimit.Ratecounter{
+			"counter": rc,
+		},
+	}
+
+	_, err := Testing_ratecounter_clear(
+		c,
+		&value.Ident{Value: "counter"},
+		&value.String{Value: "client"},
+	)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	if count := rc.Count("client", 10*time.Second, now); count != 0 {
+		t.Errorf("Count should be cleared, got %d", count)
+	}
+}
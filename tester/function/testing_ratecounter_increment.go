@@ -0,0 +1,53 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_ratecounter_increment_Name = "testing.ratecounter_increment"
+
+var Testing_ratecounter_increment_ArgumentTypes = []value.Type{value.IdentType, value.StringType, value.IntegerType}
+
+func Testing_ratecounter_increment_Validate(args []value.Value) error {
+	if len(args) != 3 {
+		return errors.ArgumentNotEnough(Testing_ratecounter_increment_Name, 3, args)
+	}
+
+	for i := range Testing_ratecounter_increment_ArgumentTypes {
+		if args[i].Type() != Testing_ratecounter_increment_ArgumentTypes[i] {
+			return errors.TypeMismatch(
+				Testing_ratecounter_increment_Name,
+				i+1,
+				Testing_ratecounter_increment_ArgumentTypes[i],
+				args[i].Type(),
+			)
+		}
+	}
+	return nil
+}
+
+// Testing_ratecounter_increment preloads the ratecounter bucket for the entry
+// at the current (possibly fixed) time. Unlike ratelimit.ratecounter_increment,
+// it does not update ratecounter.{NAME}.* variables.
+func Testing_ratecounter_increment(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_ratecounter_increment_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	name := value.Unwrap[*value.Ident](args[0]).Value
+	rc, ok := ctx.Ratecounters[name]
+	if !ok {
+		return value.Null, errors.NewTestingError("ratecounter %s not found in VCL", name)
+	}
+	entry := value.Unwrap[*value.String](args[1]).Value
+	delta := value.Unwrap[*value.Integer](args[2]).Value
+
+	rc.Increment(entry, delta, ctx.Now())
+	return value.Null, nil
+}
@@ -0,0 +1,49 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_ratecounter_increment(t *testing.T) {
+	now := time.Now()
+	rc := ratelimit.NewRatecounter("counter")
+	c := &context.Context{
+		FixedTime: &now,
+		Ratecounters: map[string]*ratelimit.Ratecounter{
+			"counter": rc,
+		},
+	}
+
+	t.Run("Preload ratecounter bucket", func(t *testing.T) {
+		_, err := Testing_ratecounter_increment(
+			c,
+			&value.Ident{Value: "counter"},
+			&value.String{Value: "client"},
+			&value.Integer{Value: 100},
+		)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if count := rc.Count("client", 10*time.Second, now); count != 100 {
+			t.Errorf("Count should be 100, got %d", count)
+		}
+	})
+
+	t.Run("Ratecounter not found", func(t *testing.T) {
+		_, err := Testing_ratecounter_increment(
+			c,
+			&value.Ident{Value: "not_found"},
+			&value.String{Value: "client"},
+			&value.Integer{Value: 100},
+		)
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
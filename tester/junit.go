@@ -0,0 +1,126 @@
+package tester
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// JUnit XML structures
+// see: https://github.com/testmoapp/junitxml
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	File     string          `xml:"file,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// Format milliseconds as JUnit time attribute which is seconds
+func junitTime(msec int64) string {
+	return fmt.Sprintf("%.3f", float64(msec)/1000)
+}
+
+// WriteJUnit writes test results as JUnit XML format.
+// Each testing file and describe block is reported as a testsuite
+func (f *TestFactory) WriteJUnit(w io.Writer) error {
+	root := junitTestSuites{
+		Name: "falco",
+	}
+	var totalTime int64
+
+	for _, r := range f.Results {
+		// Keep the order of suites as appeared in the testing file
+		var suites []*junitTestSuite
+		groups := make(map[string]*junitTestSuite)
+		for _, c := range r.Cases {
+			suite, ok := groups[c.Group]
+			if !ok {
+				name := r.Filename
+				if c.Group != "" {
+					name += " › " + c.Group
+				}
+				suite = &junitTestSuite{
+					Name: name,
+					File: r.Filename,
+				}
+				groups[c.Group] = suite
+				suites = append(suites, suite)
+			}
+
+			className := r.Filename
+			if c.Group != "" {
+				className = c.Group
+			}
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("[%s] %s", c.Scope, c.Name),
+				ClassName: className,
+				Time:      junitTime(c.Time),
+			}
+			if c.Error != nil {
+				failure := newFailureDetail(r, c)
+				tc.File = failure.File
+				tc.Line = failure.Line
+				tc.Failure = &junitFailure{
+					Message: failure.Message,
+					Type:    failure.Type,
+					Body:    failure.String(),
+				}
+				suite.Failures++
+				root.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+			suite.Tests++
+			root.Tests++
+			totalTime += c.Time
+		}
+
+		for _, s := range suites {
+			var t int64
+			for _, c := range r.Cases {
+				if groups[c.Group] == s {
+					t += c.Time
+				}
+			}
+			s.Time = junitTime(t)
+			root.Suites = append(root.Suites, *s)
+		}
+	}
+	root.Time = junitTime(totalTime)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,53 @@
+package tester
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+	"github.com/ysugimoto/falco/token"
+)
+
+func testFactory() *TestFactory {
+	failure := errors.NewAssertionError(&value.String{Value: "bar"}, "expect=foo, actual=bar")
+	failure.Token = token.Token{File: "default.test.vcl", Line: 10, Position: 3}
+
+	return &TestFactory{
+		Results: []*TestResult{
+			{
+				Filename: "default.test.vcl",
+				Cases: []*TestCase{
+					{Name: "test_recv", Scope: "RECV", Time: 12},
+					{Name: "test_fetch", Group: "backend", Scope: "FETCH", Time: 5, Error: failure},
+				},
+			},
+		},
+		Statistics: &TestCounter{Asserts: 2, Passes: 1, Fails: 1},
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testFactory().WriteJUnit(&buf); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expect := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="falco" tests="2" failures="1" time="0.017">
+  <testsuite name="default.test.vcl" tests="1" failures="0" time="0.012" file="default.test.vcl">
+    <testcase name="[RECV] test_recv" classname="default.test.vcl" time="0.012"></testcase>
+  </testsuite>
+  <testsuite name="default.test.vcl › backend" tests="1" failures="1" time="0.005" file="default.test.vcl">
+    <testcase name="[FETCH] test_fetch" classname="backend" time="0.005" file="default.test.vcl" line="10">
+      <failure message="expect=foo, actual=bar" type="AssertionError"><![CDATA[expect=foo, actual=bar
+Actual Value: bar
+at default.test.vcl:10:3]]></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if buf.String() != expect {
+		t.Errorf("JUnit output mismatch, expect=\n%s\ngot=\n%s", expect, buf.String())
+	}
+}
@@ -0,0 +1,150 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+// Extension of snapshot file, the file is placed next to the testing file
+// like "default.test.vcl.snap"
+const Extension = ".snap"
+
+// Snapshot is recorded response state
+type Snapshot struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+}
+
+// FromResponse creates snapshot from the HTTP response.
+// Response body is read and restored so that the response could be used after taking snapshot
+func FromResponse(resp *http.Response) (*Snapshot, error) {
+	s := &Snapshot{
+		Status:  resp.StatusCode,
+		Headers: make(map[string][]string),
+	}
+	for key, values := range resp.Header {
+		s.Headers[key] = append([]string{}, values...)
+	}
+	if resp.Body != nil {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		s.Body = string(body)
+	}
+	return s, nil
+}
+
+// String renders snapshot as HTTP response-like text which is used for the diff
+func (s *Snapshot) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s\n", s.Status, http.StatusText(s.Status))
+
+	keys := make([]string, 0, len(s.Headers))
+	for key := range s.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, v := range s.Headers[key] {
+			fmt.Fprintf(&b, "%s: %s\n", key, v)
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(s.Body)
+	return b.String()
+}
+
+// Store manages snapshots for a testing file
+type Store struct {
+	mu        sync.Mutex
+	file      string
+	update    bool
+	snapshots map[string]*Snapshot
+	dirty     bool
+}
+
+// Load reads snapshot file for the testing file.
+// If the snapshot file does not exist, empty store is returned
+func Load(testFile string, update bool) (*Store, error) {
+	s := &Store{
+		file:      testFile + Extension,
+		update:    update,
+		snapshots: make(map[string]*Snapshot),
+	}
+	buf, err := os.ReadFile(s.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	if err := json.Unmarshal(buf, &s.snapshots); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse snapshot file %s", s.file)
+	}
+	return s, nil
+}
+
+// Recorded returns true if the snapshot is recorded for the name
+func (s *Store) Recorded(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.snapshots[name]
+	return ok
+}
+
+// Match compares the actual snapshot with the recorded one.
+// If the store is in update mode, actual snapshot is recorded and treated as matched.
+// Otherwise returns the diff between recorded and actual snapshots when they are not matched,
+// the snapshot which is not recorded yet is treated as empty so that missing snapshot fails on CI
+func (s *Store) Match(name string, actual *Snapshot) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expect string
+	if v, ok := s.snapshots[name]; ok {
+		expect = v.String()
+	}
+	diff := cmp.Diff(expect, actual.String())
+	if diff == "" {
+		return "", true
+	}
+	if !s.update {
+		return diff, false
+	}
+	s.snapshots[name] = actual
+	s.dirty = true
+	return "", true
+}
+
+// Save writes snapshots to the file if some snapshots are recorded
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	buf, err := json.MarshalIndent(s.snapshots, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(s.file, append(buf, '\n'), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,129 @@
+package snapshot
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testResponse(header string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"X-Foo": {header},
+			"X-Bar": {"bar"},
+		},
+		Body: io.NopCloser(strings.NewReader("body")),
+	}
+}
+
+func TestFromResponse(t *testing.T) {
+	resp := testResponse("foo")
+	s, err := FromResponse(resp)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expect := "200 OK\nX-Bar: bar\nX-Foo: foo\n\nbody"
+	if s.String() != expect {
+		t.Errorf("Snapshot string mismatch, expect=%q, got=%q", expect, s.String())
+	}
+	// Body must be restored
+	body, _ := io.ReadAll(resp.Body) // nolint:errcheck
+	if string(body) != "body" {
+		t.Errorf("Response body should be restored, got=%s", string(body))
+	}
+}
+
+func TestStore(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "default.test.vcl")
+
+	t.Run("Missing snapshot", func(t *testing.T) {
+		store, err := Load(testFile, false)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		s, _ := FromResponse(testResponse("foo")) // nolint:errcheck
+		if _, ok := store.Match("deliver", s); ok {
+			t.Errorf("Missing snapshot should not be matched")
+		}
+		if store.Recorded("deliver") {
+			t.Errorf("Missing snapshot should not be recorded")
+		}
+		if err := store.Save(); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if _, err := os.Stat(testFile + Extension); !os.IsNotExist(err) {
+			t.Errorf("Snapshot file should not be written")
+		}
+	})
+
+	t.Run("Record new snapshot", func(t *testing.T) {
+		store, err := Load(testFile, true)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		s, _ := FromResponse(testResponse("foo")) // nolint:errcheck
+		if _, ok := store.Match("deliver", s); !ok {
+			t.Errorf("New snapshot should be matched")
+		}
+		if err := store.Save(); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if _, err := os.Stat(testFile + Extension); err != nil {
+			t.Errorf("Snapshot file should be written: %s", err)
+		}
+	})
+
+	t.Run("Match recorded snapshot", func(t *testing.T) {
+		store, err := Load(testFile, false)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		s, _ := FromResponse(testResponse("foo")) // nolint:errcheck
+		if _, ok := store.Match("deliver", s); !ok {
+			t.Errorf("Recorded snapshot should be matched")
+		}
+		s, _ = FromResponse(testResponse("baz")) // nolint:errcheck
+		diff, ok := store.Match("deliver", s)
+		if ok {
+			t.Errorf("Changed snapshot should not be matched")
+		}
+		if !strings.Contains(diff, "X-Foo: baz") {
+			t.Errorf("Diff should contain changed header, got=%s", diff)
+		}
+	})
+
+	t.Run("Update snapshot", func(t *testing.T) {
+		store, err := Load(testFile, true)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		s, _ := FromResponse(testResponse("baz")) // nolint:errcheck
+		if _, ok := store.Match("deliver", s); !ok {
+			t.Errorf("Snapshot should be updated")
+		}
+		if err := store.Save(); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+
+		store, err = Load(testFile, false)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if _, ok := store.Match("deliver", s); !ok {
+			t.Errorf("Updated snapshot should be matched")
+		}
+	})
+}
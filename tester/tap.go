@@ -0,0 +1,72 @@
+package tester
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTAP writes test results as TAP version 13 format.
+// Failure details are reported as YAML diagnostic block
+// see: https://testanything.org/tap-version-13-specification.html
+func (f *TestFactory) WriteTAP(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+
+	var total int
+	for _, r := range f.Results {
+		total += len(r.Cases)
+	}
+	fmt.Fprintln(buf, "TAP version 13")
+	fmt.Fprintf(buf, "1..%d\n", total)
+
+	var index int
+	for _, r := range f.Results {
+		for _, c := range r.Cases {
+			index++
+			name := c.Name
+			if c.Group != "" {
+				name = c.Group + " › " + name
+			}
+			description := fmt.Sprintf("%s [%s] %s", r.Filename, c.Scope, name)
+			if c.Error == nil {
+				fmt.Fprintf(buf, "ok %d - %s\n", index, description)
+				fmt.Fprintln(buf, "  ---")
+				fmt.Fprintf(buf, "  duration_ms: %d\n", c.Time)
+				fmt.Fprintln(buf, "  ...")
+				continue
+			}
+
+			d := newFailureDetail(r, c)
+			fmt.Fprintf(buf, "not ok %d - %s\n", index, description)
+			fmt.Fprintln(buf, "  ---")
+			fmt.Fprintf(buf, "  message: %s\n", tapQuote(d.Message))
+			fmt.Fprintf(buf, "  severity: fail\n")
+			fmt.Fprintf(buf, "  type: %s\n", d.Type)
+			if d.Actual != "" {
+				fmt.Fprintf(buf, "  actual: %s\n", tapQuote(d.Actual))
+			}
+			fmt.Fprintln(buf, "  at:")
+			fmt.Fprintf(buf, "    file: %s\n", tapQuote(d.File))
+			if d.Line > 0 {
+				fmt.Fprintf(buf, "    line: %d\n", d.Line)
+				fmt.Fprintf(buf, "    column: %d\n", d.Position)
+			}
+			fmt.Fprintf(buf, "  duration_ms: %d\n", c.Time)
+			fmt.Fprintln(buf, "  ...")
+		}
+	}
+
+	return buf.Flush()
+}
+
+// JSON string is valid for YAML double-quoted scalar
+func tapQuote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) // nolint:errcheck
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,39 @@
+package tester
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteTAP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testFactory().WriteTAP(&buf); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	expect := strings.Join([]string{
+		"TAP version 13",
+		"1..2",
+		"ok 1 - default.test.vcl [RECV] test_recv",
+		"  ---",
+		"  duration_ms: 12",
+		"  ...",
+		"not ok 2 - default.test.vcl [FETCH] backend › test_fetch",
+		"  ---",
+		`  message: "expect=foo, actual=bar"`,
+		"  severity: fail",
+		"  type: AssertionError",
+		`  actual: "bar"`,
+		"  at:",
+		`    file: "default.test.vcl"`,
+		"    line: 10",
+		"    column: 3",
+		"  duration_ms: 5",
+		"  ...",
+		"",
+	}, "\n")
+	if buf.String() != expect {
+		t.Errorf("TAP output mismatch, expect=\n%s\ngot=\n%s", expect, buf.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,13 +14,14 @@ import (
 	"github.com/ysugimoto/falco/config"
 	"github.com/ysugimoto/falco/interpreter"
 	icontext "github.com/ysugimoto/falco/interpreter/context"
-	"github.com/ysugimoto/falco/interpreter/function"
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"github.com/ysugimoto/falco/interpreter/variable"
 	"github.com/ysugimoto/falco/lexer"
 	"github.com/ysugimoto/falco/parser"
 	"github.com/ysugimoto/falco/resolver"
 	tf "github.com/ysugimoto/falco/tester/function"
+	"github.com/ysugimoto/falco/tester/snapshot"
 	"github.com/ysugimoto/falco/tester/syntax"
 	tv "github.com/ysugimoto/falco/tester/variable"
 )
@@ -32,17 +34,29 @@ var (
 type Tester struct {
 	interpreterOptions []icontext.Option
 	config             *config.TestConfig
-	counter            *TestCounter
-	debugger           *Debugger
+	coverage           *coverage.Collector
+}
+
+// Running state for each test file.
+// Counter and debugger are separated per file in order to run test files in parallel,
+// and they are aggregated in the file order after all tests have finished.
+type testRun struct {
+	counter   *TestCounter
+	debugger  *Debugger
+	snapshots *snapshot.Store
 }
 
 func New(c *config.TestConfig, opts []icontext.Option) *Tester {
-	return &Tester{
+	t := &Tester{
 		interpreterOptions: opts,
 		config:             c,
-		counter:            NewTestCounter(),
-		debugger:           NewDebugger(),
 	}
+	if c.Coverage {
+		t.coverage = coverage.New()
+	}
+	// Testing variables are stateless so we can inject them globally at once
+	variable.Inject(&tv.TestingVariables{})
+	return t
 }
 
 // Find test target VCL files
@@ -73,25 +87,56 @@ func (t *Tester) Run(main string) (*TestFactory, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	// Run tests
-	var results []*TestResult
+
+	type output struct {
+		result *TestResult
+		run    *testRun
+		err    error
+	}
+
+	// Run tests, each test file runs on separated interpreters
+	// so independent files could run concurrently up to parallel option
+	parallel := max(t.config.Parallel, 1)
+	outputs := make([]output, len(targetFiles))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
 	for i := range targetFiles {
-		result, err := t.run(targetFiles[i])
-		if err != nil {
-			return nil, errors.WithStack(err)
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			run := &testRun{
+				counter:  NewTestCounter(),
+				debugger: NewDebugger(),
+			}
+			result, err := t.run(run, targetFiles[i])
+			outputs[i] = output{result: result, run: run, err: err}
+		}(i)
+	}
+	wg.Wait()
+
+	// Aggregate results in file order to keep output deterministic
+	factory := &TestFactory{
+		Statistics: NewTestCounter(),
+		Coverage:   t.coverage,
+	}
+	for i := range outputs {
+		if outputs[i].err != nil {
+			return nil, errors.WithStack(outputs[i].err)
 		}
-		results = append(results, result)
+		factory.Results = append(factory.Results, outputs[i].result)
+		factory.Statistics.Merge(outputs[i].run.counter)
+		factory.Logs = append(factory.Logs, outputs[i].run.debugger.Logs()...)
 	}
 
-	return &TestFactory{
-		Results:    results,
-		Statistics: t.counter,
-		Logs:       t.debugger.stack,
-	}, nil
+	return factory, nil
 }
 
 // Actually run testing method
-func (t *Tester) run(testFile string) (*TestResult, error) {
+func (t *Tester) run(run *testRun, testFile string) (*TestResult, error) {
 	resolvers, err := resolver.NewFileResolvers(testFile, t.config.IncludePaths)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -108,6 +153,12 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 		return nil, errors.WithStack(err)
 	}
 
+	snapshots, err := snapshot.Load(testFile, t.config.UpdateSnapshots)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	run.snapshots = snapshots
+
 	errChan := make(chan error)
 	finishChan := make(chan []*TestCase)
 
@@ -124,7 +175,7 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 		for _, stmt := range vcl.Statements {
 			switch st := stmt.(type) {
 			case *syntax.DescribeStatement:
-				results, err := t.runDescribedTests(defs, st)
+				results, err := t.runDescribedTests(run, defs, st)
 				if len(results) > 0 {
 					cases = append(cases, results...)
 				}
@@ -135,7 +186,7 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 			case *ast.SubroutineDeclaration:
 				// Some functions like "testing.table_set()" will take side-effect for another testing subroutine
 				// so we always initialize interpreter, inject testing functions for each subroutine
-				i := t.setupInterpreter(defs)
+				i := t.setupInterpreter(run, defs)
 
 				mockRequest := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
 				if err := i.TestProcessInit(mockRequest); err != nil {
@@ -153,12 +204,17 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 						Time:  time.Since(start).Milliseconds(),
 					})
 					if err != nil {
-						t.counter.Fail()
+						run.counter.Fail()
 					}
 				}
 			}
 		}
 
+		// Write recorded snapshots after all tests in the file have finished
+		if err := snapshots.Save(); err != nil {
+			errChan <- errors.WithStack(err)
+			return
+		}
 		finishChan <- cases
 	}(vcl)
 
@@ -178,6 +234,7 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 }
 
 func (t *Tester) runDescribedTests(
+	run *testRun,
 	defs *tf.Definiions,
 	d *syntax.DescribeStatement,
 ) ([]*TestCase, error) {
@@ -186,7 +243,7 @@ func (t *Tester) runDescribedTests(
 	mockRequest := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
 
 	// describe should run as group testing, create interpreter once through tests
-	i := t.setupInterpreter(defs)
+	i := t.setupInterpreter(run, defs)
 
 	if err := i.TestProcessInit(mockRequest); err != nil {
 		return cases, err
@@ -229,7 +286,7 @@ func (t *Tester) runDescribedTests(
 				Time:  time.Since(start).Milliseconds(),
 			})
 			if err != nil {
-				t.counter.Fail()
+				run.counter.Fail()
 			}
 
 			// Run after_xxx hook that corresponds to scope is exists
@@ -311,9 +368,10 @@ func (t *Tester) findTestSuites(sub *ast.SubroutineDeclaration) (string, []icont
 }
 
 // Set up interprete for each test subroutines
-func (t *Tester) setupInterpreter(defs *tf.Definiions) *interpreter.Interpreter {
+func (t *Tester) setupInterpreter(run *testRun, defs *tf.Definiions) *interpreter.Interpreter {
 	i := interpreter.New(t.interpreterOptions...)
-	i.Debugger = t.debugger
+	i.Debugger = run.debugger
+	i.Coverage = t.coverage
 	i.IdentResolver = func(val string) value.Value {
 		if v, ok := defs.Backends[val]; ok {
 			return v
@@ -327,8 +385,9 @@ func (t *Tester) setupInterpreter(defs *tf.Definiions) *interpreter.Interpreter
 		}
 		return nil
 	}
-	variable.Inject(&tv.TestingVariables{})
-	function.Inject(tf.TestingFunctions(i, defs, t.counter))
+	// Testing functions hold the interpreter and definitions,
+	// so inject them into the interpreter instance rather than globally
+	i.InjectFunctions(tf.TestingFunctions(i, defs, run.counter, run.snapshots))
 
 	return i
 }
@@ -0,0 +1,53 @@
+package tester
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ysugimoto/falco/config"
+	icontext "github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestRunParallel(t *testing.T) {
+	main := filepath.Join("testdata", "parallel", "main.vcl")
+	rslv, err := resolver.NewFileResolvers(main, []string{})
+	if err != nil {
+		t.Errorf("Failed to create resolver: %s", err)
+		return
+	}
+
+	for _, parallel := range []int{1, 4} {
+		t.Run(fmt.Sprintf("parallel=%d", parallel), func(t *testing.T) {
+			c := &config.TestConfig{
+				Filter:          "*.test.vcl",
+				Parallel:        parallel,
+				OverrideRequest: &config.RequestConfig{},
+			}
+			factory, err := New(c, []icontext.Option{icontext.WithResolver(rslv[0])}).Run(main)
+			if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+
+			files := []string{"a", "b", "c", "d", "e", "f"}
+			if len(factory.Results) != len(files) {
+				t.Errorf("Results length mismatch, expect=%d, got=%d", len(files), len(factory.Results))
+				return
+			}
+			for i, name := range files {
+				expect := name + ".test.vcl"
+				if filepath.Base(factory.Results[i].Filename) != expect {
+					t.Errorf("Results must be ordered by file, expect=%s, got=%s", expect, factory.Results[i].Filename)
+				}
+				if !factory.Results[i].IsPassed() {
+					t.Errorf("Tests in %s should pass", factory.Results[i].Filename)
+				}
+			}
+			if factory.Statistics.Asserts != 18 || factory.Statistics.Passes != 18 || factory.Statistics.Fails != 0 {
+				t.Errorf("Statistics mismatch, got=%+v", factory.Statistics)
+			}
+		})
+	}
+}